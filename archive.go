@@ -0,0 +1,55 @@
+package zipper
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+)
+
+// archive is an open zip archive, possibly split across volumes.
+type archive struct {
+	*zip.Reader
+	ra     io.ReaderAt
+	size   int64
+	closer io.Closer
+}
+
+func (a *archive) Close() error {
+	return a.closer.Close()
+}
+
+// openArchive opens the archive at zipPath, joining split volumes.
+func openArchive(zipPath string) (*archive, error) {
+	volumes, err := openVolumes(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	if volumes != nil {
+		r, err := zip.NewReader(volumes, volumes.size)
+		if err != nil {
+			volumes.Close()
+			return nil, err
+		}
+		r.RegisterDecompressor(zipZstd, decompressZstd)
+		return &archive{Reader: r, ra: volumes, size: volumes.size, closer: volumes}, nil
+	}
+
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.RegisterDecompressor(zipZstd, decompressZstd)
+	return &archive{Reader: r, ra: f, size: info.Size(), closer: f}, nil
+}
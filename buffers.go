@@ -0,0 +1,91 @@
+package zipper
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+const (
+	// defaultReadBufferSize is the size of the buffer file contents are
+	// copied through, as io.Copy uses.
+	defaultReadBufferSize = 32 << 10
+
+	// defaultWriteBufferSize is the size of the buffer in front of the
+	// archive or extracted file being written, as bufio uses. It is also
+	// the smallest, which lets zip.Writer use the buffer as its own.
+	defaultWriteBufferSize = 4 << 10
+)
+
+// WithBufferSizes sets the sizes of the buffers Zip and Unzip move data
+// through: read for copying the contents of each file or entry, and write
+// for buffering the archive or extracted file being written. Larger
+// buffers mean fewer system calls at the cost of memory per file in
+// flight. The defaults are 32 KiB and 4 KiB, and zero keeps the default;
+// write buffers smaller than 4 KiB are raised to 4 KiB.
+//
+// Buffers are pooled and reused across entries and calls, so servers
+// archiving many requests at once do not allocate them afresh each time.
+func WithBufferSizes(read, write int) Option {
+	return func(o *options) {
+		o.readBufferSize = read
+		o.writeBufferSize = write
+	}
+}
+
+// bufferPools holds a pool of *[]byte for each read buffer size in use,
+// and writerPools a pool of *bufio.Writer for each write buffer size.
+var bufferPools, writerPools sync.Map
+
+// pool returns the pool for size in pools, creating it with newFn.
+func pool(pools *sync.Map, size int, newFn func() any) *sync.Pool {
+	if p, ok := pools.Load(size); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := pools.LoadOrStore(size, &sync.Pool{New: newFn})
+	return p.(*sync.Pool)
+}
+
+// copy copies src to dst through a pooled read buffer.
+func (o *options) copy(dst io.Writer, src io.Reader) (int64, error) {
+	size := o.readBufferSize
+	if size <= 0 {
+		size = defaultReadBufferSize
+	}
+
+	p := pool(&bufferPools, size, func() any {
+		b := make([]byte, size)
+		return &b
+	})
+	buf := p.Get().(*[]byte)
+	defer p.Put(buf)
+
+	if o.ctx != nil {
+		src = &contextReader{ctx: o.ctx, r: src}
+	}
+
+	// hide ReadFrom and WriteTo, which would bring their own buffers
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, *buf)
+}
+
+// bufferedWriter returns a pooled writer buffering writes to w. It must
+// be flushed, and released once done.
+func (o *options) bufferedWriter(w io.Writer) *bufio.Writer {
+	size := max(o.writeBufferSize, defaultWriteBufferSize)
+
+	bw := pool(&writerPools, size, func() any {
+		return bufio.NewWriterSize(nil, size)
+	}).Get().(*bufio.Writer)
+	bw.Reset(w)
+	return bw
+}
+
+// releaseWriter returns a writer from bufferedWriter to its pool,
+// discarding anything not flushed.
+func releaseWriter(bw *bufio.Writer) {
+	size := bw.Size()
+	bw.Reset(nil)
+	if p, ok := writerPools.Load(size); ok {
+		p.(*sync.Pool).Put(bw)
+	}
+}
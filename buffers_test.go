@@ -0,0 +1,73 @@
+package zipper
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestWithBufferSizes(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		read, write int
+	}{
+		{"defaults", 0, 0},
+		{"tiny", 7, 100},
+		{"large", 1 << 20, 1 << 20},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "buffered")
+			writeTree(t, src, map[string]string{
+				"a.txt":     strings.Repeat("alpha ", 10000),
+				"dir/b.txt": "beta",
+			})
+			opts := []Option{WithBufferSizes(tc.read, tc.write)}
+
+			zipPath := zipAside(t, src, opts...)
+			dest := t.TempDir()
+			if err := Unzip(zipPath, dest, opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			zipptest.AssertTreesEqual(t, src, dest)
+
+			tarPath := zipAside(t, src, append(opts, WithFormat(FormatTarGz))...)
+			dest = t.TempDir()
+			if err := Extract(tarPath, dest, opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			zipptest.AssertTreesEqual(t, src, dest)
+		})
+	}
+}
+
+func TestBufferedWriter(t *testing.T) {
+	o := newOptions([]Option{WithBufferSizes(0, 100)})
+
+	var out bytes.Buffer
+	bw := o.bufferedWriter(&out)
+	if bw.Size() != defaultWriteBufferSize {
+		t.Errorf("expected small buffers raised to %d, got %d", defaultWriteBufferSize, bw.Size())
+	}
+
+	bw.WriteString("buffered")
+	if out.Len() != 0 {
+		t.Error("expected writes to be buffered")
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	releaseWriter(bw)
+	if out.String() != "buffered" {
+		t.Errorf("expected the flushed contents, got %q", out.String())
+	}
+
+	// a released writer no longer refers to the old destination
+	bw = o.bufferedWriter(&bytes.Buffer{})
+	defer releaseWriter(bw)
+	if bw.Buffered() != 0 {
+		t.Error("expected a pooled writer to start empty")
+	}
+}
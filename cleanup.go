@@ -0,0 +1,95 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempExts are the extensions of the archives Zip writes, which name its
+// temporary files with tempSuffix appended.
+var tempExts = []string{".zip", ".gz", ".tar.zst", ".sfx", ".exe"}
+
+// Cleanup removes orphaned temporary archives (files such as "a.zip.tmp")
+// left in dir by runs that crashed or were killed before finishing. Only
+// files last modified more than olderThan ago are removed, so archives
+// still being written by a live process are left alone. Partial archives
+// kept for WithResume, which have a journal beside them, are left alone
+// too.
+//
+// It returns the paths of the removed files.
+func Cleanup(dir string, olderThan time.Duration) ([]string, error) {
+	return removeStale(dir, olderThan, func(path string) bool {
+		if !isTempArchive(filepath.Base(path)) {
+			return false
+		}
+		_, err := os.Stat(strings.TrimSuffix(path, tempSuffix) + journalSuffix)
+		return err != nil
+	})
+}
+
+// CleanupSpill removes orphaned chunks of compressed data (files such as
+// "zipper-spill-123") that concurrent compression spilled to os.TempDir
+// in runs that crashed or were killed before finishing, on the same terms
+// as Cleanup. Nothing else in os.TempDir is touched.
+//
+// It returns the paths of the removed files.
+func CleanupSpill(olderThan time.Duration) ([]string, error) {
+	return removeStale(os.TempDir(), olderThan, func(path string) bool {
+		return strings.HasPrefix(filepath.Base(path), spillPrefix)
+	})
+}
+
+// removeStale removes the files in dir that orphaned selects and that
+// were last modified more than olderThan ago, returning their paths.
+func removeStale(dir string, olderThan time.Duration, orphaned func(path string) bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := make([]string, 0)
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || !orphaned(path) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			// removed by someone else since listing
+			if os.IsNotExist(err) {
+				continue
+			}
+			return removed, err
+		}
+
+		if info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
+
+// isTempArchive reports whether name is that of a temporary archive.
+func isTempArchive(name string) bool {
+	stem, ok := strings.CutSuffix(name, tempSuffix)
+	if !ok {
+		return false
+	}
+	for _, ext := range tempExts {
+		if strings.HasSuffix(stem, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+
+	files := map[string]time.Time{
+		"stale.zip.tmp":       old,
+		"stale.tar.gz.tmp":    old,
+		"active.zip.tmp":      time.Now(),
+		"keep.zip":            old,
+		"notes.tmp":           old,
+		"resumed.zip.tmp":     old,
+		"resumed.zip.journal": old,
+		"zipper-spill-123":    old,
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Cleanup(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stale := map[string]bool{"stale.zip.tmp": true, "stale.tar.gz.tmp": true}
+	if len(removed) != len(stale) {
+		t.Errorf("expected only %v to be removed, got %v", stale, removed)
+	}
+
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		exists := err == nil
+		if want := !stale[name]; exists != want {
+			t.Errorf("%s: expected exists=%v, got %v", name, want, exists)
+		}
+	}
+}
+
+func TestCleanupSpill(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	old := time.Now().Add(-2 * time.Hour)
+
+	files := map[string]time.Time{
+		"zipper-spill-123": old,
+		"zipper-spill-456": time.Now(),
+		"other.zip.tmp":    old,
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("chunk"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := CleanupSpill(time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "zipper-spill-123" {
+		t.Errorf("expected only the stale spill chunk removed, got %v", removed)
+	}
+}
+
+func TestCleanupMissingDir(t *testing.T) {
+	if _, err := Cleanup(filepath.Join(t.TempDir(), "missing"), 0); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
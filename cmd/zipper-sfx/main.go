@@ -0,0 +1,37 @@
+// Command zipper-sfx is the extractor stub of self-extracting archives.
+// Built on its own it does nothing useful; zipper.WithSelfExtractor
+// appends an archive to it, and running the result extracts that archive.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+func main() {
+	dest := flag.String("d", ".", "directory to extract into")
+	overwrite := flag.Bool("overwrite", false, "replace existing files")
+	flag.Parse()
+
+	// the archive is appended to this executable
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error locating archive: %v\n", err)
+		os.Exit(1)
+	}
+
+	var opts []zipper.Option
+	if *overwrite {
+		opts = append(opts, zipper.WithOverwrite(zipper.OverwriteAlways))
+	}
+
+	if err := zipper.Unzip(exe, *dest, opts...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error extracting: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("successfully extracted to: %s\n", *dest)
+}
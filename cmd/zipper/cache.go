@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runCache implements the cache command, printing the compression ratios
+// learned per extension or resetting them.
+func runCache(args []string) {
+	flags := flag.NewFlagSet("cache", flag.ExitOnError)
+	reset := flags.Bool("reset", false, "forget all learned ratios")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper cache [-reset]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	path, err := zipper.DefaultStatsCachePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error locating cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	cache, err := zipper.LoadStatsCache(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading cache %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	if *reset {
+		cache.Reset()
+		if err := cache.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error resetting cache %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("reset: %s\n", path)
+		return
+	}
+
+	fmt.Printf("cache: %s\n", path)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXTENSION\tSAMPLES\tUNCOMPRESSED\tCOMPRESSED\tRATIO")
+	for _, s := range cache.Stats() {
+		ext := s.Extension
+		if ext == "" {
+			ext = "(none)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f\n", ext, s.Samples, s.Uncompressed, s.Compressed, s.Ratio())
+	}
+	w.Flush()
+}
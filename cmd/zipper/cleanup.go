@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runCleanup implements the cleanup command, removing orphaned temporary
+// archives from each directory given, and orphaned spill chunks from the
+// system's temporary directory with -spill. Given no directory, it cleans
+// the current directory and the spill chunks.
+func runCleanup(args []string) {
+	flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	age := flags.Duration("age", time.Hour, "only remove files older than this")
+	spill := flags.Bool("spill", false, "also remove spill chunks from the temporary directory (the default without dir)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper cleanup [-age duration] [-spill] [dir ...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+		*spill = true
+	}
+
+	failed := false
+	for _, dir := range dirs {
+		removed, err := zipper.Cleanup(dir, *age)
+		for _, path := range removed {
+			fmt.Printf("removed: %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning %s: %v\n", dir, err)
+			failed = true
+		}
+	}
+
+	if *spill {
+		removed, err := zipper.CleanupSpill(*age)
+		for _, path := range removed {
+			fmt.Printf("removed: %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning %s: %v\n", os.TempDir(), err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
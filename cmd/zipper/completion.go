@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// command describes a command's flags for shell completion, split into
+// those that take a value and those that do not.
+type command struct {
+	Name   string
+	Bools  []string
+	Values []string
+}
+
+// Flags shared by several commands.
+var (
+	reporterFlags = []string{"v", "q", "progress", "json"}
+	passwordFlags = []string{"password", "P"}
+)
+
+// commands lists the commands and their flags for shell completion, zip,
+// the default, first.
+var commands = []command{
+	{
+		Name: "zip",
+		Bools: append([]string{
+			"c", "force", "sync", "dry-run", "dedup", "hard-links", "sparse", "reproducible", "preserve-ownership",
+			"one-file-system", "skip-hidden", "keep-mac-metadata", "resume", "encrypt",
+		}, reporterFlags...),
+		Values: append([]string{
+			"o", "output", "include", "exclude", "verify", "sfx", "max-depth", "min-size",
+			"max-size", "root-dir", "normalize", "flatten", "duplicates", "special", "format", "method", "level", "order", "workers",
+			"max-open-files", "bwlimit", "base",
+		}, passwordFlags...),
+	},
+	{
+		Name:   "extract",
+		Bools:  append([]string{"u", "sync", "ignore-modes", "allow-special-bits", "preserve-ownership", "skip-mac-metadata"}, reporterFlags...),
+		Values: append([]string{"C", "overwrite", "cleanup", "workers", "include", "exclude", "component", "normalize", "duplicates", "mode-mask", "default-mode", "special", "remap"}, passwordFlags...),
+	},
+	{Name: "list", Bools: []string{"long"}, Values: []string{"sort"}},
+	{Name: "test", Bools: []string{"q"}, Values: passwordFlags},
+	{Name: "diff", Bools: []string{"q"}},
+	{Name: "stats", Values: []string{"top"}},
+	{Name: "find", Bools: []string{"long"}},
+	{Name: "scan"},
+	{Name: "cleanup", Bools: []string{"spill"}, Values: []string{"age"}},
+	{Name: "cache", Bools: []string{"reset"}},
+	{Name: "completion"},
+	{Name: "help"},
+}
+
+// flagChoices lists the values of flags taking one of a few words.
+var flagChoices = map[string][]string{
+	"format":     {"zip", "tar.gz", "tar.zst", "gz"},
+	"method":     {"deflate", "store", "zstd", "auto"},
+	"order":      {"walk", "name", "dirs-first", "largest-first"},
+	"flatten":    {"error", "suffix", "keep-first", "keep-last"},
+	"duplicates": {"error", "suffix", "keep-first", "keep-last"},
+	"normalize":  {"nfc", "nfd"},
+	"special":    {"skip", "error", "recreate", "read"},
+	"overwrite":  {"error", "skip", "always", "if-newer", "rename"},
+	"cleanup":    {"keep", "rollback", "mark"},
+	"sort":       {"name", "size", "compressed", "ratio", "modified"},
+}
+
+// runCompletion implements the completion command, printing a completion
+// script for a shell.
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: zipper completion bash|zsh|fish")
+		os.Exit(1)
+	}
+
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unsupported shell %q\n", args[0])
+		os.Exit(1)
+	}
+
+	funcs := template.FuncMap{"join": strings.Join}
+	t := template.Must(template.New(args[0]).Funcs(funcs).Parse(script))
+	data := struct {
+		Commands []command
+		Names    string
+		Others   string // the commands other than zip
+		Choices  map[string][]string
+	}{commands, commandNames(), strings.TrimPrefix(commandNames(), "zip "), flagChoices}
+	if err := t.Execute(os.Stdout, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runEntries implements the hidden __entries command the completion
+// scripts call, printing the entry names of an archive.
+func runEntries(args []string) {
+	if len(args) != 1 {
+		os.Exit(1)
+	}
+	entries, err := zipper.List(args[0])
+	if err != nil {
+		os.Exit(1)
+	}
+	for _, e := range entries {
+		fmt.Println(e.Name)
+	}
+}
+
+// commandNames returns the names of the commands, space separated.
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// completionScripts are the templates of the completion scripts by shell.
+// The names of archive entries are completed for the -include and
+// -exclude flags of extract.
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  "#compdef zipper\nautoload -U +X bashcompinit && bashcompinit\n" + bashCompletion,
+	"fish": fishCompletion,
+}
+
+const bashCompletion = `# bash completion for zipper, from "zipper completion bash"
+
+_zipper() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local cmd=zip start=1 bools values
+	case ${COMP_WORDS[1]} in
+{{- range .Commands}}
+	{{.Name}}) cmd={{.Name}} start=2 ;;
+{{- end}}
+	esac
+
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "{{.Names}}" -- "$cur") $(compgen -f -- "$cur"))
+		return
+	fi
+
+	case $cmd in
+{{- range .Commands}}
+	{{.Name}}) bools="{{join .Bools " "}}" values="{{join .Values " "}}" ;;
+{{- end}}
+	esac
+
+	local p=${prev#-}
+	p=${p#-}
+	if [[ $prev == -* && " $values " == *" $p "* ]]; then
+		case $p in
+{{- range $flag, $choices := .Choices}}
+		{{$flag}}) COMPREPLY=($(compgen -W "{{join $choices " "}}" -- "$cur")) ;;
+{{- end}}
+		include|exclude)
+			if [[ $cmd == extract ]]; then
+				local IFS=$'\n'
+				COMPREPLY=($(compgen -W "$(_zipper_entries $start "$values")" -- "$cur"))
+			fi
+			;;
+		*) COMPREPLY=($(compgen -f -- "$cur")) ;;
+		esac
+		return
+	fi
+
+	if [[ $cur == -* ]]; then
+		local f words=
+		for f in $bools $values; do
+			words+=" -$f"
+		done
+		COMPREPLY=($(compgen -W "$words" -- "$cur"))
+		return
+	fi
+	COMPREPLY=($(compgen -f -- "$cur"))
+}
+
+# _zipper_entries prints the entries of the archive named by the first
+# word from $1 on that is neither a flag nor the value of one in $2.
+_zipper_entries() {
+	local i w n skip=
+	for ((i = $1; i < COMP_CWORD; i++)); do
+		w=${COMP_WORDS[i]}
+		if [[ -n $skip ]]; then
+			skip=
+			continue
+		fi
+		if [[ $w == -* ]]; then
+			n=${w#-}
+			n=${n#-}
+			[[ $w != *=* && " $2 " == *" $n "* ]] && skip=1
+			continue
+		fi
+		zipper __entries "$w" 2>/dev/null
+		return
+	done
+}
+
+complete -o filenames -F _zipper zipper
+`
+
+const fishCompletion = `# fish completion for zipper, from "zipper completion fish"
+
+# __zipper_entries prints the entries of the archive given to extract.
+function __zipper_entries
+	set -l skip 0
+	for t in (commandline -opc)[3..-1]
+		if test $skip = 1
+			set skip 0
+			continue
+		end
+		switch $t
+			case '-*=*'
+			case '-*'
+				if contains -- (string replace -r '^--?' '' -- $t) {{range .Commands}}{{if eq .Name "extract"}}{{join .Values " "}}{{end}}{{end}}
+					set skip 1
+				end
+			case '*'
+				zipper __entries $t 2>/dev/null
+				return
+		end
+	end
+end
+
+complete -c zipper -n __fish_use_subcommand -a '{{.Names}}'
+{{- range .Commands}}
+{{- $cond := printf "__fish_seen_subcommand_from %s" .Name}}
+{{- if eq .Name "zip"}}{{$cond = printf "not __fish_seen_subcommand_from %s" $.Others}}{{end}}
+{{- range .Bools}}
+complete -c zipper -n '{{$cond}}' -o {{.}}
+{{- end}}
+{{- range .Values}}
+{{- if index $.Choices .}}
+complete -c zipper -n '{{$cond}}' -o {{.}} -x -a '{{join (index $.Choices .) " "}}'
+{{- else}}
+complete -c zipper -n '{{$cond}}' -o {{.}} -r
+{{- end}}
+{{- end}}
+{{- end}}
+complete -c zipper -n '__fish_seen_subcommand_from extract' -o include -x -a '(__zipper_entries)'
+complete -c zipper -n '__fish_seen_subcommand_from extract' -o exclude -x -a '(__zipper_entries)'
+complete -c zipper -n '__fish_seen_subcommand_from completion' -x -a 'bash zsh fish'
+`
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configName is the file flag defaults are read from, in the working
+// directory or else the home directory.
+const configName = ".zipper.yaml"
+
+// configAliases maps friendlier config keys onto flag names.
+var configAliases = map[string]string{
+	"destination": "C",
+	"dest":        "C",
+}
+
+// flagAliases maps flags onto the flags they are the same as, so a value
+// is set once whichever name gives it.
+var flagAliases = map[string]string{
+	"output": "o",
+	"P":      "password",
+}
+
+// config holds flag defaults loaded from a config file. A top-level key
+// sets the flag of that name on every command defining it, and a section
+// named after a command sets its flags alone, winning over the top level:
+//
+//	exclude: [node_modules, .git]
+//	level: 9
+//	output: "backups/{name}-{date}.zip"
+//	extract:
+//	  destination: ~/unpacked
+type config struct {
+	path   string
+	values map[string]any
+}
+
+// loadConfig reads the config file, returning an empty config if there is
+// none.
+func loadConfig() (*config, error) {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, configName)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c := &config{path: path}
+		if err := yaml.Unmarshal(data, &c.values); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return c, nil
+	}
+	return &config{}, nil
+}
+
+// apply sets the flags of command that were not given on the command
+// line to their configured values.
+func (c *config) apply(flags *flag.FlagSet, command string) error {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[canonicalFlag(f.Name)] = true
+	})
+
+	values := make(map[string]any)
+	for key, v := range c.values {
+		if _, ok := v.(map[string]any); !ok {
+			values[key] = v
+		}
+	}
+	if section, ok := c.values[command].(map[string]any); ok {
+		for key, v := range section {
+			values[key] = v
+		}
+	}
+
+	for key, v := range values {
+		name := key
+		if alias, ok := configAliases[key]; ok {
+			name = alias
+		}
+		name = canonicalFlag(name)
+		if flags.Lookup(name) == nil || given[name] {
+			continue
+		}
+
+		// a list sets a repeatable flag once per item
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		for _, item := range items {
+			if err := flags.Set(name, configValue(item)); err != nil {
+				return fmt.Errorf("%s: %s: %w", c.path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// canonicalFlag returns the flag another is the same as, such as -o for
+// -output, or name itself.
+func canonicalFlag(name string) string {
+	if other, ok := flagAliases[name]; ok {
+		return other
+	}
+	return name
+}
+
+// configValue formats a config value as a flag value, expanding a
+// leading ~ in paths.
+func configValue(v any) string {
+	s := fmt.Sprint(v)
+	if rest, ok := strings.CutPrefix(s, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return s
+}
+
+// applyConfig loads the config file and applies it to the flags of
+// command, exiting on error.
+func applyConfig(flags *flag.FlagSet, command string) {
+	c, err := loadConfig()
+	if err == nil {
+		err = c.apply(flags, command)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: config %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// expandOutput replaces {name} in an output path with name, the archive's
+// usual name without its extension, and {date} with today's date.
+func expandOutput(output, name string) string {
+	r := strings.NewReplacer("{name}", name, "{date}", time.Now().Format("2006-01-02"))
+	return r.Replace(output)
+}
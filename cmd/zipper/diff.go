@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runDiff implements the diff command, listing how the entries of two
+// archives differ, or an archive and a directory. Like diff(1), it exits
+// 1 if they do and 2 on error.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	quiet := flags.Bool("q", false, "print only whether the archives differ")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper diff [-q] <old archive> <new archive | dir>")
+		flags.PrintDefaults()
+	}
+
+	archives := parseArgs(flags, args)
+	applyConfig(flags, "diff")
+	if len(archives) != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	diff := zipper.Diff
+	if info, err := os.Stat(archives[1]); err == nil && info.IsDir() {
+		diff = zipper.CompareDir
+	}
+	report, err := diff(archives[0], archives[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if report.Equal() {
+		return
+	}
+
+	if *quiet {
+		fmt.Printf("%s and %s differ\n", archives[0], archives[1])
+		os.Exit(1)
+	}
+	for _, e := range report.Removed {
+		fmt.Printf("- %s\n", e.Name)
+	}
+	for _, e := range report.Added {
+		fmt.Printf("+ %s\n", e.Name)
+	}
+	for _, c := range report.Changed {
+		fmt.Printf("~ %s (%s)\n", c.New.Name, c.Reason)
+	}
+	os.Exit(1)
+}
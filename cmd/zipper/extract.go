@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// overwritePolicies names the values of the -overwrite flag.
+var overwritePolicies = map[string]zipper.OverwritePolicy{
+	"error":    zipper.OverwriteError,
+	"skip":     zipper.OverwriteSkip,
+	"always":   zipper.OverwriteAlways,
+	"if-newer": zipper.OverwriteIfNewer,
+	"rename":   zipper.OverwriteRename,
+}
+
+// cleanupPolicies names the values of the -cleanup flag.
+var cleanupPolicies = map[string]zipper.CleanupPolicy{
+	"keep":     zipper.CleanupKeep,
+	"rollback": zipper.CleanupRollback,
+	"mark":     zipper.CleanupMark,
+}
+
+// runExtract implements the extract command, extracting an archive of any
+// supported format into a directory.
+func runExtract(args []string) {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	dest := flags.String("C", ".", "extract into this directory")
+	overwrite := flags.String("overwrite", "error", "what to do with existing files: error, skip, always, if-newer or rename (default asking on a terminal)")
+	force := flags.Bool("force", false, "replace existing files, the same as -overwrite always")
+	update := flags.Bool("u", false, "only extract entries newer than the files they replace, or absent, the same as -overwrite if-newer")
+	sync := flags.Bool("sync", false, "flush each extracted file to disk before reporting success")
+	workers := flags.Int("workers", 0, "write at most this many files of a zip archive at once (default one)")
+	cleanup := flags.String("cleanup", "keep", "what to do with the files written when extraction fails: keep, rollback or mark with a .partial file")
+	var includes, excludes, components, remaps listFlag
+	flags.Var(&includes, "include", "only extract entries matching this pattern; may be repeated")
+	flags.Var(&excludes, "exclude", "skip entries matching this pattern; may be repeated")
+	flags.Var(&components, "component", "only extract this component, and the entries in none; may be repeated")
+	flags.Var(&remaps, "remap", "extract the entries below old under new instead, given as old=new; may be repeated")
+	normalize := flags.String("normalize", "", "normalize the names of extracted files to this Unicode form: nfc or nfd")
+	modeMask := flags.String("mode-mask", "", "clear these octal permission bits from extracted files and directories, e.g. 022")
+	defaultMode := flags.String("default-mode", "", "give files whose entries record no permissions this octal mode (default 644)")
+	ignoreModes := flags.Bool("ignore-modes", false, "give every file the default mode instead of the one its entry records")
+	allowSpecialBits := flags.Bool("allow-special-bits", false, "keep the setuid, setgid and sticky bits of files, cleared by default")
+	preserveOwnership := flags.Bool("preserve-ownership", false, "give files the owner and group recorded in the archive, when run as root")
+	special := flags.String("special", "", "what to do with named pipe and device entries: skip, error or recreate, devices only as root (default skip)")
+	duplicates := flags.String("duplicates", "", "resolve entries of the same name by: error, suffix, keep-first or keep-last (default error)")
+	skipMacMetadata := flags.Bool("skip-mac-metadata", false, "leave out the .DS_Store, ._* and __MACOSX metadata of macOS, as in archives made by Finder")
+	var pass password
+	pass.addFlags(flags, false)
+	var out reporter
+	out.addFlags(flags, "extracted")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper extract <archive | -> [-C dir] [flags]")
+		flags.PrintDefaults()
+	}
+	// flags may also follow the archive
+	positional := parseArgs(flags, args)
+	applyConfig(flags, "extract")
+	if len(positional) != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	archive := positional[0]
+
+	policy, ok := overwritePolicies[*overwrite]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown overwrite policy %q\n", *overwrite)
+		os.Exit(1)
+	}
+	if *force {
+		policy = zipper.OverwriteAlways
+	} else if *update {
+		policy = zipper.OverwriteIfNewer
+	}
+
+	onFailure, ok := cleanupPolicies[*cleanup]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown cleanup policy %q\n", *cleanup)
+		os.Exit(1)
+	}
+
+	opts := append(out.options("extracted"), zipper.WithOverwrite(policy), zipper.WithCleanupPolicy(onFailure))
+
+	// without a policy, ask about each existing file
+	chosen := *force || *update
+	flags.Visit(func(f *flag.Flag) {
+		chosen = chosen || f.Name == "overwrite"
+	})
+	if !chosen && archive != "-" && isTerminal(os.Stdin) {
+		p := &conflictPrompt{in: bufio.NewReader(os.Stdin), out: &out}
+		opts = append(opts, zipper.WithOverwriteFunc(p.decide))
+	}
+	if len(includes) > 0 {
+		opts = append(opts, zipper.WithInclude(includes...))
+	}
+	if len(excludes) > 0 {
+		opts = append(opts, zipper.WithExclude(excludes...))
+	}
+	if len(components) > 0 {
+		opts = append(opts, zipper.WithComponents(components...))
+	}
+	for _, r := range remaps {
+		from, to, ok := strings.Cut(r, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid remapping %q, want old=new\n", r)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithRemap(from, to))
+	}
+	if *skipMacMetadata {
+		opts = append(opts, zipper.WithSkipMacMetadata())
+	}
+	if *sync {
+		opts = append(opts, zipper.WithSync())
+	}
+	if *workers > 0 {
+		opts = append(opts, zipper.WithMaxWorkers(*workers))
+	}
+	if *modeMask != "" {
+		mask, err := parseMode(*modeMask)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithModeMask(mask))
+	}
+	if *defaultMode != "" {
+		perm, err := parseMode(*defaultMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithDefaultMode(perm))
+	}
+	if *ignoreModes {
+		opts = append(opts, zipper.WithIgnoreModes())
+	}
+	if *allowSpecialBits {
+		opts = append(opts, zipper.WithAllowSpecialBits())
+	}
+	if *preserveOwnership {
+		opts = append(opts, zipper.WithPreserveOwnership())
+	}
+	if *special != "" {
+		policy, err := parseSpecial(*special)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithSpecialFiles(policy))
+	}
+	if *normalize != "" {
+		form, err := parseNormalization(*normalize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithNormalization(form))
+	}
+	if *duplicates != "" {
+		policy, err := parseCollision(*duplicates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithDuplicates(policy))
+	}
+
+	// an archive on stdin cannot be inspected before it is read
+	needed := func() bool { return encrypted(archive) }
+	if archive == "-" {
+		needed = nil
+	}
+	pw, err := pass.get(needed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if pw != "" {
+		opts = append(opts, zipper.WithPassword(pw))
+	}
+
+	archiveSize := int64(-1)
+	if archive == "-" {
+		if isTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Error: refusing to read an archive from a terminal")
+			os.Exit(1)
+		}
+		cr := &countingReader{r: os.Stdin}
+		err = zipper.ExtractReader(cr, *dest, opts...)
+		archive, archiveSize = "standard input", cr.n
+	} else {
+		err = zipper.Extract(archive, *dest, opts...)
+		archiveSize = fileSize(archive)
+	}
+	out.bar.clear()
+	if err != nil {
+		out.fail("extracting %s: %v", archive, err)
+	}
+
+	out.result("extracted", archive, archiveSize, "")
+}
+
+// conflictPrompt asks on the terminal what to do with each existing file,
+// as unzip does.
+type conflictPrompt struct {
+	in  *bufio.Reader
+	out *reporter
+
+	// the answer for every remaining file, once "all" or "none" is given
+	rest *zipper.OverwritePolicy
+}
+
+// decide asks what to do with the conflicting file, failing the
+// extraction if the terminal is closed.
+func (p *conflictPrompt) decide(c zipper.Conflict) zipper.OverwritePolicy {
+	if p.rest != nil {
+		return *p.rest
+	}
+
+	p.out.bar.clear()
+	for {
+		fmt.Fprintf(os.Stderr, "replace %s? [y]es, [n]o, [A]ll, [N]one, [r]ename: ", c.Path)
+		line, err := p.in.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			return zipper.OverwriteError
+		}
+
+		switch answer := strings.TrimSpace(line); answer {
+		case "y", "yes":
+			return zipper.OverwriteAlways
+		case "n", "no":
+			return zipper.OverwriteSkip
+		case "A", "all", "N", "none":
+			policy := zipper.OverwriteAlways
+			if answer == "N" || answer == "none" {
+				policy = zipper.OverwriteSkip
+			}
+			p.rest = &policy
+			return policy
+		case "r", "rename":
+			return zipper.OverwriteRename
+		}
+	}
+}
+
+// parseMode parses octal permission bits, such as 644 or 0022.
+func parseMode(s string) (fs.FileMode, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > uint64(fs.ModePerm) {
+		return 0, fmt.Errorf("invalid mode %q", s)
+	}
+	return fs.FileMode(n), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runFind implements the find command, printing the entries of an archive
+// matching a pattern. Like grep, it exits 1 if none do.
+func runFind(args []string) {
+	flags := flag.NewFlagSet("find", flag.ExitOnError)
+	long := flags.Bool("long", false, "also print each entry's size and modification time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper find [-long] <archive> <pattern>")
+		flags.PrintDefaults()
+	}
+
+	positional := parseArgs(flags, args)
+	applyConfig(flags, "find")
+	if len(positional) != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	archive, pattern := positional[0], positional[1]
+
+	found, err := zipper.Find(archive, pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range found {
+		if *long {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", e.Size, e.Modified.Format("2006-01-02 15:04"), e.Name)
+		} else {
+			fmt.Fprintln(w, e.Name)
+		}
+	}
+	w.Flush()
+
+	if len(found) == 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// The events printed by -json, one JSON object per line, each naming its
+// kind in its "event" field.
+type (
+	entryEvent struct {
+		Event          string `json:"event"` // "entry" or "planned"
+		Name           string `json:"name"`
+		Size           int64  `json:"size"`
+		CompressedSize int64  `json:"compressed_size,omitempty"`
+		Mode           string `json:"mode,omitempty"`
+		Modified       string `json:"modified,omitempty"`
+	}
+
+	skippedEvent struct {
+		Event  string `json:"event"` // "skipped"
+		Name   string `json:"name"`
+		Size   int64  `json:"size"`
+		Reason string `json:"reason"`
+	}
+
+	errorEvent struct {
+		Event string `json:"event"` // "error"
+		Error string `json:"error"`
+	}
+
+	resultEvent struct {
+		Event       string `json:"event"` // "created", "extracted" or "dry-run"
+		Archive     string `json:"archive"`
+		Files       int    `json:"files"`
+		Bytes       int64  `json:"bytes"`
+		ArchiveSize int64  `json:"archive_size"`
+		SHA256      string `json:"sha256,omitempty"`
+	}
+)
+
+// emit prints a -json event.
+func (r *reporter) emit(v any) {
+	json.NewEncoder(r.out()).Encode(v)
+}
+
+// newEntryEvent returns the event reporting an entry handled.
+func newEntryEvent(kind string, info zipper.EntryInfo) entryEvent {
+	e := entryEvent{
+		Event:          kind,
+		Name:           info.Name,
+		Size:           info.Size,
+		CompressedSize: max(info.CompressedSize, 0),
+		Mode:           info.Mode.String(),
+	}
+	if !info.Modified.IsZero() {
+		e.Modified = info.Modified.Format(time.RFC3339)
+	}
+	return e
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path, or "" if it
+// cannot be read.
+func fileSHA256(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// entryOrders are the values of the list command's -sort flag, each
+// reporting whether a sorts before b.
+var entryOrders = map[string]func(a, b zipper.EntryInfo) bool{
+	"name":       func(a, b zipper.EntryInfo) bool { return a.Name < b.Name },
+	"size":       func(a, b zipper.EntryInfo) bool { return a.Size > b.Size },
+	"compressed": func(a, b zipper.EntryInfo) bool { return a.CompressedSize > b.CompressedSize },
+	"ratio":      func(a, b zipper.EntryInfo) bool { return ratio(a) < ratio(b) },
+	"modified":   func(a, b zipper.EntryInfo) bool { return a.Modified.After(b.Modified) },
+}
+
+// runList implements the list command, printing the entries of an archive
+// in a table.
+func runList(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	long := flags.Bool("long", false, "also print each entry's mode and encryption, with full modification times")
+	sortBy := flags.String("sort", "", "sort entries by name, size, compressed, ratio or modified (default archive order)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper list [-long] [-sort key] <archive>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	applyConfig(flags, "list")
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	archive := flags.Arg(0)
+
+	less, ok := entryOrders[*sortBy]
+	if !ok && *sortBy != "" {
+		fmt.Fprintf(os.Stderr, "Error: unknown sort key %q\n", *sortBy)
+		os.Exit(1)
+	}
+
+	entries, err := zipper.List(archive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing %s: %v\n", archive, err)
+		os.Exit(1)
+	}
+	if less != nil {
+		sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "SIZE\tCOMPRESSED\tRATIO\tMETHOD\tMODIFIED\t"
+	if *long {
+		header = "MODE\t" + header + "ENCRYPTED\t"
+	}
+	fmt.Fprintln(w, header+"NAME")
+
+	var size, compressed int64
+	for _, e := range entries {
+		size += e.Size
+		compressed += e.CompressedSize
+
+		modified := e.Modified.Format("2006-01-02 15:04")
+		row := fmt.Sprintf("%d\t%d\t%.2f\t%s\t%s\t", e.Size, e.CompressedSize, ratio(e), methodName(e.Method), modified)
+		if *long {
+			row = fmt.Sprintf("%s\t%d\t%d\t%.2f\t%s\t%s\t%v\t", e.Mode, e.Size, e.CompressedSize, ratio(e), methodName(e.Method), e.Modified.Format(time.RFC3339), e.Encrypted)
+		}
+		fmt.Fprintln(w, row+e.Name)
+	}
+	w.Flush()
+
+	fmt.Printf("%d entries, %d bytes, %d compressed\n", len(entries), size, compressed)
+}
+
+// ratio returns an entry's compressed size over its size, 1 when empty.
+func ratio(e zipper.EntryInfo) float64 {
+	if e.Size == 0 {
+		return 1
+	}
+	return float64(e.CompressedSize) / float64(e.Size)
+}
+
+// methodName names a zip compression method.
+func methodName(method uint16) string {
+	switch method {
+	case zip.Store:
+		return "store"
+	case zip.Deflate:
+		return "deflate"
+	case 93:
+		return "zstd"
+	case 99:
+		return "aes"
+	default:
+		return fmt.Sprintf("method %d", method)
+	}
+}
@@ -3,29 +3,271 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	zipper "github.com/irrisdev/go-zip"
+	"golang.org/x/term"
 )
 
 func main() {
-	// Define flags
-	path := flag.String("path", "", "path to file or directory to zip")
-	flag.Parse()
+	trapSignals()
 
-	// Validate required flag
-	if *path == "" {
-		fmt.Fprintln(os.Stderr, "Error: -path flag is required")
-		flag.Usage()
-		os.Exit(1)
+	// Dispatch commands, zipping by default
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "zip":
+			runZip(args[1:])
+		case "extract":
+			runExtract(args[1:])
+		case "list":
+			runList(args[1:])
+		case "test":
+			runTest(args[1:])
+		case "diff":
+			runDiff(args[1:])
+		case "stats":
+			runStats(args[1:])
+		case "find":
+			runFind(args[1:])
+		case "cache":
+			runCache(args[1:])
+		case "cleanup":
+			runCleanup(args[1:])
+		case "scan":
+			runScan(args[1:])
+		case "completion":
+			runCompletion(args[1:])
+		case "__entries":
+			runEntries(args[1:])
+		case "help":
+			usage()
+		default:
+			// anything else is a path to zip
+			runZip(args)
+		}
+		return
 	}
 
-	// Compress the path
-	zipPath, err := zipper.Zip(*path)
+	runZip(args)
+}
+
+// usage prints the commands zipper accepts.
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  zipper [zip] <path>... [-o archive | -c] [flags]
+  zipper extract <archive | -> [-C dir] [flags]
+  zipper list [-long] [-sort key] <archive>
+  zipper test [-q] <archive>...
+  zipper diff [-q] <old archive> <new archive | dir>
+  zipper stats [-top n] <archive>
+  zipper find [-long] <archive> <pattern>
+  zipper scan <archive>
+  zipper cleanup [-age duration] [-spill] [dir ...]
+  zipper cache [-reset]
+  zipper completion bash|zsh|fish
+
+Run "zipper <command> -h" for the flags of a command. Flag defaults are
+read from .zipper.yaml in the working or home directory.`)
+}
+
+// parseArgs parses flags, which may come before, between or after the
+// positional arguments, and returns the positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positional
+		}
+
+		// everything after "--" is positional
+		rest := flags.Args()
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...)
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// listFlag collects the values of a flag given more than once.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// reporter prints what a command does, at the verbosity set by its -v and
+// -q flags, and counts the entries it handles.
+type reporter struct {
+	verbose  bool
+	quiet    bool
+	progress bool
+	json     bool
+	bar      *progressBar
+	w        io.Writer // where messages go, stdout if nil
+
+	files int
+	bytes int64
+}
+
+// addFlags defines the -v, -q, -progress and -json flags on flags.
+func (r *reporter) addFlags(flags *flag.FlagSet, verb string) {
+	flags.BoolVar(&r.verbose, "v", false, "print each file as it is "+verb)
+	flags.BoolVar(&r.quiet, "q", false, "print nothing but errors")
+	flags.BoolVar(&r.progress, "progress", false, "show a progress bar, or periodic progress lines when not on a terminal")
+	flags.BoolVar(&r.json, "json", false, "print a JSON object per line for each file, skipped file, error and the result")
+}
+
+// options returns the options that report to r.
+func (r *reporter) options(verb string) []zipper.Option {
+	opts := []zipper.Option{
+		zipper.WithContext(interrupted),
+		zipper.WithOnEntry(func(info zipper.EntryInfo) {
+			r.entry(verb, info)
+		}),
+	}
+	if r.progress && !r.quiet && !r.json {
+		r.bar = newProgressBar()
+		opts = append(opts, zipper.WithProgress(r.bar.update))
+	}
+	return opts
+}
+
+// out returns where messages go.
+func (r *reporter) out() io.Writer {
+	if r.w == nil {
+		return os.Stdout
+	}
+	return r.w
+}
+
+// printf prints unless quiet or printing JSON.
+func (r *reporter) printf(format string, args ...any) {
+	if !r.quiet && !r.json {
+		r.bar.clear()
+		fmt.Fprintf(r.out(), format, args...)
+	}
+}
+
+// entry counts an entry handled, printing it with verb if verbose.
+func (r *reporter) entry(verb string, info zipper.EntryInfo) {
+	if info.Mode.IsDir() {
+		return
+	}
+	r.files++
+	r.bytes += max(info.Size, 0)
+	if r.json {
+		r.emit(newEntryEvent("entry", info))
+	} else if r.verbose {
+		r.printf("%s: %s\n", verb, info.Name)
+	}
+}
+
+// planned prints an entry a dry run would add.
+func (r *reporter) planned(p zipper.PlannedEntry) {
+	if r.json {
+		r.emit(entryEvent{Event: "planned", Name: p.Name, Size: p.Size})
+		return
+	}
+	r.printf("would add: %s (%d bytes)\n", p.Name, p.Size)
+}
+
+// skipped prints a file left out.
+func (r *reporter) skipped(s zipper.Skipped) {
+	if r.json {
+		r.emit(skippedEvent{Event: "skipped", Name: s.Entry, Size: s.Size, Reason: s.Reason})
+		return
+	}
+	r.printf("skipped: %s\n", s)
+}
+
+// fail prints the error a command failed with, and exits, with
+// exitInterrupted if the command was interrupted.
+func (r *reporter) fail(format string, args ...any) {
+	r.bar.clear()
+	msg := fmt.Sprintf(format, args...)
+	if r.json {
+		r.emit(errorEvent{Event: "error", Error: msg})
+	} else {
+		fmt.Fprintf(os.Stderr, "Error %s\n", msg)
+	}
+	if interrupted.Err() != nil {
+		os.Exit(exitInterrupted)
+	}
+	os.Exit(1)
+}
+
+// result prints the outcome of a command on archive: kind is "created",
+// "extracted" or "dry-run", and sum the archive's SHA-256
+// if known.
+func (r *reporter) result(kind, archive string, archiveSize int64, sum string) {
+	if r.json {
+		r.emit(resultEvent{Event: kind, Archive: archive, Files: r.files, Bytes: r.bytes, ArchiveSize: archiveSize, SHA256: sum})
+		return
+	}
+
+	if kind == "dry-run" {
+		r.printf("would create: %s\n", archive)
+		return
+	}
+	r.printf("successfully %s: %s\n", kind, archive)
+	r.summary(archiveSize)
+}
+
+// summary prints the entries counted and their total size, compared with
+// the archive's size if known, or -1.
+func (r *reporter) summary(archiveSize int64) {
+	r.bar.clear()
+	line := fmt.Sprintf("%d files, %d bytes", r.files, r.bytes)
+	if archiveSize >= 0 && r.bytes > 0 {
+		line += fmt.Sprintf(", archive %d bytes (ratio %.2f)", archiveSize, float64(archiveSize)/float64(r.bytes))
+	}
+	r.printf("%s\n", line)
+}
+
+// fileSize returns the size of the file at path, or -1 if unknown.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error zipping %s: %v\n", *path, err)
-		os.Exit(1)
+		return -1
 	}
+	return info.Size()
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
 
-	fmt.Printf("successfully created: %s\n", zipPath)
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	zipper "github.com/irrisdev/go-zip"
+	"golang.org/x/term"
+)
+
+// passwordEnv names the environment variable a password may be given in,
+// keeping it out of the process list.
+const passwordEnv = "ZIPPER_PASSWORD"
+
+// password holds the -password and -P flags of a command.
+type password struct {
+	value  string
+	prompt bool
+}
+
+// addFlags defines the password flags on flags, with -encrypt, to prompt
+// for a password, when create is true.
+func (p *password) addFlags(flags *flag.FlagSet, create bool) {
+	usage := "decrypt entries with this password, visible to other users; prefer " + passwordEnv + " or the prompt"
+	if create {
+		usage = "encrypt entries with this password, visible to other users; prefer " + passwordEnv + " or -encrypt"
+		flags.BoolVar(&p.prompt, "encrypt", false, "prompt for a password to encrypt entries with")
+	}
+	flags.StringVar(&p.value, "password", "", usage)
+	flags.StringVar(&p.value, "P", "", "same as -password")
+}
+
+// get returns the password given by flag or environment, prompting for
+// one if asked to with -encrypt or if needed reports one is, and "" for
+// none.
+func (p *password) get(needed func() bool) (string, error) {
+	if p.value != "" {
+		return p.value, nil
+	}
+	if v := os.Getenv(passwordEnv); v != "" {
+		return v, nil
+	}
+	if p.prompt {
+		return promptPassword(true)
+	}
+	if needed != nil && isTerminal(os.Stdin) && needed() {
+		return promptPassword(false)
+	}
+	return "", nil
+}
+
+// promptPassword reads a password from the terminal without echoing it,
+// asking for it twice when confirm is true.
+func promptPassword(confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !isTerminal(os.Stdin) {
+		return "", errors.New("cannot prompt for a password: standard input is not a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 {
+		return "", errors.New("empty password")
+	}
+	if !confirm {
+		return string(b), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Confirm password: ")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(again) != string(b) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(b), nil
+}
+
+// encrypted reports whether the archive at path has encrypted entries.
+func encrypted(path string) bool {
+	entries, err := zipper.List(path)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if e.Encrypted {
+			return true
+		}
+	}
+	return false
+}
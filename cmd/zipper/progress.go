@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+const (
+	// barWidth is the number of cells in the progress bar.
+	barWidth = 30
+
+	// plainInterval is how often progress is printed when stderr is not
+	// a terminal.
+	plainInterval = 5 * time.Second
+)
+
+// progressBar draws progress on stderr: a bar redrawn in place on a
+// terminal, and otherwise a plain line every few seconds.
+type progressBar struct {
+	tty   bool
+	drawn bool      // a bar is on the current line
+	last  time.Time // when the last plain line was printed
+}
+
+func newProgressBar() *progressBar {
+	return &progressBar{tty: isTerminal(os.Stderr), last: time.Now()}
+}
+
+// update shows the progress p.
+func (b *progressBar) update(p zipper.Progress) {
+	rate := 0.0
+	if secs := p.Elapsed.Seconds(); secs > 0 {
+		rate = float64(p.Bytes) / secs
+	}
+
+	status := fmt.Sprintf("%s  %s/s", formatBytes(float64(p.Bytes)), formatBytes(rate))
+	percent := -1.0
+	if p.Total > 0 {
+		percent = min(float64(p.Bytes)/float64(p.Total)*100, 100)
+		status = fmt.Sprintf("%3.0f%%  %s", percent, status)
+		if rate > 0 {
+			eta := time.Duration(float64(p.Total-p.Bytes) / rate * float64(time.Second))
+			status += "  ETA " + max(eta, 0).Round(time.Second).String()
+		}
+	}
+
+	if !b.tty {
+		if time.Since(b.last) >= plainInterval {
+			b.last = time.Now()
+			fmt.Fprintf(os.Stderr, "progress: %s\n", status)
+		}
+		return
+	}
+
+	if percent >= 0 {
+		filled := int(percent / 100 * barWidth)
+		status = "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "] " + status
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", status)
+	b.drawn = true
+}
+
+// clear removes the bar from the terminal, before other output or when
+// done.
+func (b *progressBar) clear() {
+	if b != nil && b.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		b.drawn = false
+	}
+}
+
+// formatBytes formats n bytes with a binary unit.
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runScan implements the scan command, reporting license files and
+// potential secrets in an archive. It exits non-zero if secrets are found.
+func runScan(args []string) {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper scan <archive>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	archive := flags.Arg(0)
+
+	report, err := zipper.Scan(archive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", archive, err)
+		os.Exit(1)
+	}
+
+	for _, f := range report.Findings {
+		location := f.Entry
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.Entry, f.Line)
+		}
+		fmt.Printf("%s: %s: %s\n", location, f.Kind, f.Detail)
+	}
+
+	if report.HasKind("secret") {
+		fmt.Fprintln(os.Stderr, "potential secrets found")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// exitInterrupted is the status zipper exits with once interrupted, the
+// one shells give a command killed by SIGINT.
+const exitInterrupted = 130
+
+// interrupted is done once zipper receives an interrupt or termination
+// signal, stopping the command in progress.
+var interrupted = context.Background()
+
+// trapSignals makes the first interrupt or termination signal cancel
+// interrupted instead of killing zipper, so the command stops cleanly,
+// removing what it was partway through writing. A second signal kills it
+// as usual.
+func trapSignals() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	interrupted = ctx
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runStats implements the stats command, printing how well the entries of
+// an archive compressed by extension and directory, and the largest.
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	top := flags.Int("top", 10, "print at most this many extensions, directories and entries")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper stats [-top n] <archive>")
+		flags.PrintDefaults()
+	}
+
+	positional := parseArgs(flags, args)
+	applyConfig(flags, "stats")
+	if len(positional) != 1 || *top < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	archive := positional[0]
+
+	report, err := zipper.CompressionStats(archive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", archive, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d entries, %d bytes, %d compressed, ratio %.2f, %d bytes saved\n",
+		report.Entries, report.Uncompressed, report.Compressed, report.Ratio(), report.Saved())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nEXTENSION\tENTRIES\tSIZE\tCOMPRESSED\tRATIO")
+	for _, s := range report.Extensions[:min(len(report.Extensions), *top)] {
+		ext := s.Extension
+		if ext == "" {
+			ext = "(none)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f\n", ext, s.Samples, s.Uncompressed, s.Compressed, s.Ratio())
+	}
+
+	fmt.Fprintln(w, "\nDIRECTORY\tENTRIES\tSIZE\tCOMPRESSED\tRATIO")
+	for _, d := range report.Directories[:min(len(report.Directories), *top)] {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f\n", d.Directory, d.Entries, d.Uncompressed, d.Compressed, d.Ratio())
+	}
+
+	fmt.Fprintln(w, "\nLARGEST\t\tSIZE\tCOMPRESSED\tRATIO")
+	for _, e := range report.Largest[:min(len(report.Largest), *top)] {
+		fmt.Fprintf(w, "%s\t\t%d\t%d\t%.2f\n", e.Name, e.Size, e.CompressedSize, ratio(e))
+	}
+	w.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// runTest implements the test command, checking the integrity of each
+// archive given and exiting non-zero if any is corrupt.
+func runTest(args []string) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	quiet := flags.Bool("q", false, "print only failures")
+	var pass password
+	pass.addFlags(flags, false)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zipper test [-q] <archive>...")
+		flags.PrintDefaults()
+	}
+
+	archives := parseArgs(flags, args)
+	applyConfig(flags, "test")
+	if len(archives) == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	ok := true
+	for _, archive := range archives {
+		pw, err := pass.get(func() bool { return encrypted(archive) })
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var opts []zipper.Option
+		if pw != "" {
+			opts = append(opts, zipper.WithPassword(pw))
+		}
+		if !verify(archive, *quiet, opts...) {
+			ok = false
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// runVerify checks an archive's integrity, printing every corrupt entry
+// and exiting non-zero if any are found.
+func runVerify(archive string) {
+	if !verify(archive, false) {
+		os.Exit(1)
+	}
+}
+
+// verify checks an archive's integrity, printing every corrupt entry, and
+// reports whether it is intact.
+func verify(archive string, quiet bool, opts ...zipper.Option) bool {
+	err := zipper.Verify(archive, opts...)
+	if err == nil {
+		if !quiet {
+			fmt.Printf("%s: OK\n", archive)
+		}
+		return true
+	}
+
+	var verr *zipper.VerifyError
+	if !errors.As(err, &verr) {
+		fmt.Fprintf(os.Stderr, "Error verifying %s: %v\n", archive, err)
+		return false
+	}
+
+	for _, e := range verr.Entries {
+		fmt.Fprintf(os.Stderr, "%s: %s: FAILED: %v\n", archive, e.Name, e.Err)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d entries failed verification\n", archive, len(verr.Entries))
+	return false
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// formats names the values of the -format flag, in the order their
+// extensions are matched against the archive's name.
+var formats = []struct {
+	name   string
+	exts   []string
+	format zipper.Format
+}{
+	{"zip", []string{".zip"}, zipper.FormatZip},
+	{"tar.gz", []string{".tar.gz", ".tgz"}, zipper.FormatTarGz},
+	{"tar.zst", []string{".tar.zst", ".tzst"}, zipper.FormatTarZst},
+	{"gz", []string{".gz"}, zipper.FormatGzip},
+}
+
+// parseFormat returns the archive format named by the -format flag or,
+// if it is empty, implied by the extension of output, defaulting to zip.
+func parseFormat(name, output string) (zipper.Format, error) {
+	for _, f := range formats {
+		if name == "" {
+			for _, ext := range f.exts {
+				if strings.HasSuffix(strings.ToLower(output), ext) {
+					return f.format, nil
+				}
+			}
+		} else if name == f.name {
+			return f.format, nil
+		}
+	}
+	if name != "" {
+		return 0, fmt.Errorf("unknown format %q", name)
+	}
+	return zipper.FormatZip, nil
+}
+
+// methods names the values of the -method flag.
+var methods = map[string]zipper.Method{
+	"deflate": zipper.MethodDeflate,
+	"store":   zipper.MethodStore,
+	"zstd":    zipper.MethodZstd,
+	"auto":    zipper.MethodAuto,
+}
+
+// runZip implements the zip command, the default, archiving the files and
+// directories given as arguments.
+func runZip(args []string) {
+	flags := flag.NewFlagSet("zip", flag.ExitOnError)
+	var output string
+	flags.StringVar(&output, "o", "", "write the archive to this path, or into this directory, replacing {name} and {date} (default the current directory)")
+	flags.StringVar(&output, "output", "", "same as -o")
+	stdout := flags.Bool("c", false, "write the archive to standard output, and messages to standard error")
+	force := flags.Bool("force", false, "replace the archive if it already exists")
+	sync := flags.Bool("sync", false, "flush the archive to disk before reporting success")
+	var includes, excludes listFlag
+	flags.Var(&includes, "include", "only archive files matching this pattern; may be repeated")
+	flags.Var(&excludes, "exclude", "leave out files and directories matching this pattern, e.g. node_modules; may be repeated")
+	dryRun := flags.Bool("dry-run", false, "list what would be archived without writing anything")
+	verify := flags.String("verify", "", "verify the integrity of an existing archive instead of zipping")
+	sfx := flags.String("sfx", "", "build a self-extracting archive using this extractor stub")
+	dedup := flags.Bool("dedup", false, "store the contents of identical files once")
+	hardLinks := flags.Bool("hard-links", false, "store hard-linked files once and recreate the links on extraction")
+	sparse := flags.Bool("sparse", false, "read only the data regions of sparse files")
+	reproducible := flags.Bool("reproducible", false, "write identical archives for identical input, dated SOURCE_DATE_EPOCH if set")
+	preserveOwnership := flags.Bool("preserve-ownership", false, "record the owner and group of each file")
+	special := flags.String("special", "", "what to do with named pipes, sockets and devices: skip, error or read, archiving what is read from pipes (default skip)")
+	oneFileSystem := flags.Bool("one-file-system", false, "do not descend into directories on other file systems")
+	skipHidden := flags.Bool("skip-hidden", false, "leave out hidden files and directories, those named with a leading dot or, on Windows, marked hidden")
+	keepMacMetadata := flags.Bool("keep-mac-metadata", false, "archive the .DS_Store, ._* and __MACOSX metadata of macOS, left out by default")
+	maxDepth := flags.Int("max-depth", 0, "only archive files this many levels below the path, 1 for its top level (default no limit)")
+	minSize := flags.String("min-size", "", "skip files smaller than this many bytes, with an optional k, m or g suffix")
+	maxSize := flags.String("max-size", "", "skip files larger than this many bytes, with an optional k, m or g suffix")
+	rootDir := flags.String("root-dir", "", "place every entry under this directory, e.g. myapp-1.2.3")
+	normalize := flags.String("normalize", "", "normalize entry names to this Unicode form: nfc or nfd")
+	flatten := flags.String("flatten", "", "store every file at the archive root, resolving name clashes by: error, suffix, keep-first or keep-last")
+	duplicates := flags.String("duplicates", "", "resolve files renamed or normalized to the same name by: error, suffix, keep-first or keep-last (default error)")
+	format := flags.String("format", "", "archive format: zip, tar.gz, tar.zst or gz, a single gzipped file (default from the -o extension, else zip)")
+	method := flags.String("method", "deflate", "compression method: deflate, store, zstd or auto, which stores files that do not compress")
+	level := flags.Int("level", 0, "compression level from 1, fastest, to 9, smallest (default the method's own)")
+	order := flags.String("order", "walk", "entry order: walk, name, dirs-first or largest-first")
+	workers := flags.Int("workers", 0, "compress at most this many files at once (default GOMAXPROCS)")
+	maxOpenFiles := flags.Int("max-open-files", 0, "hold at most this many files open at once (default half the process limit)")
+	bwlimit := flags.String("bwlimit", "", "limit reads and writes to this many bytes per second each, with an optional k, m or g suffix")
+	resume := flags.Bool("resume", false, "journal progress and continue an interrupted archive")
+	base := flags.String("base", "", "only archive files changed since this archive or manifest")
+	var pass password
+	pass.addFlags(flags, true)
+	var out reporter
+	out.addFlags(flags, "archived")
+	flags.Usage = func() {
+		usage()
+		fmt.Fprintln(flags.Output(), "\nFlags of zip:")
+		flags.PrintDefaults()
+	}
+	paths := parseArgs(flags, args)
+	applyConfig(flags, "zip")
+
+	if *verify != "" {
+		runVerify(*verify)
+		return
+	}
+
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no paths to zip")
+		flags.Usage()
+		os.Exit(1)
+	}
+	paths, err := expandPaths(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *stdout {
+		if output != "" {
+			fmt.Fprintln(os.Stderr, "Error: -c and -o cannot be used together")
+			os.Exit(1)
+		}
+		if isTerminal(os.Stdout) && !*dryRun {
+			fmt.Fprintln(os.Stderr, "Error: refusing to write an archive to a terminal")
+			os.Exit(1)
+		}
+		out.w = os.Stderr
+	}
+
+	var opts []zipper.Option
+	if output != "" {
+		opts = append(opts, zipper.WithOutput(expandOutput(output, archiveStem(paths))))
+	}
+	if !*force {
+		opts = append(opts, zipper.WithOutputExclusive())
+	}
+	if len(includes) > 0 {
+		opts = append(opts, zipper.WithInclude(includes...))
+	}
+	if len(excludes) > 0 {
+		opts = append(opts, zipper.WithExclude(excludes...))
+	}
+	if *sfx != "" {
+		opts = append(opts, zipper.WithSelfExtractor(*sfx))
+	}
+	if *dedup {
+		opts = append(opts, zipper.WithDedup())
+	}
+	if *hardLinks {
+		opts = append(opts, zipper.WithHardLinks())
+	}
+	if *sparse {
+		opts = append(opts, zipper.WithSparse())
+	}
+	if *preserveOwnership {
+		opts = append(opts, zipper.WithPreserveOwnership())
+	}
+	if *special != "" {
+		policy, err := parseSpecial(*special)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithSpecialFiles(policy))
+	}
+	if *oneFileSystem {
+		opts = append(opts, zipper.WithOneFileSystem())
+	}
+	if *sync {
+		opts = append(opts, zipper.WithSync())
+	}
+	if *skipHidden {
+		opts = append(opts, zipper.WithIncludeHidden(false))
+	}
+	if !*keepMacMetadata {
+		opts = append(opts, zipper.WithSkipMacMetadata())
+	}
+	if *maxDepth > 0 {
+		opts = append(opts, zipper.WithMaxDepth(*maxDepth))
+	}
+	for _, f := range []struct {
+		value string
+		opt   func(int64) zipper.Option
+	}{{*minSize, zipper.WithMinSize}, {*maxSize, zipper.WithMaxSize}} {
+		if f.value == "" {
+			continue
+		}
+		n, err := parseSize(f.value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, f.opt(n))
+	}
+	if *rootDir != "" {
+		opts = append(opts, zipper.WithRootDir(*rootDir))
+	}
+	if *normalize != "" {
+		form, err := parseNormalization(*normalize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithNormalization(form))
+	}
+	if *flatten != "" {
+		policy, err := parseCollision(*flatten)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithFlatten(policy))
+	}
+	if *duplicates != "" {
+		policy, err := parseCollision(*duplicates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithDuplicates(policy))
+	}
+	if *workers > 0 {
+		opts = append(opts, zipper.WithMaxWorkers(*workers))
+	}
+	if *maxOpenFiles > 0 {
+		opts = append(opts, zipper.WithMaxOpenFiles(*maxOpenFiles))
+	}
+	if *bwlimit != "" {
+		rate, err := parseSize(*bwlimit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithBandwidthLimit(rate))
+	}
+	archiveFormat, err := parseFormat(*format, output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts = append(opts, zipper.WithFormat(archiveFormat))
+	m, ok := methods[*method]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown method %q\n", *method)
+		os.Exit(1)
+	}
+	opts = append(opts, zipper.WithMethod(m))
+	if *level != 0 {
+		opts = append(opts, zipper.WithLevel(*level))
+	}
+	entryOrder, err := parseOrder(*order)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts = append(opts, zipper.WithOrder(entryOrder))
+	if *reproducible {
+		epoch, err := sourceDateEpoch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, zipper.WithReproducible(epoch))
+	}
+	if *resume {
+		opts = append(opts, zipper.WithResume())
+	}
+	if *base != "" {
+		opts = append(opts, zipper.WithBase(*base))
+	}
+	pw, err := pass.get(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if pw != "" {
+		opts = append(opts, zipper.WithPassword(pw))
+	}
+	if *dryRun {
+		opts = append(opts, zipper.WithDryRun(out.planned))
+	}
+	opts = append(opts, out.options("added")...)
+
+	report := &zipper.Report{}
+	opts = append(opts, zipper.WithReport(report))
+
+	// Compress the paths
+	var zipPath, sum string
+	archiveSize := int64(-1)
+	if *stdout {
+		h := sha256.New()
+		cw := &countingWriter{w: io.MultiWriter(os.Stdout, h)}
+		err = zipper.ZipPathsTo(cw, paths, opts...)
+		zipPath, archiveSize, sum = "standard output", cw.n, hex.EncodeToString(h.Sum(nil))
+	} else {
+		zipPath, err = zipper.ZipPaths(paths, opts...)
+		archiveSize = fileSize(zipPath)
+		if out.json && !*dryRun {
+			sum = fileSHA256(zipPath)
+		}
+	}
+	out.bar.clear()
+	if errors.Is(err, fs.ErrExist) && !*force {
+		out.fail("zipping %s: %v (use -force to replace it)", strings.Join(paths, ", "), err)
+	}
+	if err != nil {
+		out.fail("zipping %s: %v", strings.Join(paths, ", "), err)
+	}
+
+	for _, s := range report.Skipped {
+		out.skipped(s)
+	}
+
+	if *dryRun {
+		out.result("dry-run", zipPath, -1, "")
+		return
+	}
+	out.result("created", zipPath, archiveSize, sum)
+}
+
+// expandPaths expands the glob patterns among paths, for shells that do
+// not, such as those of Windows. A path that exists is taken literally,
+// and a pattern matching nothing is an error.
+func expandPaths(paths []string) ([]string, error) {
+	var expanded []string
+	for _, p := range paths {
+		if !strings.ContainsAny(p, "*?[") {
+			expanded = append(expanded, p)
+			continue
+		}
+		if _, err := os.Lstat(p); err == nil {
+			expanded = append(expanded, p)
+			continue
+		}
+
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", p)
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
+
+// archiveStem returns the name the archive of paths is given by default,
+// without its extension: that of the single path, or of the directory
+// holding them all.
+func archiveStem(paths []string) string {
+	if len(paths) == 1 {
+		return filepath.Base(paths[0])
+	}
+
+	var dir string
+	for i, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "archive"
+		}
+		if i == 0 {
+			dir = filepath.Dir(abs)
+		}
+		for !strings.HasPrefix(abs, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return filepath.Base(dir)
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH
+// environment variable for reproducible builds, or the zero time.
+func sourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", v)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// parseOrder returns the entry order named by the -order flag.
+func parseOrder(name string) (zipper.Order, error) {
+	for _, order := range []zipper.Order{zipper.OrderWalk, zipper.OrderName, zipper.OrderDirsFirst, zipper.OrderLargestFirst} {
+		if order.String() == name {
+			return order, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown order %q", name)
+}
+
+// parseCollision returns the collision policy with the given name.
+func parseCollision(name string) (zipper.Collision, error) {
+	for _, c := range []zipper.Collision{zipper.CollisionError, zipper.CollisionSuffix, zipper.CollisionKeepFirst, zipper.CollisionKeepLast} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown collision policy %q", name)
+}
+
+// parseSpecial returns the special file policy with the given name.
+func parseSpecial(name string) (zipper.SpecialFilePolicy, error) {
+	for _, p := range []zipper.SpecialFilePolicy{zipper.SpecialSkip, zipper.SpecialError, zipper.SpecialRecreate, zipper.SpecialRead} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown special file policy %q", name)
+}
+
+// parseNormalization returns the Unicode normalization form with the
+// given name.
+func parseNormalization(name string) (zipper.Normalization, error) {
+	for _, n := range []zipper.Normalization{zipper.NormalizeNFC, zipper.NormalizeNFD} {
+		if n.String() == strings.ToLower(name) {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown normalization form %q", name)
+}
+
+// parseSize parses a positive number of bytes, with an optional binary k,
+// m or g suffix.
+func parseSize(s string) (int64, error) {
+	num, mult := s, int64(1)
+	switch strings.ToLower(s[max(len(s)-1, 0):]) {
+	case "k":
+		mult = 1 << 10
+	case "m":
+		mult = 1 << 20
+	case "g":
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		num = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
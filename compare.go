@@ -0,0 +1,224 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompareDir compares the archive at zipPath with the directory dir, such
+// as the one it was made from, to check a backup before deleting the
+// originals. The report lists as Removed the entries with no file in dir,
+// as Added the files in dir the archive lacks, and as Changed the entries
+// whose file differs in type, size, CRC-32, SHA-256 where the archive's
+// manifest records it, or link target. Entries with unsafe names are
+// listed as Removed, as they cannot be in dir. Files stored once by
+// WithDedup or WithHardLinks are compared with the entry holding their
+// contents. The files are read in full, but of the entries only symlinks
+// are read. AES encrypted entries record no CRC-32, so only their size is
+// compared.
+func CompareDir(zipPath, dir string) (DiffReport, error) {
+	var report DiffReport
+
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return report, err
+	}
+	defer r.Close()
+
+	m, err := readEmbeddedManifest(r.Reader)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return report, err
+	}
+	compared, err := comparedEntries(r.Reader, m)
+	if err != nil {
+		return report, err
+	}
+	hashes := make(map[string]string)
+	if m != nil {
+		for _, e := range m.Entries {
+			hashes[e.Name] = e.SHA256
+		}
+	}
+
+	// directories holding entries are not extra
+	entries := make(map[string]bool, len(compared))
+	for _, c := range compared {
+		old := fileInfo(c.f)
+		old.Name = c.name
+
+		name, err := sanitizeName(c.name)
+		if err != nil {
+			report.Removed = append(report.Removed, old)
+			continue
+		}
+		entries[name] = true
+
+		// Zip archives the file a symlink points to
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Lstat(path)
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 && c.f.Mode()&fs.ModeSymlink == 0 {
+			info, err = os.Stat(path)
+		}
+		if os.IsNotExist(err) {
+			report.Removed = append(report.Removed, old)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		reason, err := compareFile(c.f, path, info, hashes[c.name])
+		if err != nil {
+			return report, &EntryError{Name: c.name, Err: err}
+		}
+		if reason != "" {
+			report.Changed = append(report.Changed, EntryChange{Old: old, New: diskInfo(c.name, info), Reason: reason})
+		}
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := entryName(dir, path)
+		if err != nil || entries[name] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		report.Added = append(report.Added, diskInfo(name, info))
+		return nil
+	})
+	return report, err
+}
+
+// comparedEntry is an entry CompareDir compares under name. The files
+// stored once by WithDedup or WithHardLinks are compared through the
+// entry holding their contents.
+type comparedEntry struct {
+	name string
+	f    *zip.File
+}
+
+// comparedEntries returns the entries of r, followed by the duplicates
+// recorded in its manifest m, if any.
+func comparedEntries(r *zip.Reader, m *Manifest) ([]comparedEntry, error) {
+	compared := make([]comparedEntry, 0, len(r.File))
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+		compared = append(compared, comparedEntry{name: f.Name, f: f})
+		files[f.Name] = f
+	}
+	if m == nil {
+		return compared, nil
+	}
+
+	for _, d := range m.Entries {
+		if d.Duplicate == "" {
+			continue
+		}
+		f, ok := files[d.Duplicate]
+		if !ok {
+			return nil, &EntryError{Name: d.Name, Err: fmt.Errorf("duplicate of entry %s: %w", d.Duplicate, ErrNotFound)}
+		}
+		compared = append(compared, comparedEntry{name: d.Name, f: f})
+	}
+	return compared, nil
+}
+
+// compareFile returns what differs between the entry f and the file at
+// path, described by info, given the entry's SHA-256 if known, or "" if
+// nothing does.
+func compareFile(f *zip.File, path string, info fs.FileInfo, hash string) (string, error) {
+	mode := f.Mode()
+	if mode.Type() != info.Mode().Type() {
+		return "type", nil
+	}
+
+	switch {
+	case mode.IsDir():
+		return "", nil
+	case mode&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		stored, err := readLinkEntry(f)
+		if err != nil {
+			return "", err
+		}
+		if filepath.ToSlash(target) != stored {
+			return "link target", nil
+		}
+		return "", nil
+	case uint64(info.Size()) != f.UncompressedSize64:
+		return "size", nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	crc := crc32.NewIEEE()
+	sum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(crc, sum), file); err != nil {
+		return "", err
+	}
+
+	switch {
+	case !isAES(f) && crc.Sum32() != f.CRC32:
+		return "crc32", nil
+	case hash != "" && hex.EncodeToString(sum.Sum(nil)) != hash:
+		return "sha256", nil
+	}
+	return "", nil
+}
+
+// readLinkEntry returns the target stored in the symlink entry f.
+func readLinkEntry(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	target, err := io.ReadAll(io.LimitReader(rc, maxLinkTarget+1))
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(target), `\`, "/"), nil
+}
+
+// isAES reports whether f is encrypted with WinZip AES, whose entries
+// record no CRC-32.
+func isAES(f *zip.File) bool {
+	return f.Method == methodAES
+}
+
+// diskInfo describes the file named name in a directory, described by
+// info, as if it were an entry.
+func diskInfo(name string, info fs.FileInfo) EntryInfo {
+	return EntryInfo{
+		Name:           name,
+		Size:           info.Size(),
+		CompressedSize: -1,
+		Mode:           info.Mode(),
+		Modified:       info.ModTime(),
+	}
+}
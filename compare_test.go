@@ -0,0 +1,67 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "backup")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta", "c.txt": "gamma"})
+	zipPath := zipAside(t, src, WithManifest())
+
+	report, err := CompareDir(zipPath, src)
+	if err != nil || !report.Equal() {
+		t.Fatalf("expected the directory to match, got %+v (%v)", report, err)
+	}
+
+	writeTree(t, src, map[string]string{"c.txt": "GAMMA", "d.txt": "delta"})
+	if err := os.Remove(filepath.Join(src, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = CompareDir(zipPath, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "dir/b.txt" {
+		t.Errorf("expected dir/b.txt missing, got %v", report.Removed)
+	}
+	if len(report.Added) != 1 || report.Added[0].Name != "d.txt" {
+		t.Errorf("expected d.txt extra, got %v", report.Added)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].String() != "c.txt: crc32 differs" {
+		t.Errorf("expected c.txt changed, got %v", report.Changed)
+	}
+}
+
+func TestCompareDirDedup(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "backup")
+	writeTree(t, src, map[string]string{"a.txt": "shared", "b.txt": "shared", "dir/c.txt": "shared"})
+	zipPath := zipAside(t, src, WithDedup())
+
+	report, err := CompareDir(zipPath, src)
+	if err != nil || !report.Equal() {
+		t.Fatalf("expected the directory to match, got %+v (%v)", report, err)
+	}
+
+	writeTree(t, src, map[string]string{"dir/c.txt": "SHARED"})
+	if err := os.Remove(filepath.Join(src, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = CompareDir(zipPath, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "b.txt" {
+		t.Errorf("expected b.txt missing, got %v", report.Removed)
+	}
+	if len(report.Added) != 0 {
+		t.Errorf("expected nothing extra, got %v", report.Added)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].String() != "dir/c.txt: crc32 differs" {
+		t.Errorf("expected dir/c.txt changed, got %v", report.Changed)
+	}
+}
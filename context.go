@@ -0,0 +1,38 @@
+package zipper
+
+import (
+	"context"
+	"io"
+)
+
+// WithContext stops Zip, Unzip and Extract once ctx is done, between
+// files and while copying their contents, returning ctx.Err(). Zip
+// removes the archive it was writing, and extraction the file it was
+// writing; files already extracted are kept.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// ctxErr returns the error of the context the operation was given, if
+// done.
+func (o *options) ctxErr() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
+}
+
+// contextReader fails reads once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
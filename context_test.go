@@ -0,0 +1,35 @@
+package zipper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContextCancelled(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "cancel")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+	zipPath := zipAside(t, src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	outDir := t.TempDir()
+	_, err := Zip(src, WithContext(ctx), WithOutput(filepath.Join(outDir, "out.zip")))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the zip to be cancelled, got %v", err)
+	}
+	if leftover, _ := os.ReadDir(outDir); len(leftover) != 0 {
+		t.Errorf("expected the partial archive to be removed, found %v", leftover)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the extraction to be cancelled, got %v", err)
+	}
+	if leftover, _ := os.ReadDir(dest); len(leftover) != 0 {
+		t.Errorf("expected nothing extracted, found %v", leftover)
+	}
+}
@@ -0,0 +1,413 @@
+package zipper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// archiveExts are the extensions stripped from a source archive's name
+// to name the converted archive, longest first.
+var archiveExts = []string{".tar.gz", ".tar.zst", ".tar.xz", ".tgz", ".tar", ".zip", ".7z", ".sfx", ".exe"}
+
+// Convert transcodes the archive at src into format, writing it to the
+// current directory, or as set by WithOutput, under src's name with the
+// new extension, and returns its path. The source format is detected as by Extract, and entries are
+// copied across without touching the disk, keeping their names,
+// permissions, modification times and symlinks. Ownership and zip-only
+// metadata such as the manifest are not carried over.
+//
+// WithInclude and WithExclude select the entries converted. Entries that
+// cannot be read fail the conversion, or are left out and recorded when
+// WithReport is given.
+func Convert(src string, format Format, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return "", err
+	}
+	if o.resume {
+		return "", errors.New("only Zip can be resumed")
+	}
+	if format == FormatGzip {
+		return "", errors.New("cannot convert an archive to gzip format")
+	}
+
+	dstPath := o.outputPath(archiveBase(filepath.Base(src)) + format.ext())
+	if err := checkOutput(dstPath, o); err != nil {
+		return "", err
+	}
+	if o.sink == nil {
+		if same, err := samePath(src, dstPath); err != nil || same {
+			if err == nil {
+				err = fmt.Errorf("%s is already in %s format", src, format)
+			}
+			return "", err
+		}
+	}
+
+	out, err := createOutput(dstPath, o)
+	if err != nil {
+		return "", err
+	}
+
+	// discard partial output on failure
+	completed := false
+	defer func() {
+		if !completed {
+			out.Abort()
+		}
+	}()
+
+	w, err := newEntryWriter(out, format, o.level)
+	if err != nil {
+		return "", err
+	}
+
+	err = readEntries(src, o, func(f entry, open func() (io.ReadCloser, error)) error {
+		if !o.selectedEntry(f.name) {
+			return nil
+		}
+		return w.add(f, open)
+	})
+	if err != nil {
+		w.Close()
+		return "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	if err := out.Commit(); err != nil {
+		return "", err
+	}
+
+	completed = true
+
+	return dstPath, nil
+}
+
+// archiveBase returns name without its archive extension.
+func archiveBase(name string) string {
+	for _, ext := range archiveExts {
+		if len(name) > len(ext) && strings.EqualFold(name[len(name)-len(ext):], ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// samePath reports whether a and b name the same existing file.
+func samePath(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bi, err := os.Stat(b)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+// readEntries calls fn for every readable entry of the archive at src in
+// order, detecting its format as Extract does. Unreadable entries are
+// recorded or fail, as by recordUnsupported.
+func readEntries(src string, o *options, fn func(f entry, open func() (io.ReadCloser, error)) error) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head, err := sniff(f)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case is7z(head):
+		return read7zEntries(src, o, fn)
+	case isCompressed(head) || isTar(head):
+		return readTarEntries(src, f, head, o, fn)
+	default:
+		return readZipEntries(src, o, fn)
+	}
+}
+
+func readZipEntries(src string, o *options, fn func(entry, func() (io.ReadCloser, error)) error) error {
+	r, err := openArchive(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	m, err := readEmbeddedManifest(r.Reader)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return err
+	}
+	if m != nil {
+		if err := o.limits.checkDuplicates(m, len(r.File)); err != nil {
+			return err
+		}
+	}
+
+	files := make(map[string]*zip.File, len(r.File))
+	supported := make(map[string]bool, len(r.File))
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+
+		ok, err := o.checkSupported(f)
+		if err != nil {
+			return err
+		}
+		files[f.Name], supported[f.Name] = f, ok
+		if !ok {
+			continue
+		}
+
+		if err := fn(zipEntry(f), o.opener(f)); err != nil {
+			return err
+		}
+	}
+	if m == nil {
+		return nil
+	}
+
+	// files stored once by WithDedup or WithHardLinks follow
+	count := len(r.File)
+	for _, d := range m.Entries {
+		if d.Duplicate == "" {
+			continue
+		}
+		count++
+		if err := o.limits.checkEntry(d.Name, count); err != nil {
+			return err
+		}
+		f, ok := files[d.Duplicate]
+		if !ok {
+			return &EntryError{Name: d.Name, Err: fmt.Errorf("duplicate of entry %s: %w", d.Duplicate, ErrNotFound)}
+		}
+		if !supported[f.Name] {
+			continue
+		}
+
+		if err := fn(duplicateEntry(f, d), o.opener(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func read7zEntries(src string, o *options, fn func(entry, func() (io.ReadCloser, error)) error) error {
+	r, err := sevenzip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		mode := f.Mode()
+		ok, err := o.recordUnsupported(unsupportedMode(f.Name, mode))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		ent := entry{name: f.Name, mode: mode, modified: f.Modified, size: int64(f.UncompressedSize), compressed: -1}
+		if err := fn(ent, f.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readTarEntries(src string, f io.Reader, head []byte, o *options, fn func(entry, func() (io.ReadCloser, error)) error) error {
+	r, err := decompress(f, head)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	br := bufio.NewReaderSize(r, sniffSize)
+	inner, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !isTar(inner) {
+		return fn(singleEntry(src, r), func() (io.ReadCloser, error) {
+			return io.NopCloser(br), nil
+		})
+	}
+
+	tr := tar.NewReader(br)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		ok, err := o.recordUnsupported(unsupportedTarFeatures(hdr))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := fn(tarEntry(hdr), tarOpener(tr, hdr)); err != nil {
+			return err
+		}
+	}
+}
+
+// entryWriter writes entries to an archive in one of the Zip formats.
+type entryWriter interface {
+	// add writes the entry f, whose contents, or link target for a
+	// symlink, are read from open.
+	add(f entry, open func() (io.ReadCloser, error)) error
+
+	Close() error
+}
+
+// newEntryWriter returns a writer of archives in format onto w,
+// compressing tarballs at level.
+func newEntryWriter(w io.Writer, format Format, level int) (entryWriter, error) {
+	switch format {
+	case FormatZip:
+		return &zipEntryWriter{zw: zip.NewWriter(w)}, nil
+	case FormatTarGz, FormatTarZst:
+		zw, err := format.compressor(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return &tarEntryWriter{zw: zw, tw: tar.NewWriter(zw)}, nil
+	default:
+		return nil, fmt.Errorf("cannot write entries in %s format", format)
+	}
+}
+
+type zipEntryWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipEntryWriter) add(f entry, open func() (io.ReadCloser, error)) error {
+	hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: f.modified}
+	hdr.SetMode(f.mode)
+
+	if f.mode.IsDir() {
+		hdr.Name = strings.TrimSuffix(hdr.Name, "/") + "/"
+		hdr.Method = zip.Store
+		_, err := w.zw.CreateHeader(hdr)
+		return err
+	}
+
+	dst, err := w.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(dst, rc)
+	return err
+}
+
+func (w *zipEntryWriter) Close() error {
+	return w.zw.Close()
+}
+
+type tarEntryWriter struct {
+	zw io.WriteCloser
+	tw *tar.Writer
+}
+
+func (w *tarEntryWriter) add(f entry, open func() (io.ReadCloser, error)) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     f.name,
+		Mode:     int64(f.mode.Perm()),
+		ModTime:  f.modified,
+	}
+
+	if f.mode.IsDir() {
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name = strings.TrimSuffix(hdr.Name, "/") + "/"
+		return w.tw.WriteHeader(hdr)
+	}
+
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if f.mode&os.ModeSymlink != 0 {
+		target, err := io.ReadAll(io.LimitReader(rc, maxLinkTarget+1))
+		if err != nil {
+			return err
+		}
+		if len(target) > maxLinkTarget {
+			return &PathError{Op: "convert", Entry: f.name, Err: invalidPath("link target too long")}
+		}
+
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = string(target)
+		return w.tw.WriteHeader(hdr)
+	}
+
+	// tar needs the size up front, which a bare compressed stream lacks
+	var r io.Reader = rc
+	if f.size < 0 {
+		buf := newSpillBuffer(spillThreshold)
+		defer buf.Release()
+
+		if _, err := io.Copy(buf, rc); err != nil {
+			return err
+		}
+		if r, err = buf.Reader(); err != nil {
+			return err
+		}
+		f.size = int64(buf.Len())
+	}
+
+	hdr.Size = f.size
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.CopyN(w.tw, r, f.size)
+	return err
+}
+
+func (w *tarEntryWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		w.zw.Close()
+		return err
+	}
+	return w.zw.Close()
+}
@@ -0,0 +1,163 @@
+package zipper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestConvertZipToTarGz(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "dir/", mode: fs.ModeDir | 0755},
+		testEntry{name: "dir/run.sh", body: "#!/bin/sh\n", mode: 0755},
+		testEntry{name: "dir/link", body: "run.sh", mode: fs.ModeSymlink | 0777},
+	)
+
+	tarPath, err := Convert(zipPath, FormatTarGz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(tarPath)
+
+	if tarPath != "crafted.tar.gz" {
+		t.Errorf("expected crafted.tar.gz, got %s", tarPath)
+	}
+
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := make(map[string]*tar.Header)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		headers[hdr.Name] = hdr
+	}
+
+	if hdr := headers["dir/"]; hdr == nil || hdr.Typeflag != tar.TypeDir {
+		t.Errorf("expected a directory entry, got %+v", hdr)
+	}
+	if hdr := headers["dir/run.sh"]; hdr == nil || hdr.Mode != 0755 || hdr.Size != 10 {
+		t.Errorf("expected an executable file, got %+v", hdr)
+	}
+	if hdr := headers["dir/link"]; hdr == nil || hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "run.sh" {
+		t.Errorf("expected a symlink to run.sh, got %+v", hdr)
+	}
+
+	// and back again
+	backPath, err := Convert(tarPath, FormatZip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(backPath)
+
+	zipptest.AssertArchivesEqual(t, zipPath, backPath)
+
+	entries, err := List(backPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name == "dir/link" && e.Mode&fs.ModeSymlink == 0 {
+			t.Errorf("expected dir/link to stay a symlink, got %v", e.Mode)
+		}
+	}
+}
+
+func TestConvertKeepsTimes(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "times-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	modified := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath, err := Zip(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	tarPath, err := Convert(zipPath, FormatTarZst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(tarPath)
+
+	dest := t.TempDir()
+	if err := Extract(tarPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	info, err := os.Stat(filepath.Join(dest, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("expected modification time %v, got %v", modified, info.ModTime())
+	}
+}
+
+func TestConvertSameFormat(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "alpha"})
+
+	if _, err := Convert(zipPath, FormatZip); err == nil {
+		t.Error("expected an error converting an archive onto itself")
+	}
+	if _, err := os.Stat(zipPath); err != nil {
+		t.Errorf("expected the source to survive: %v", err)
+	}
+}
+
+func TestArchiveBase(t *testing.T) {
+	for name, want := range map[string]string{
+		"site.zip":     "site",
+		"site.tar.gz":  "site",
+		"site.TGZ":     "site",
+		"site.tar.zst": "site",
+		"site.7z":      "site",
+		"app.log.gz":   "app.log",
+	} {
+		if got := archiveBase(name); got != want {
+			t.Errorf("archiveBase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestConvertDedup(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dedup")
+	writeTree(t, src, map[string]string{"a.txt": "shared", "dir/b.txt": "shared"})
+	zipPath := zipAside(t, src, WithDedup())
+
+	tarPath, err := Convert(zipPath, FormatTarGz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(tarPath)
+
+	files, err := ExtractToMap(tarPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || string(files["dir/b.txt"]) != "shared" {
+		t.Errorf("expected the duplicate converted, got %q", files)
+	}
+}
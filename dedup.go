@@ -0,0 +1,132 @@
+package zipper
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+)
+
+// WithDedup makes Zip store the contents of identical files once. The
+// first file with given contents is written as usual, and later files
+// with the same SHA-256 are recorded in the embedded manifest, which
+// WithDedup therefore enables, as duplicates of it, with
+// ManifestEntry.Duplicate naming the entry holding the data.
+//
+// Unzip, ExtractToMap and Convert recreate duplicates from the entry
+// holding their data. Other readers, including UnzipReader and other zip
+// tools, only see the first copy of each file.
+func WithDedup() Option {
+	return func(o *options) {
+		o.dedup = true
+		o.manifest = true
+	}
+}
+
+// dedup maps the SHA-256 of contents written to an archive to the entry
+// holding them.
+type dedup map[string]string
+
+// original returns the entry already holding the contents of c, if any,
+// and otherwise records c as holding them. Empty files are never
+// deduplicated, as there is nothing to save.
+func (d dedup) original(c compressed) string {
+	if d == nil || c.sha256 == "" || c.hdr.UncompressedSize64 == 0 {
+		return ""
+	}
+	if name, ok := d[c.sha256]; ok {
+		return name
+	}
+	d[c.sha256] = c.hdr.Name
+	return ""
+}
+
+// extractDuplicates writes the contents of entries stored once by
+// WithDedup or WithHardLinks under the names of their duplicates, as hard
+// links where they were recorded and the file linked to was extracted.
+func (e *extractor) extractDuplicates(r *zip.Reader, m *Manifest, inComponents func(name string) bool) error {
+	if m == nil {
+		return nil
+	}
+
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	// duplicates count after the entries of the archive
+	count := len(r.File)
+	for _, d := range m.Entries {
+		if d.Duplicate == "" {
+			continue
+		}
+		count++
+		if err := e.o.limits.checkEntry(d.Name, count); err != nil {
+			return err
+		}
+		if !e.o.selectedEntry(d.Name) {
+			continue
+		}
+		if inComponents != nil && !inComponents(d.Name) {
+			continue
+		}
+
+		f, ok := files[d.Duplicate]
+		if !ok {
+			return &EntryError{Name: d.Name, Err: fmt.Errorf("duplicate of entry %s: %w", d.Duplicate, ErrNotFound)}
+		}
+
+		ok, err := e.o.checkSupported(f)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		dup := duplicateEntry(f, d)
+		dup.sparse = e.sparse[f.Name]
+		if d.HardLink != "" {
+			dup.link = e.paths[d.HardLink]
+		}
+
+		if err := e.extract(dup, e.o.opener(f)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// duplicateEntry returns the entry of the duplicate d, whose contents are
+// stored in f.
+func duplicateEntry(f *zip.File, d ManifestEntry) entry {
+	dup := zipEntry(f)
+	dup.name = d.Name
+	dup.modified = d.Modified
+	if perm, ok := parsePerm(d.Mode); ok {
+		dup.mode = dup.mode&^fs.ModePerm | perm
+		dup.noMode = false
+	}
+	return dup
+}
+
+// parsePerm returns the permission bits of a mode formatted by
+// fs.FileMode.String, which end with the nine "rwx" characters.
+func parsePerm(mode string) (fs.FileMode, bool) {
+	if len(mode) < 9 {
+		return 0, false
+	}
+
+	var perm fs.FileMode
+	bits := mode[len(mode)-9:]
+	for i := 0; i < len(bits); i++ {
+		switch bits[i] {
+		case "rwxrwxrwx"[i]:
+			perm |= 1 << (8 - i)
+		case '-':
+		default:
+			return 0, false
+		}
+	}
+	return perm, true
+}
@@ -0,0 +1,108 @@
+package zipper
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestDedup(t *testing.T) {
+	body := strings.Repeat("repeated contents ", 1000)
+	src := filepath.Join(t.TempDir(), "dedup")
+	writeTree(t, src, map[string]string{
+		"a.txt":     body,
+		"b.txt":     body,
+		"dir/c.txt": body,
+		"d.txt":     "unique",
+		"e.txt":     "",
+		"f.txt":     "",
+	})
+	if err := os.Chmod(filepath.Join(src, "b.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath, err := Zip(src, WithDedup())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	names, m := readManifest(t, zipPath)
+	if want := []string{"a.txt", "d.txt", "e.txt", "f.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected entries %v, got %v", want, names)
+	}
+
+	duplicates := make(map[string]string)
+	for _, e := range m.Entries {
+		if e.Duplicate != "" {
+			duplicates[e.Name] = e.Duplicate
+		}
+	}
+	if want := map[string]string{"b.txt": "a.txt", "dir/c.txt": "a.txt"}; !reflect.DeepEqual(duplicates, want) {
+		t.Errorf("expected duplicates %v, got %v", want, duplicates)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the duplicate's own mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestDedupSelected(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "selected")
+	writeTree(t, src, map[string]string{"a.txt": "same", "b.txt": "same"})
+
+	zipPath, err := Zip(src, WithDedup())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	// a duplicate is extracted even when the entry holding its data is not
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithInclude("b.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := zipptest.ReadTree(t, dest)
+	if len(got) != 1 || string(got["b.txt"]) != "same" {
+		t.Errorf("expected only b.txt, got %q", got)
+	}
+}
+
+func TestParsePerm(t *testing.T) {
+	tests := []struct {
+		mode string
+		perm fs.FileMode
+		ok   bool
+	}{
+		{"-rw-r--r--", 0644, true},
+		{"-rwxr-x---", 0750, true},
+		{"urwxr-xr-x", 0755, true},
+		{"-rw-r--r-S", 0, false},
+		{"rw", 0, false},
+	}
+
+	for _, tt := range tests {
+		perm, ok := parsePerm(tt.mode)
+		if perm != tt.perm || ok != tt.ok {
+			t.Errorf("parsePerm(%q) = %v, %v, want %v, %v", tt.mode, perm, ok, tt.perm, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package zipper
+
+import (
+	"archive/zip"
+	"fmt"
+)
+
+// DiffReport lists how the entries of one archive differ from those of
+// another.
+type DiffReport struct {
+	Added   []EntryInfo // entries only in the second archive
+	Removed []EntryInfo // entries only in the first archive
+	Changed []EntryChange
+}
+
+// Equal reports whether the archives hold the same entries.
+func (r DiffReport) Equal() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// EntryChange is an entry whose contents differ between two archives.
+type EntryChange struct {
+	Old, New EntryInfo
+
+	// Reason says what differs: "size", "crc32" or "sha256", or for
+	// CompareDir also "type" or "link target".
+	Reason string
+}
+
+func (c EntryChange) String() string {
+	return fmt.Sprintf("%s: %s differs", c.New.Name, c.Reason)
+}
+
+// Diff compares the entries of the archives at a and b by name, without
+// extracting anything, listing those added in b, those removed from a and
+// those whose contents changed. Contents are compared by size and CRC-32,
+// and by SHA-256 where both archives embed a manifest recording it, so
+// changes are found even in entries of the same size and checksum. AES
+// encrypted entries record no CRC-32, so only their size is compared.
+// Removed entries are listed in a's order, the others in b's.
+func Diff(a, b string) (DiffReport, error) {
+	var report DiffReport
+
+	ra, err := openArchive(a)
+	if err != nil {
+		return report, err
+	}
+	defer ra.Close()
+
+	rb, err := openArchive(b)
+	if err != nil {
+		return report, err
+	}
+	defer rb.Close()
+
+	hashesA, err := manifestHashes(ra.Reader)
+	if err != nil {
+		return report, err
+	}
+	hashesB, err := manifestHashes(rb.Reader)
+	if err != nil {
+		return report, err
+	}
+
+	old := make(map[string]*zip.File, len(ra.File))
+	for _, f := range ra.File {
+		if f.Name != ManifestName {
+			old[f.Name] = f
+		}
+	}
+
+	seen := make(map[string]bool, len(rb.File))
+	for _, f := range rb.File {
+		if f.Name == ManifestName {
+			continue
+		}
+		seen[f.Name] = true
+
+		prev, ok := old[f.Name]
+		if !ok {
+			report.Added = append(report.Added, fileInfo(f))
+			continue
+		}
+		if reason := changed(prev, f, hashesA[f.Name], hashesB[f.Name]); reason != "" {
+			report.Changed = append(report.Changed, EntryChange{Old: fileInfo(prev), New: fileInfo(f), Reason: reason})
+		}
+	}
+
+	for _, f := range ra.File {
+		if f.Name != ManifestName && !seen[f.Name] {
+			report.Removed = append(report.Removed, fileInfo(f))
+		}
+	}
+
+	return report, nil
+}
+
+// changed returns what differs between the contents of entries a and b,
+// given their SHA-256 digests if known, or "" if nothing does.
+func changed(a, b *zip.File, hashA, hashB string) string {
+	switch {
+	case a.UncompressedSize64 != b.UncompressedSize64:
+		return "size"
+	case a.CRC32 != b.CRC32:
+		return "crc32"
+	case hashA != "" && hashB != "" && hashA != hashB:
+		return "sha256"
+	}
+	return ""
+}
+
+// manifestHashes returns the SHA-256 digests the embedded manifest of r
+// records by entry name, or none if it has no manifest.
+func manifestHashes(r *zip.Reader) (map[string]string, error) {
+	m, err := readEmbeddedManifest(r)
+	if err == ErrNoManifest {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string, len(m.Entries))
+	for _, e := range m.Entries {
+		hashes[e.Name] = e.SHA256
+	}
+	return hashes, nil
+}
+
+// fileInfo describes the zip entry f.
+func fileInfo(f *zip.File) EntryInfo {
+	info := headerInfo(&f.FileHeader)
+	info.Modified = zipTimes(f).modified
+	info.Encrypted = f.Flags&0x1 != 0
+	return info
+}
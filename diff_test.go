@@ -0,0 +1,39 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "release")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta", "c.txt": "gamma"})
+	a := zipAside(t, src, WithManifest())
+
+	// d.txt is added, a.txt removed, b.txt grows and c.txt keeps its size
+	writeTree(t, src, map[string]string{"b.txt": "beta 2", "c.txt": "GAMMA", "d.txt": "delta"})
+	if err := os.Remove(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	b := zipAside(t, src, WithManifest())
+
+	report, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Added) != 1 || report.Added[0].Name != "d.txt" {
+		t.Errorf("expected d.txt added, got %v", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "a.txt" {
+		t.Errorf("expected a.txt removed, got %v", report.Removed)
+	}
+	if len(report.Changed) != 2 || report.Changed[0].String() != "b.txt: size differs" || report.Changed[1].String() != "c.txt: crc32 differs" {
+		t.Errorf("expected b.txt and c.txt changed, got %v", report.Changed)
+	}
+
+	same, err := Diff(a, a)
+	if err != nil || !same.Equal() {
+		t.Errorf("expected an archive to equal itself, got %+v (%v)", same, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package zipper
+
+// freeSpace returns 0 as free space cannot be determined on this
+// platform.
+func freeSpace(path string) uint64 {
+	return 0
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package zipper
+
+import "syscall"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// file system holding path, or 0 if unknown.
+func freeSpace(path string) uint64 {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize)
+}
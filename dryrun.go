@@ -0,0 +1,50 @@
+package zipper
+
+import "os"
+
+// PlannedEntry describes an entry a dry run would have written.
+type PlannedEntry struct {
+	// Name is the entry's name within the archive.
+	Name string
+
+	// Path is the source file when zipping, or the destination file when
+	// extracting.
+	Path string
+
+	// Size is the uncompressed size in bytes.
+	Size int64
+}
+
+// WithDryRun makes Zip and Unzip walk the input and call report for every
+// entry they would write, without writing anything to disk. Zip still
+// returns the archive path it would have created, and Unzip still fails
+// on entries it would refuse, so a dry run validates patterns, limits and
+// extraction targets.
+func WithDryRun(report func(PlannedEntry)) Option {
+	return func(o *options) {
+		o.dryRun = report
+	}
+}
+
+// planEntries reports the entries Zip would create for files below root to
+// the WithDryRun callback.
+func planEntries(root string, files []string, o *options) error {
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if err := o.unreadable(file, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, err := o.entryName(root, file)
+		if err != nil {
+			return err
+		}
+
+		o.dryRun(PlannedEntry{Name: name, Path: file, Size: info.Size()})
+	}
+
+	return nil
+}
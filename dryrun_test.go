@@ -0,0 +1,101 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestZipDryRun(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dryrun-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "sub/b.txt": "beta!"})
+
+	var planned []PlannedEntry
+	zipPath, err := Zip(src, WithDryRun(func(p PlannedEntry) {
+		planned = append(planned, p)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if zipPath != "dryrun-src.zip" {
+		t.Errorf("expected planned archive dryrun-src.zip, got %s", zipPath)
+	}
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		os.Remove(zipPath)
+		t.Error("dry run must not create the archive")
+	}
+
+	sort.Slice(planned, func(i, j int) bool { return planned[i].Name < planned[j].Name })
+	want := []PlannedEntry{
+		{Name: "a.txt", Path: filepath.Join(src, "a.txt"), Size: 5},
+		{Name: "sub/b.txt", Path: filepath.Join(src, "sub", "b.txt"), Size: 5},
+	}
+	if len(planned) != len(want) {
+		t.Fatalf("expected %v, got %v", want, planned)
+	}
+	for i := range want {
+		if planned[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], planned[i])
+		}
+	}
+}
+
+func TestUnzipDryRun(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "docs/", body: ""},
+		testEntry{name: "docs/readme.md", body: "read me"},
+		testEntry{name: "main.go", body: "package main"},
+	)
+	dest := filepath.Join(t.TempDir(), "out")
+
+	var planned []PlannedEntry
+	err := Unzip(zipPath, dest, WithExclude("*.go"), WithDryRun(func(p PlannedEntry) {
+		planned = append(planned, p)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("dry run must not create the destination")
+	}
+
+	want := []PlannedEntry{
+		{Name: "docs/", Path: filepath.Join(dest, "docs")},
+		{Name: "docs/readme.md", Path: filepath.Join(dest, "docs", "readme.md"), Size: 7},
+	}
+	if len(planned) != len(want) {
+		t.Fatalf("expected %v, got %v", want, planned)
+	}
+	for i := range want {
+		if planned[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], planned[i])
+		}
+	}
+}
+
+func TestUnzipDryRunKeepsExistingFiles(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "a.txt", body: "new"})
+	dest := t.TempDir()
+	existing := filepath.Join(dest, "a.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var planned []PlannedEntry
+	err := Unzip(zipPath, dest, WithOverwrite(OverwriteAlways), WithDryRun(func(p PlannedEntry) {
+		planned = append(planned, p)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(planned) != 1 || planned[0].Path != existing {
+		t.Errorf("expected a.txt to be planned for overwrite, got %v", planned)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "old" {
+		t.Errorf("dry run modified existing file: %q", data)
+	}
+}
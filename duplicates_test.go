@@ -0,0 +1,109 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipDuplicates(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "cased")
+	writeTree(t, src, map[string]string{"A.txt": "upper", "a.txt": "lower"})
+	lower := WithRename(strings.ToLower)
+
+	if _, err := Zip(src, lower); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected the clash to be refused, got %v", err)
+	}
+
+	files := zipptest.ReadArchive(t, zipAside(t, src, lower, WithDuplicates(CollisionSuffix)))
+	if string(files["a.txt"]) != "upper" || string(files["a-1.txt"]) != "lower" {
+		t.Errorf("expected the later file renamed, got %q", files)
+	}
+
+	files = zipptest.ReadArchive(t, zipAside(t, src, lower, WithDuplicates(CollisionKeepLast)))
+	if len(files) != 1 || string(files["a.txt"]) != "lower" {
+		t.Errorf("expected the later file kept, got %q", files)
+	}
+}
+
+func TestWriterDuplicates(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddBytes("a.txt", 0644, time.Now(), []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddBytes("./a.txt", 0644, time.Now(), []byte("second")); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected the second entry to be refused, got %v", err)
+	}
+
+	if _, err := NewWriter(&buf, WithDuplicates(CollisionKeepLast)); err == nil {
+		t.Error("expected keeping the last entry to be refused")
+	}
+
+	buf.Reset()
+	if w, err = NewWriter(&buf, WithDuplicates(CollisionSuffix)); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := w.AddBytes("a.txt", 0644, time.Now(), []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 2 || r.File[0].Name != "a.txt" || r.File[1].Name != "a-1.txt" {
+		t.Errorf("expected the later entry renamed, got %v", r.File)
+	}
+}
+
+func TestUnzipDuplicates(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "dir/a.txt", body: "first"},
+		testEntry{name: "dir/b.txt", body: "other"},
+		testEntry{name: "dir//a.txt", body: "second"},
+	)
+
+	var pe *PathError
+	if err := Unzip(zipPath, t.TempDir()); !errors.As(err, &pe) || pe.Entry != "dir//a.txt" || !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected the duplicate entry to be refused, got %v", err)
+	}
+
+	for _, test := range []struct {
+		policy Collision
+		want   map[string]string
+	}{
+		{CollisionKeepFirst, map[string]string{"dir/a.txt": "first", "dir/b.txt": "other"}},
+		{CollisionKeepLast, map[string]string{"dir/a.txt": "second", "dir/b.txt": "other"}},
+		{CollisionSuffix, map[string]string{"dir/a.txt": "first", "dir/b.txt": "other", "dir/a-1.txt": "second"}},
+	} {
+		dest := t.TempDir()
+		if err := Unzip(zipPath, dest, WithDuplicates(test.policy)); err != nil {
+			t.Errorf("%v: unexpected error: %v", test.policy, err)
+			continue
+		}
+		got := zipptest.ReadTree(t, dest)
+		if len(got) != len(test.want) {
+			t.Errorf("%v: expected %d files, got %q", test.policy, len(test.want), got)
+		}
+		for name, body := range test.want {
+			if string(got[name]) != body {
+				t.Errorf("%v: expected %s to hold %q, got %q", test.policy, name, body, got[name])
+			}
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// ErrPassword is returned when an encrypted entry cannot be decrypted
+// with the password given, or no password was given.
+var ErrPassword = errors.New("incorrect password")
+
+const (
+	// methodAES is the method recorded for WinZip AES entries, whose
+	// actual method is in the AES extra field.
+	methodAES = 99
+
+	// aesExtraID tags the WinZip AES extra field.
+	aesExtraID = 0x9901
+
+	// zipVersion51 is the format version needed for AES encryption.
+	zipVersion51 = 51
+
+	aesIterations = 1000
+	aesVerifySize = 2
+	aesMACSize    = 10
+
+	// zipCryptoHeaderSize is the length of the encryption header before
+	// traditionally encrypted data.
+	zipCryptoHeaderSize = 12
+)
+
+// WithPassword makes Zip encrypt the contents of every entry with
+// password, using WinZip AES-256, and lets Unzip, Extract and Convert
+// read entries encrypted with WinZip AES or the traditional PKWARE
+// scheme. Entry names, sizes and times are not encrypted.
+//
+// Encrypted archives must be zip archives, and cannot be resumed or carry
+// a manifest, which would give away the checksums of their contents;
+// options that need one, such as WithDedup and WithGroup, are rejected
+// too. Convert reads encrypted archives but does not encrypt the one it
+// writes.
+func WithPassword(password string) Option {
+	return func(o *options) {
+		o.password = password
+	}
+}
+
+// aesKeySize returns the key length in bytes for an AES strength code.
+func aesKeySize(strength byte) int {
+	switch strength {
+	case 1:
+		return 16
+	case 2:
+		return 24
+	case 3:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// aesKeys derives the encryption key, authentication key and password
+// verifier for a WinZip AES entry.
+func aesKeys(password string, salt []byte, keySize int) (key, macKey, verifier []byte) {
+	dk := pbkdf2SHA1([]byte(password), salt, aesIterations, 2*keySize+aesVerifySize)
+	return dk[:keySize], dk[keySize : 2*keySize], dk[2*keySize:]
+}
+
+// pbkdf2SHA1 derives a key of keyLen bytes with PBKDF2 (RFC 8018) using
+// HMAC-SHA1.
+func pbkdf2SHA1(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	var dk []byte
+	var index [4]byte
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		binary.BigEndian.PutUint32(index[:], block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(index[:])
+		u := prf.Sum(nil)
+		t := bytes.Clone(u)
+		for n := 1; n < iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			subtle.XORBytes(t, t, u)
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
+
+// aesCTR is the counter mode WinZip AES uses, which unlike cipher.NewCTR
+// counts from 1 in little-endian order.
+type aesCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	used    int
+}
+
+func newAESCTR(block cipher.Block) *aesCTR {
+	return &aesCTR{block: block, used: aes.BlockSize}
+}
+
+func (c *aesCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.used == aes.BlockSize {
+			for j := range c.counter {
+				c.counter[j]++
+				if c.counter[j] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.stream[:], c.counter[:])
+			c.used = 0
+		}
+		dst[i] = src[i] ^ c.stream[c.used]
+		c.used++
+	}
+}
+
+// writeEncrypted writes a compressed entry to the archive encrypted with
+// WinZip AES-256, leaving c.hdr as it was for the manifest and journal.
+func writeEncrypted(zipw *zip.Writer, c compressed, o *options) error {
+	const strength = 3
+	keySize := aesKeySize(strength)
+	saltSize := keySize / 2
+
+	hdr := *c.hdr
+	hdr.Method = methodAES
+	hdr.Flags |= 0x1
+	hdr.ReaderVersion = zipVersion51
+	hdr.CompressedSize64 += uint64(saltSize + aesVerifySize + aesMACSize)
+
+	// AE-2 leaves out the checksum, which would give away the contents of
+	// small files; the MAC authenticates them instead
+	hdr.CRC32 = 0
+
+	// AES extra field: tag, size, vendor version, vendor ID, strength,
+	// actual method
+	var ext [11]byte
+	binary.LittleEndian.PutUint16(ext[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(ext[2:], 7)
+	binary.LittleEndian.PutUint16(ext[4:], 2)
+	copy(ext[6:], "AE")
+	ext[8] = strength
+	binary.LittleEndian.PutUint16(ext[9:], c.hdr.Method)
+	hdr.Extra = append(bytes.Clone(c.hdr.Extra), ext[:]...)
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, macKey, verifier := aesKeys(o.password, salt, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	w, err := zipw.CreateRaw(&hdr)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(salt); err != nil {
+		return err
+	}
+	if _, err := w.Write(verifier); err != nil {
+		return err
+	}
+
+	r, err := c.data.Reader()
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha1.New, macKey)
+	enc := cipher.StreamWriter{S: newAESCTR(block), W: io.MultiWriter(w, mac)}
+	if _, err := o.copy(enc, r); err != nil {
+		return err
+	}
+
+	_, err = w.Write(mac.Sum(nil)[:aesMACSize])
+	return err
+}
+
+// opener returns the function opening the contents of the zip entry f,
+// decrypting them with the configured password if f is encrypted.
+func (o *options) opener(f *zip.File) func() (io.ReadCloser, error) {
+	if f.Flags&0x1 == 0 {
+		return f.Open
+	}
+	return func() (io.ReadCloser, error) {
+		return openEncrypted(f, o.password)
+	}
+}
+
+// decryptable reports whether the encryption of f can be undone given a
+// password.
+func decryptable(f *zip.File) bool {
+	if f.Method == methodAES {
+		_, _, ok := aesParams(f)
+		return ok
+	}
+	return f.Flags&0x40 == 0
+}
+
+// aesParams returns the strength and actual method recorded in the AES
+// extra field of f.
+func aesParams(f *zip.File) (strength byte, method uint16, ok bool) {
+	extra := f.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if id == aesExtraID && size >= 7 && aesKeySize(extra[8]) > 0 {
+			return extra[8], binary.LittleEndian.Uint16(extra[9:]), true
+		}
+		extra = extra[4+size:]
+	}
+	return 0, 0, false
+}
+
+// openEncrypted returns the decrypted and decompressed contents of the
+// encrypted zip entry f.
+func openEncrypted(f *zip.File, password string) (io.ReadCloser, error) {
+	if password == "" || !decryptable(f) {
+		return nil, &EntryError{Name: f.Name, Err: ErrPassword}
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	method := f.Method
+	checkCRC := true
+	if f.Method == methodAES {
+		var strength byte
+		strength, method, _ = aesParams(f)
+		r, err = decryptAES(raw, f.CompressedSize64, strength, password)
+
+		// AE-2 entries record no checksum
+		checkCRC = f.CRC32 != 0
+	} else {
+		r, err = decryptZipCrypto(raw, f, password)
+	}
+	if err == ErrPassword {
+		err = &EntryError{Name: f.Name, Err: ErrPassword}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = io.NopCloser(r)
+	case zip.Deflate:
+		rc = flate.NewReader(r)
+	case zipZstd:
+		rc = decompressZstd(r)
+	default:
+		return nil, &EntryError{Name: f.Name, Err: zip.ErrAlgorithm}
+	}
+
+	cr := &checkedReader{rc: rc, hash: crc32.NewIEEE(), size: f.UncompressedSize64}
+	if checkCRC {
+		cr.crc = &f.CRC32
+	}
+	return cr, nil
+}
+
+// decryptAES returns the plaintext of a WinZip AES entry whose encrypted
+// data, size bytes including salt and MAC, is read from raw.
+func decryptAES(raw io.Reader, size uint64, strength byte, password string) (io.Reader, error) {
+	keySize := aesKeySize(strength)
+	saltSize := keySize / 2
+	overhead := uint64(saltSize + aesVerifySize + aesMACSize)
+	if size < overhead {
+		return nil, zip.ErrFormat
+	}
+
+	head := make([]byte, saltSize+aesVerifySize)
+	if _, err := io.ReadFull(raw, head); err != nil {
+		return nil, err
+	}
+	key, macKey, verifier := aesKeys(password, head[:saltSize], keySize)
+	if subtle.ConstantTimeCompare(verifier, head[saltSize:]) != 1 {
+		return nil, ErrPassword
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aesReader{
+		r:   io.LimitReader(raw, int64(size-overhead)),
+		raw: raw,
+		ctr: newAESCTR(block),
+		mac: hmac.New(sha1.New, macKey),
+	}, nil
+}
+
+// aesReader decrypts WinZip AES data, checking the MAC that follows it
+// once it has all been read.
+type aesReader struct {
+	r   io.Reader // the encrypted data
+	raw io.Reader // the encrypted data and the MAC
+	ctr *aesCTR
+	mac hash.Hash
+}
+
+func (a *aesReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	a.mac.Write(p[:n])
+	a.ctr.XORKeyStream(p[:n], p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+
+	want := make([]byte, aesMACSize)
+	if _, err := io.ReadFull(a.raw, want); err != nil {
+		return n, err
+	}
+	if !hmac.Equal(want, a.mac.Sum(nil)[:aesMACSize]) {
+		return n, zip.ErrChecksum
+	}
+	return n, io.EOF
+}
+
+// decryptZipCrypto returns the plaintext of the traditionally encrypted
+// entry f read from raw.
+func decryptZipCrypto(raw io.Reader, f *zip.File, password string) (io.Reader, error) {
+	if f.CompressedSize64 < zipCryptoHeaderSize {
+		return nil, zip.ErrFormat
+	}
+
+	z := newZipCrypto(password)
+	head := make([]byte, zipCryptoHeaderSize)
+	if _, err := io.ReadFull(raw, head); err != nil {
+		return nil, err
+	}
+	z.decrypt(head)
+
+	// the last header byte repeats the high byte of the checksum, or of
+	// the time when the checksum follows the data
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&0x8 != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if head[zipCryptoHeaderSize-1] != check {
+		return nil, ErrPassword
+	}
+
+	lr := io.LimitReader(raw, int64(f.CompressedSize64-zipCryptoHeaderSize))
+	return &zipCryptoReader{r: lr, z: z}, nil
+}
+
+// zipCrypto holds the keys of the traditional PKWARE encryption.
+type zipCrypto [3]uint32
+
+func newZipCrypto(password string) *zipCrypto {
+	z := &zipCrypto{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		z.update(password[i])
+	}
+	return z
+}
+
+func (z *zipCrypto) update(b byte) {
+	z[0] = crc32.IEEETable[byte(z[0])^b] ^ z[0]>>8
+	z[1] = (z[1]+z[0]&0xff)*134775813 + 1
+	z[2] = crc32.IEEETable[byte(z[2])^byte(z[1]>>24)] ^ z[2]>>8
+}
+
+func (z *zipCrypto) decrypt(p []byte) {
+	for i, c := range p {
+		t := z[2] | 2
+		p[i] = c ^ byte(t*(t^1)>>8)
+		z.update(p[i])
+	}
+}
+
+// zipCryptoReader decrypts traditionally encrypted data.
+type zipCryptoReader struct {
+	r io.Reader
+	z *zipCrypto
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.z.decrypt(p[:n])
+	return n, err
+}
+
+// checkedReader checks the size and, if crc is not nil, the checksum of
+// decrypted contents once they have all been read.
+type checkedReader struct {
+	rc   io.ReadCloser
+	hash hash.Hash32
+	crc  *uint32
+	size uint64
+	n    uint64
+}
+
+func (c *checkedReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.hash.Write(p[:n])
+	c.n += uint64(n)
+	if err != io.EOF {
+		return n, err
+	}
+
+	if c.n != c.size {
+		return n, fmt.Errorf("%w: size mismatch", zip.ErrFormat)
+	}
+	if c.crc != nil && c.hash.Sum32() != *c.crc {
+		return n, zip.ErrChecksum
+	}
+	return n, io.EOF
+}
+
+func (c *checkedReader) Close() error {
+	return c.rc.Close()
+}
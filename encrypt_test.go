@@ -0,0 +1,102 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestPasswordRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "secret")
+	writeTree(t, src, map[string]string{
+		"a.txt":     "attack at dawn",
+		"dir/b.txt": strings.Repeat("compressible ", 1000),
+		"empty.txt": "",
+	})
+
+	zipPath, err := Zip(src, WithPassword("hunter2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range r.File {
+		if !f.Mode().IsDir() && (f.Method != methodAES || f.Flags&0x1 == 0) {
+			t.Errorf("expected %s to be AES encrypted, got method %d", f.Name, f.Method)
+		}
+	}
+	r.Close()
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithPassword("hunter2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
+
+func TestPasswordIncorrect(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "secret")
+	writeTree(t, src, map[string]string{"a.txt": "attack at dawn"})
+
+	zipPath, err := Zip(src, WithPassword("hunter2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	err = Unzip(zipPath, t.TempDir(), WithPassword("letmein"))
+	if !errors.Is(err, ErrPassword) {
+		t.Errorf("expected ErrPassword, got %v", err)
+	}
+}
+
+func TestPasswordZipCrypto(t *testing.T) {
+	// written by Info-ZIP zip -P hunter2
+	dest := t.TempDir()
+	if err := Unzip("testdata/zipcrypto.zip", dest, WithPassword("hunter2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := zipptest.ReadTree(t, dest)
+	if string(got["secret.txt"]) != "hello, traditional\n" {
+		t.Errorf("unexpected contents %q", got["secret.txt"])
+	}
+	if len(got["numbers.txt"]) != 8893 {
+		t.Errorf("expected 8893 bytes of numbers.txt, got %d", len(got["numbers.txt"]))
+	}
+
+	err := Unzip("testdata/zipcrypto.zip", t.TempDir(), WithPassword("letmein"))
+	if !errors.Is(err, ErrPassword) {
+		t.Errorf("expected ErrPassword, got %v", err)
+	}
+}
+
+func TestPasswordRejectsManifest(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "secret")
+	writeTree(t, src, map[string]string{"a.txt": "a"})
+
+	if _, err := Zip(src, WithPassword("hunter2"), WithDedup()); err == nil {
+		t.Error("expected an error for an encrypted archive with a manifest")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	if err := Verify("testdata/zipcrypto.zip", WithPassword("hunter2")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := Verify("testdata/zipcrypto.zip")
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || len(verifyErr.Entries) != 2 || !errors.Is(err, ErrPassword) {
+		t.Errorf("expected both entries to fail with ErrPassword, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrInvalidPath is returned for a path that cannot be archived or
+// extracted: an input naming no archive, such as "." or "/", or an entry
+// name that is absolute or escapes the destination.
+var ErrInvalidPath = errors.New("invalid path")
+
+// ErrNotFound is returned when a path to archive does not exist, or an
+// entry refers to one the archive lacks. It also matches fs.ErrNotExist.
+var ErrNotFound error = notFoundError{}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return "not found"
+}
+
+func (notFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
+// PathError records an error and the operation and path, or entry name,
+// that caused it, like fs.PathError does for files.
+type PathError struct {
+	Entry string
+	Op    string
+	Err   error
+}
+
+func (e *PathError) Error() string {
+	return e.Op + " " + e.Entry + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// invalidPath returns an error wrapping ErrInvalidPath that gives the
+// reason a path was rejected.
+func invalidPath(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPath, fmt.Sprintf(format, args...))
+}
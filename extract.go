@@ -0,0 +1,344 @@
+package zipper
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// sniffSize is how much of a file Extract reads to detect its format,
+// enough to reach the magic of a tar header.
+const sniffSize = 512
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// Extract extracts the archive at src into the directory dest, detecting
+// the format from the file's contents rather than its name. Zip archives,
+// including split and self-extracting ones, are extracted by Unzip.
+// Tarballs, plain or compressed with gzip, zstd or xz, and 7z archives
+// are extracted with the same safety checks and options, except
+// WithComponents, which needs a zip manifest.
+//
+// A gzip, zstd or xz file that does not hold a tarball is decompressed to
+// a single file in dest, named after the gzip header or, failing that,
+// the source file without its extension.
+//
+// Tarballs and 7z archives record no compressed sizes per entry, so
+// Limits.MaxRatio applies to the archive as a whole. Entries other than
+// files, directories and symlinks fail the extraction, or are skipped
+// and recorded when WithReport is given.
+func Extract(src, dest string, opts ...Option) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head, err := sniff(f)
+	if err != nil {
+		return err
+	}
+
+	if !isCompressed(head) && !isTar(head) && !is7z(head) {
+		f.Close()
+		return Unzip(src, dest, opts...)
+	}
+
+	o, err := newExtractOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if is7z(head) {
+		f.Close()
+		return extract7z(src, dest, o)
+	}
+
+	return extractStream(f, head, src, dest, o)
+}
+
+// ExtractReader extracts an archive read sequentially from r, such as
+// stdin or a network stream, into the directory dest, detecting the
+// format as Extract does. Zip archives are extracted by UnzipReader, with
+// its limitations, and tarballs as by Extract. A compressed file that is
+// not a tarball can only be extracted if its gzip header records a name.
+// 7z archives need random access and are not supported.
+func ExtractReader(r io.Reader, dest string, opts ...Option) error {
+	return extractReader(r, "", dest, opts)
+}
+
+// extractReader implements ExtractReader, naming a single compressed file
+// after src if its header does not.
+func extractReader(r io.Reader, src, dest string, opts []Option) error {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if is7z(head) {
+		return fmt.Errorf("7z archives cannot be read from a stream: %w", errors.ErrUnsupported)
+	}
+	if !isCompressed(head) && !isTar(head) {
+		return UnzipReader(br, dest, opts...)
+	}
+
+	o, err := newExtractOptions(opts)
+	if err != nil {
+		return err
+	}
+	return extractStream(br, head, src, dest, o)
+}
+
+// newExtractOptions returns the options for extracting an archive other
+// than a zip.
+func newExtractOptions(opts []Option) (*options, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.components != nil {
+		return nil, errors.New("selecting components requires a zip archive")
+	}
+	return o, nil
+}
+
+// extractStream extracts a tarball, or a single compressed file, read
+// from r, whose first bytes are head. src names the single file when its
+// header does not.
+func extractStream(r io.Reader, head []byte, src, dest string, o *options) error {
+	input := &countingReader{r: r}
+	dr, err := decompress(input, head)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+
+	e, err := newExtractor(dest, o)
+	if err != nil {
+		return err
+	}
+	defer e.abort()
+	e.input = &input.n
+
+	// a compressed stream holding anything but a tarball is a single file
+	br := bufio.NewReaderSize(dr, sniffSize)
+	inner, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !isTar(inner) {
+		f := singleEntry(src, dr)
+		if f.name == "" {
+			return errors.New("cannot name the file in a compressed stream without a gzip header")
+		}
+		err = e.extractSingle(br, f)
+	} else {
+		err = e.extractTar(tar.NewReader(br))
+	}
+	if err != nil {
+		return err
+	}
+	return e.finish()
+}
+
+// singleEntry describes the file held by the compressed stream r read
+// from src. Its name comes from the gzip header when present, and is
+// otherwise src's base name without the compression extension.
+func singleEntry(src string, r io.Reader) entry {
+	f := entry{mode: 0644, size: -1, compressed: -1, noMode: true}
+	if zr, ok := r.(*gzip.Reader); ok {
+		f.name = filepath.Base(zr.Name)
+		f.modified = zr.ModTime
+	}
+	if (f.name == "" || f.name == "." || f.name == string(filepath.Separator)) && src != "" {
+		base := filepath.Base(src)
+		f.name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if f.name == "." || f.name == string(filepath.Separator) {
+		f.name = ""
+	}
+	return f
+}
+
+// extractSingle writes the decompressed contents of r as the entry f.
+func (e *extractor) extractSingle(r io.Reader, f entry) error {
+	if !e.o.selectedEntry(f.name) {
+		return nil
+	}
+	return e.extract(f, func() (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	})
+}
+
+// sniff returns the start of f for format detection, leaving f at its
+// start.
+func sniff(f *os.File) ([]byte, error) {
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// isCompressed reports whether head starts with a supported compression
+// format's magic bytes.
+func isCompressed(head []byte) bool {
+	return bytes.HasPrefix(head, gzipMagic) || bytes.HasPrefix(head, zstdMagic) || bytes.HasPrefix(head, xzMagic)
+}
+
+// isTar reports whether head is a POSIX or GNU tar header.
+func isTar(head []byte) bool {
+	return len(head) >= 262 && string(head[257:262]) == "ustar"
+}
+
+// decompress returns a reader decompressing r according to the magic
+// bytes in head, or r itself if it is not compressed.
+func decompress(r io.Reader, head []byte) (io.ReadCloser, error) {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case bytes.HasPrefix(head, xzMagic):
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+// extractTar writes the entries of a tarball below dest.
+func (e *extractor) extractTar(tr *tar.Reader) error {
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// global PAX headers carry no entry
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		count++
+		if err := e.o.limits.checkEntry(hdr.Name, count); err != nil {
+			return err
+		}
+
+		if !e.o.selectedEntry(hdr.Name) {
+			continue
+		}
+
+		// special files are left to WithSpecialFiles
+		f := tarEntry(hdr)
+		if f.mode&specialTypes == 0 {
+			ok, err := e.o.recordUnsupported(unsupportedTarFeatures(hdr))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if err := e.extract(f, tarOpener(tr, hdr)); err != nil {
+			return err
+		}
+	}
+}
+
+// tarEntry returns the description of a tar entry.
+func tarEntry(hdr *tar.Header) entry {
+	f := entry{
+		name:       hdr.Name,
+		mode:       hdr.FileInfo().Mode(),
+		modified:   hdr.ModTime,
+		size:       hdr.Size,
+		compressed: -1,
+		owner:      &owner{uid: hdr.Uid, gid: hdr.Gid},
+	}
+	switch hdr.Typeflag {
+	case tar.TypeSymlink:
+		f.size = int64(len(hdr.Linkname))
+	case tar.TypeChar, tar.TypeBlock:
+		f.device = &device{major: uint32(hdr.Devmajor), minor: uint32(hdr.Devminor)}
+	}
+	return f
+}
+
+// tarOpener returns the contents of the current tar entry, which for a
+// symlink is its target.
+func tarOpener(tr *tar.Reader, hdr *tar.Header) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		if hdr.Typeflag == tar.TypeSymlink {
+			return io.NopCloser(strings.NewReader(hdr.Linkname)), nil
+		}
+		return io.NopCloser(tr), nil
+	}
+}
+
+// tarTypes names the tar entry types that cannot be extracted.
+var tarTypes = map[byte]string{
+	tar.TypeLink:  "hard link",
+	tar.TypeChar:  "character device",
+	tar.TypeBlock: "block device",
+	tar.TypeFifo:  "named pipe",
+}
+
+// unsupportedTarFeatures inspects hdr for features that cannot be
+// honored.
+func unsupportedTarFeatures(hdr *tar.Header) []Unsupported {
+	switch hdr.Typeflag {
+	case tar.TypeReg, tar.TypeDir, tar.TypeSymlink:
+		return nil
+	}
+
+	detail, ok := tarTypes[hdr.Typeflag]
+	if !ok {
+		detail = fmt.Sprintf("type %q", hdr.Typeflag)
+	}
+	return []Unsupported{{Entry: hdr.Name, Feature: FeatureEntryType, Detail: detail, Skipped: true}}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
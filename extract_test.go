@@ -0,0 +1,187 @@
+package zipper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+	"github.com/ulikunitz/xz"
+)
+
+// tarTestEntry is an entry for createTar; body is the link target of
+// symlinks.
+type tarTestEntry struct {
+	typ  byte
+	name string
+	body string
+}
+
+// createTar writes the entries verbatim into a new tarball, compressed by
+// compress unless it is nil.
+func createTar(t *testing.T, compress func(io.Writer) (io.WriteCloser, error), entries ...tarTestEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "crafted.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.WriteCloser = nopWriteCloser{f}
+	if compress != nil {
+		if w, err = compress(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{Typeflag: e.typ, Name: e.name, Mode: 0644}
+		switch e.typ {
+		case tar.TypeReg:
+			hdr.Size = int64(len(e.body))
+		case tar.TypeSymlink:
+			hdr.Linkname = e.body
+		case tar.TypeDir:
+			hdr.Mode = 0755
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if e.typ == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func gzipCompress(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func xzCompress(w io.Writer) (io.WriteCloser, error) {
+	return xz.NewWriter(w)
+}
+
+func TestExtractFormats(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "extract-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	for _, format := range []Format{FormatZip, FormatTarGz, FormatTarZst} {
+		t.Run(format.String(), func(t *testing.T) {
+			archivePath, err := Zip(src, WithFormat(format))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(archivePath)
+
+			// the name must not matter
+			renamed := filepath.Join(t.TempDir(), "archive.bin")
+			if err := os.Rename(archivePath, renamed); err != nil {
+				t.Fatal(err)
+			}
+
+			dest := t.TempDir()
+			if err := Extract(renamed, dest); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			zipptest.AssertTreesEqual(t, src, dest)
+		})
+	}
+}
+
+func TestExtractTar(t *testing.T) {
+	entries := []tarTestEntry{
+		{tar.TypeDir, "dir/", ""},
+		{tar.TypeReg, "dir/a.txt", "alpha"},
+		{tar.TypeSymlink, "dir/link", "a.txt"},
+	}
+
+	for name, compress := range map[string]func(io.Writer) (io.WriteCloser, error){
+		"plain": nil,
+		"xz":    xzCompress,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tarPath := createTar(t, compress, entries...)
+
+			dest := t.TempDir()
+			if err := Extract(tarPath, dest); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dest, "dir", "link"))
+			if err != nil || string(data) != "alpha" {
+				t.Errorf("expected alpha through the link, got %q (%v)", data, err)
+			}
+		})
+	}
+}
+
+func TestExtractTarTraversal(t *testing.T) {
+	tarPath := createTar(t, gzipCompress, tarTestEntry{tar.TypeReg, "../evil.txt", "evil"})
+
+	root := t.TempDir()
+	dest := filepath.Join(root, "dest")
+	err := Extract(tarPath, dest)
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) || pathErr.Entry != "../evil.txt" || !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("expected an invalid path error for ../evil.txt, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Error("expected no file outside dest")
+	}
+}
+
+func TestExtractTarUnsupported(t *testing.T) {
+	tarPath := createTar(t, gzipCompress,
+		tarTestEntry{tar.TypeFifo, "pipe", ""},
+		tarTestEntry{tar.TypeReg, "a.txt", "alpha"},
+	)
+
+	err := Extract(tarPath, t.TempDir())
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+
+	var report Report
+	dest := t.TempDir()
+	if err := Extract(tarPath, dest, WithReport(&report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Unsupported{Entry: "pipe", Feature: FeatureEntryType, Detail: "named pipe", Skipped: true}
+	if len(report.Unsupported) != 1 || report.Unsupported[0] != want {
+		t.Errorf("expected %v, got %v", want, report.Unsupported)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); err != nil {
+		t.Errorf("expected a.txt to be extracted: %v", err)
+	}
+}
+
+func TestExtractTarMaxRatio(t *testing.T) {
+	tarPath := createTar(t, gzipCompress, tarTestEntry{tar.TypeReg, "zeros", strings.Repeat("\x00", 4<<20)})
+
+	err := Extract(tarPath, t.TempDir(), WithLimits(Limits{MaxRatio: 10}))
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxRatio" {
+		t.Errorf("expected a MaxRatio limit error, got %v", err)
+	}
+}
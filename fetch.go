@@ -0,0 +1,224 @@
+package zipper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// defaultRetries is how many times ExtractURL resumes an interrupted
+// download unless WithRetries says otherwise.
+const defaultRetries = 3
+
+// ErrChecksum is returned by ExtractURL when the downloaded archive does
+// not match the checksum given to WithSHA256.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// WithHTTPClient sets the client ExtractURL downloads with. The default
+// is http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithSHA256 makes ExtractURL verify the downloaded archive against the
+// hex-encoded SHA-256 digest sum.
+func WithSHA256(sum string) Option {
+	return func(o *options) {
+		o.sha256 = strings.ToLower(sum)
+	}
+}
+
+// WithRetries sets how many times ExtractURL resumes a download that
+// fails part way, picking up where it stopped with a range request. The
+// default is 3; 0 disables resuming.
+func WithRetries(n int) Option {
+	return func(o *options) {
+		o.retries = n
+	}
+}
+
+// ExtractURL downloads the archive at rawURL and extracts it into the
+// directory dest in a single streaming pass, without storing the archive.
+// The format is detected as by ExtractReader, which does the extraction.
+//
+// A download that breaks off is resumed from where it stopped, provided
+// the server supports range requests and the resource is unchanged.
+// With WithSHA256 the whole archive is hashed as it streams past and
+// checked once it has been read, before the extraction completes. A
+// mismatch fails it with ErrChecksum as a broken entry would, so
+// WithCleanupPolicy decides what becomes of the files written; under the
+// default CleanupKeep they stay, and cannot be trusted.
+//
+// Zip archives are read as UnzipReader reads them, from their local
+// headers alone, so the embedded manifest is not consulted: files stored
+// once by WithDedup or WithHardLinks are extracted only under the name of
+// the entry holding their contents. Download such archives and use Unzip
+// instead.
+func ExtractURL(ctx context.Context, rawURL, dest string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	client := o.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	rr := &resumingReader{ctx: ctx, client: client, url: rawURL, retries: o.retries}
+	if err := rr.open(); err != nil {
+		return err
+	}
+	defer rr.Close()
+
+	var r io.Reader = rr
+	if o.sha256 != "" {
+		sum := sha256.New()
+		r = io.TeeReader(rr, sum)
+		opts = append(opts[:len(opts):len(opts)], withInputCheck(func() error {
+			return checkSum(r, sum, o.sha256, rawURL)
+		}))
+	}
+
+	return extractReader(r, urlBase(rawURL), dest, opts)
+}
+
+// withInputCheck makes extraction run check once every entry is written,
+// failing as a broken entry would if it does.
+func withInputCheck(check func() error) Option {
+	return func(o *options) {
+		o.checkInput = check
+	}
+}
+
+// checkSum reads the rest of r, which feeds sum, and checks that the
+// whole input hashes to want.
+func checkSum(r io.Reader, sum hash.Hash, want, rawURL string) error {
+	// extraction may stop before the end, e.g. at a zip central directory
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); got != want {
+		return fmt.Errorf("%s: %w: got sha256 %s", rawURL, ErrChecksum, got)
+	}
+	return nil
+}
+
+// urlBase returns the last element of the URL's path, used to name a
+// single compressed file.
+func urlBase(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// resumingReader reads the body of a GET request, re-requesting the rest
+// with a range request when the connection fails part way.
+type resumingReader struct {
+	ctx     context.Context
+	client  *http.Client
+	url     string
+	retries int
+
+	body      io.ReadCloser
+	n         int64  // bytes read so far
+	validator string // ETag or Last-Modified, to resume the same version
+}
+
+// open makes the initial request.
+func (r *resumingReader) open() error {
+	resp, err := r.get("")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("downloading %s: %s", r.url, resp.Status)
+	}
+
+	r.body = resp.Body
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		r.validator = etag
+	} else {
+		r.validator = resp.Header.Get("Last-Modified")
+	}
+	return nil
+}
+
+// resume requests the rest of the body from the current offset.
+func (r *resumingReader) resume() error {
+	if r.validator == "" {
+		return errors.New("resource has no validator to resume against")
+	}
+
+	resp, err := r.get(fmt.Sprintf("bytes=%d-", r.n))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent ||
+		!strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", r.n)) {
+		resp.Body.Close()
+		return fmt.Errorf("resuming %s: %s", r.url, resp.Status)
+	}
+
+	r.body = resp.Body
+	return nil
+}
+
+func (r *resumingReader) get(byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+		req.Header.Set("If-Range", r.validator)
+	}
+	return r.client.Do(req)
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.n += int64(n)
+
+	if err == nil || err == io.EOF || r.retries <= 0 || r.ctx.Err() != nil {
+		return n, err
+	}
+
+	r.body.Close()
+	if rerr := r.resume(); rerr != nil {
+		r.body = io.NopCloser(errReader{err})
+		return n, fmt.Errorf("%w (%v)", err, rerr)
+	}
+	r.retries--
+
+	if n > 0 {
+		return n, nil
+	}
+	return r.Read(p)
+}
+
+func (r *resumingReader) Close() error {
+	return r.body.Close()
+}
+
+// errReader fails every read with err.
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
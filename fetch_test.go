@@ -0,0 +1,135 @@
+package zipper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// serveArchive serves data with range support. If cut is set, the first
+// full request is cut off half way through.
+func serveArchive(t *testing.T, data []byte, cut bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var ranges atomic.Int32
+	var cutOnce atomic.Bool
+	cutOnce.Store(cut)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		} else if cutOnce.Swap(false) {
+			w.Header().Set("Content-Length", "1000000")
+			w.Write(data[:len(data)/2])
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "archive", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &ranges
+}
+
+// archiveBytes zips src in format and returns the archive's contents.
+func archiveBytes(t *testing.T, src string, format Format) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := ZipTo(&buf, src, WithFormat(format)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractURL(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "url-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	for _, format := range []Format{FormatZip, FormatTarGz} {
+		t.Run(format.String(), func(t *testing.T) {
+			data := archiveBytes(t, src, format)
+			srv, _ := serveArchive(t, data, false)
+
+			sum := sha256.Sum256(data)
+			dest := t.TempDir()
+			err := ExtractURL(context.Background(), srv.URL+"/archive", dest, WithSHA256(hex.EncodeToString(sum[:])))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			zipptest.AssertTreesEqual(t, src, dest)
+		})
+	}
+}
+
+func TestExtractURLChecksum(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "url-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	srv, _ := serveArchive(t, archiveBytes(t, src, FormatZip), false)
+
+	wrong := hex.EncodeToString(make([]byte, sha256.Size))
+	err := ExtractURL(context.Background(), srv.URL, t.TempDir(), WithSHA256(wrong))
+	if !errors.Is(err, ErrChecksum) {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+
+	// a mismatch rolls back as a failed extraction does
+	dest := filepath.Join(t.TempDir(), "out")
+	err = ExtractURL(context.Background(), srv.URL, dest, WithSHA256(wrong), WithCleanupPolicy(CleanupRollback))
+	if !errors.Is(err, ErrChecksum) {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected the extraction rolled back, got %v", err)
+	}
+
+	if err := ExtractURL(context.Background(), srv.URL, t.TempDir(), WithSHA256("abc")); err == nil {
+		t.Error("expected an error for a malformed checksum")
+	}
+}
+
+func TestExtractURLResume(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "url-src")
+	writeTree(t, src, map[string]string{"big.bin": randomString(t, 100<<10), "a.txt": "alpha"})
+	data := archiveBytes(t, src, FormatTarGz)
+
+	srv, ranges := serveArchive(t, data, true)
+
+	dest := t.TempDir()
+	if err := ExtractURL(context.Background(), srv.URL, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	if ranges.Load() != 1 {
+		t.Errorf("expected 1 range request, got %d", ranges.Load())
+	}
+
+	srv, _ = serveArchive(t, data, true)
+	if err := ExtractURL(context.Background(), srv.URL, t.TempDir(), WithRetries(0)); err == nil {
+		t.Error("expected an error without retries")
+	}
+}
+
+func TestExtractURLStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "dest")
+	if err := ExtractURL(context.Background(), srv.URL, dest); err == nil {
+		t.Error("expected an error for a missing archive")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("expected nothing to be created")
+	}
+}
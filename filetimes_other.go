@@ -0,0 +1,20 @@
+//go:build !windows
+
+package zipper
+
+import (
+	"io/fs"
+	"time"
+)
+
+// statTimes reports no access or creation times, which are not available
+// portably outside Windows.
+func statTimes(info fs.FileInfo) (accessed, created time.Time) {
+	return time.Time{}, time.Time{}
+}
+
+// setCreated does nothing, as creation times cannot be set outside
+// Windows.
+func setCreated(path string, t time.Time) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+package zipper
+
+import "fmt"
+
+// Find returns the entries of the archive at zipPath whose names match
+// pattern, in archive order, without extracting anything. Patterns are
+// those of WithInclude, so "**/*.conf" and "*.conf" both find .conf files
+// at any depth, and "etc/*.conf" only those directly in etc.
+func Find(zipPath, pattern string) ([]EntryInfo, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	found := entries[:0]
+	for _, e := range entries {
+		if match(pattern, e.Name) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,45 @@
+package zipper
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "etc")
+	writeTree(t, src, map[string]string{
+		"app.conf":         "a",
+		"nginx/site.conf":  "b",
+		"nginx/mime.types": "c",
+		"deep/x/y/z.conf":  "d",
+	})
+	zipPath := zipAside(t, src, WithOrder(OrderName))
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.conf", []string{"app.conf", "deep/x/y/z.conf", "nginx/site.conf"}},
+		{"nginx/*", []string{"nginx/mime.types", "nginx/site.conf"}},
+		{"*.txt", nil},
+	}
+	for _, tt := range tests {
+		found, err := Find(zipPath, tt.pattern)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.pattern, err)
+		}
+		if len(found) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.pattern, tt.want, found)
+			continue
+		}
+		for i, e := range found {
+			if e.Name != tt.want[i] {
+				t.Errorf("%s: expected %s, got %s", tt.pattern, tt.want[i], e.Name)
+			}
+		}
+	}
+
+	if _, err := Find(zipPath, "[a"); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
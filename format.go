@@ -0,0 +1,248 @@
+package zipper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format selects the kind of archive Zip writes.
+type Format int
+
+const (
+	// FormatZip writes a zip archive. It is the default.
+	FormatZip Format = iota
+
+	// FormatTarGz writes a gzip-compressed tarball.
+	FormatTarGz
+
+	// FormatTarZst writes a zstd-compressed tarball, which is faster to
+	// write and usually smaller than FormatTarGz.
+	FormatTarZst
+
+	// FormatGzip compresses a single file with gzip, without any
+	// archive container, giving "<name>.gz".
+	FormatGzip
+)
+
+// WithFormat sets the kind of archive Zip writes. The walk and entry
+// naming are the same for every format; zip-specific options such as
+// WithManifest, WithGroup and WithSelfExtractor require FormatZip.
+func WithFormat(f Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// ext returns the file extension of archives in the format.
+func (f Format) ext() string {
+	switch f {
+	case FormatTarGz:
+		return ".tar.gz"
+	case FormatTarZst:
+		return ".tar.zst"
+	case FormatGzip:
+		return ".gz"
+	default:
+		return ".zip"
+	}
+}
+
+// contentType returns the MIME type of archives in the format.
+func (f Format) contentType() string {
+	switch f {
+	case FormatTarGz, FormatGzip:
+		return "application/gzip"
+	case FormatTarZst:
+		return "application/zstd"
+	default:
+		return "application/zip"
+	}
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatZip:
+		return "zip"
+	case FormatTarGz:
+		return "tar.gz"
+	case FormatTarZst:
+		return "tar.zst"
+	case FormatGzip:
+		return "gzip"
+	default:
+		return "unknown"
+	}
+}
+
+// compressor returns a writer compressing a tarball onto w at level, 0
+// for the default.
+func (f Format) compressor(w io.Writer, level int) (io.WriteCloser, error) {
+	switch f {
+	case FormatTarGz:
+		return gzipWriter(w, level)
+	case FormatTarZst:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(level)))
+	default:
+		return nil, fmt.Errorf("%s is not a tarball format", f)
+	}
+}
+
+// gzipWriter returns a gzip writer onto w at level, 0 for the default.
+func gzipWriter(w io.Writer, level int) (*gzip.Writer, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// writeTarball writes files below root to w as a tarball compressed
+// according to o.format.
+func writeTarball(w io.Writer, root string, files []string, o *options) error {
+	o.progress.expectFiles(files)
+
+	bw := o.bufferedWriter(o.meterWriter(w))
+	defer releaseWriter(bw)
+
+	zw, err := o.format.compressor(bw, o.level)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(zw)
+	for _, file := range files {
+		if err := writeTarEntry(tw, root, file, o); err != nil {
+			zw.Close()
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeTarEntry adds a single file to the tarball.
+func writeTarEntry(tw *tar.Writer, root, file string, o *options) error {
+	// once its header is written an entry can no longer be left out
+	start := time.Now()
+	f, err := o.openTimed(file)
+	if err != nil {
+		return o.unreadable(file, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return o.unreadable(file, err)
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+
+	name, err := o.entryName(root, file)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+
+	if o.reproducible {
+		hdr.Mode = int64(normalizeMode(info.Mode()).Perm())
+		hdr.ModTime = o.epoch
+		hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+		hdr.Uid, hdr.Gid = 0, 0
+		hdr.Uname, hdr.Gname = "", ""
+	}
+
+	// a named pipe, read with SpecialRead, is only sized once read
+	body := o.timedReader(file, f, start)
+	if info.Mode()&fs.ModeNamedPipe != 0 {
+		buf := newSpillBuffer(spillThreshold)
+		defer buf.Release()
+		if _, err := o.copy(buf, body); err != nil {
+			return o.unreadable(file, err)
+		}
+		if body, err = buf.Reader(); err != nil {
+			return err
+		}
+		hdr.Typeflag, hdr.Size = tar.TypeReg, buf.Len()
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	// the file may have changed size since it was statted
+	n, err := o.copy(tw, o.source(io.LimitReader(body, hdr.Size)))
+	if err == nil && n < hdr.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+
+	entry := EntryInfo{Name: name, Size: n, CompressedSize: -1, Mode: info.Mode(), Modified: hdr.ModTime}
+	o.fileAdded(entry, false)
+	o.entryDone(entry)
+	return nil
+}
+
+// writeGzip compresses a single file to w, recording its name and
+// modification time in the gzip header.
+func writeGzip(w io.Writer, file string, o *options) error {
+	start := time.Now()
+	f, err := o.openTimed(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	o.progress.setTotal(info.Size())
+
+	bw := o.bufferedWriter(o.meterWriter(w))
+	defer releaseWriter(bw)
+
+	zw, err := gzipWriter(bw, o.level)
+	if err != nil {
+		return err
+	}
+	zw.Name = filepath.Base(file)
+	zw.ModTime = info.ModTime()
+	if o.reproducible {
+		zw.ModTime = o.epoch
+	}
+
+	n, err := o.copy(zw, o.source(o.timedReader(file, f, start)))
+	if err != nil {
+		zw.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	entry := EntryInfo{Name: zw.Name, Size: n, CompressedSize: -1, Mode: info.Mode(), Modified: zw.ModTime}
+	o.fileAdded(entry, false)
+	o.entryDone(entry)
+	return nil
+}
@@ -0,0 +1,144 @@
+package zipper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+	"github.com/klauspost/compress/zstd"
+)
+
+// readTarball returns the contents of the regular files in a tarball.
+func readTarball(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(data)
+	}
+	return files
+}
+
+func TestZipTarball(t *testing.T) {
+	tests := []struct {
+		format     Format
+		decompress func(io.Reader) (io.Reader, error)
+	}{
+		{FormatTarGz, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{FormatTarZst, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "tar-src")
+			want := map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"}
+			writeTree(t, src, want)
+
+			tarPath, err := Zip(src, WithFormat(tt.format))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(tarPath)
+
+			if tarPath != "tar-src"+tt.format.ext() {
+				t.Errorf("expected tar-src%s, got %s", tt.format.ext(), tarPath)
+			}
+
+			f, err := os.Open(tarPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			r, err := tt.decompress(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := readTarball(t, r)
+			if len(got) != len(want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+			for name, body := range want {
+				if got[name] != body {
+					t.Errorf("%s: expected %q, got %q", name, body, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestZipTarGzManifest(t *testing.T) {
+	_, err := Zip(t.TempDir(), WithFormat(FormatTarGz), WithManifest())
+	if err == nil {
+		t.Error("expected an error asking for a manifest in a tarball")
+	}
+}
+
+func TestZipGzip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("line one\nline two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzPath, err := Zip(file, WithFormat(FormatGzip))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(gzPath)
+
+	if gzPath != "app.log.gz" {
+		t.Errorf("expected app.log.gz, got %s", gzPath)
+	}
+
+	f, err := os.Open(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "line one\nline two\n" || zr.Name != "app.log" {
+		t.Errorf("unexpected contents %q named %q", data, zr.Name)
+	}
+
+	// Extract restores the file under its recorded name
+	dest := t.TempDir()
+	if err := Extract(gzPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, filepath.Dir(file), dest)
+}
+
+func TestZipGzipDirectory(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "gz-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	if _, err := Zip(src, WithFormat(FormatGzip)); err == nil {
+		t.Error("expected an error compressing a directory with gzip")
+	}
+}
@@ -0,0 +1,60 @@
+package zipper
+
+import (
+	"path"
+	"strings"
+)
+
+// match reports whether the slash-separated name matches pattern.
+//
+// Patterns use path.Match syntax per segment, with "**" additionally
+// matching any number of segments, so "docs/**" matches everything under
+// docs. A pattern without a slash is matched against the base name only,
+// so "*.sql" matches .sql files at any depth.
+func match(pattern, name string) bool {
+	name = strings.Trim(name, "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// collapse repeated wildcards and try every split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validatePattern reports whether pattern is well formed.
+func validatePattern(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
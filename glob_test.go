@@ -0,0 +1,41 @@
+package zipper
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.sql", name: "dump.sql", want: true},
+		{pattern: "*.sql", name: "db/backups/dump.sql", want: true},
+		{pattern: "*.sql", name: "dump.sql.gz", want: false},
+		{pattern: "docs/**", name: "docs/index.md", want: true},
+		{pattern: "docs/**", name: "docs/api/v1/index.md", want: true},
+		{pattern: "docs/**", name: "docs/", want: true},
+		{pattern: "docs/**", name: "src/docs/index.md", want: false},
+		{pattern: "**/testdata/*", name: "a/b/testdata/x.json", want: true},
+		{pattern: "**/testdata/*", name: "testdata/x.json", want: true},
+		{pattern: "**/testdata/*", name: "a/testdata/b/x.json", want: false},
+		{pattern: "src/*.go", name: "src/main.go", want: true},
+		{pattern: "src/*.go", name: "src/pkg/main.go", want: false},
+		{pattern: "a/**/z.txt", name: "a/z.txt", want: true},
+		{pattern: "a/**/z.txt", name: "a/b/c/z.txt", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	if err := validatePattern("docs/**/*.md"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validatePattern("docs/[a-"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,79 @@
+package zipper
+
+import "fmt"
+
+// entryGroup is a named set of entry patterns given to WithGroup.
+type entryGroup struct {
+	name     string
+	patterns []string
+}
+
+// WithGroup tags the entries Zip writes that match any of the glob
+// patterns as belonging to the named group, or component. Groups are
+// recorded in the embedded manifest, which WithGroup therefore enables,
+// and can be selected on extraction with WithComponents. It may be given
+// more than once, and an entry may belong to several groups.
+func WithGroup(name string, patterns ...string) Option {
+	return func(o *options) {
+		o.groups = append(o.groups, entryGroup{name: name, patterns: patterns})
+		o.manifest = true
+	}
+}
+
+// WithComponents limits Unzip to entries in at least one of the named
+// groups, plus every entry that belongs to no group. Calling it with no
+// names extracts only the ungrouped entries, which makes for a minimal
+// install. The archive must have been created with WithGroup.
+func WithComponents(names ...string) Option {
+	return func(o *options) {
+		if o.components == nil {
+			o.components = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.components[name] = true
+		}
+	}
+}
+
+// groupsFor returns the names of the groups the entry belongs to.
+func (o *options) groupsFor(name string) []string {
+	var groups []string
+	for _, g := range o.groups {
+		for _, p := range g.patterns {
+			if match(p, name) {
+				groups = append(groups, g.name)
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// componentFilter returns a filter selecting the entries of the chosen
+// components, using the groups recorded in the archive's manifest m, nil
+// if it has none. It returns nil when WithComponents was not given.
+func (o *options) componentFilter(m *Manifest) (func(name string) bool, error) {
+	if o.components == nil {
+		return nil, nil
+	}
+	if m == nil {
+		return nil, fmt.Errorf("selecting components: %w", ErrNoManifest)
+	}
+
+	groups := make(map[string][]string, len(m.Entries))
+	for _, e := range m.Entries {
+		groups[e.Name] = e.Groups
+	}
+
+	return func(name string) bool {
+		if len(groups[name]) == 0 {
+			return true
+		}
+		for _, g := range groups[name] {
+			if o.components[g] {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
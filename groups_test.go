@@ -0,0 +1,92 @@
+package zipper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnzipComponents(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"bin/app":            "binary",
+		"docs/guide.md":      "guide",
+		"docs/api.md":        "api",
+		"examples/hello.go":  "hello",
+		"examples/README.md": "examples",
+	})
+
+	zipPath, err := Zip(src,
+		WithGroup("docs", "docs/**", "*.md"),
+		WithGroup("examples", "examples/**"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	tests := []struct {
+		name       string
+		components []string
+		want       []string
+	}{
+		{
+			name:       "minimal install",
+			components: nil,
+			want:       []string{"bin/app"},
+		},
+		{
+			name:       "docs",
+			components: []string{"docs"},
+			want:       []string{"bin/app", "docs/api.md", "docs/guide.md", "examples/README.md"},
+		},
+		{
+			name:       "examples",
+			components: []string{"examples"},
+			want:       []string{"bin/app", "examples/README.md", "examples/hello.go"},
+		},
+		{
+			name:       "full install",
+			components: []string{"docs", "examples"},
+			want:       []string{"bin/app", "docs/api.md", "docs/guide.md", "examples/README.md", "examples/hello.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := Unzip(zipPath, dest, WithComponents(tt.components...)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := listTree(t, dest)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	// without WithComponents everything is extracted
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listTree(t, dest); len(got) != 5 {
+		t.Errorf("expected all 5 files, got %v", got)
+	}
+}
+
+func TestUnzipComponentsWithoutManifest(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "a.txt", body: "a"})
+
+	err := Unzip(zipPath, t.TempDir(), WithComponents("docs"))
+	if !errors.Is(err, ErrNoManifest) {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package zipper
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Handler returns an http.Handler that answers GET and HEAD requests with
+// an archive of the directory or file below root named by the request
+// path, so "/docs" downloads "docs.zip". The archive is streamed with
+// ZipTo as it is produced, never stored, and sent as an attachment named
+// after the directory. The options are passed to ZipTo, so WithFormat
+// serves tarballs instead.
+//
+// Request paths cannot climb out of root, but symlinks within root are
+// followed as by Zip, so root should only hold content meant to be
+// served. If archiving fails part way the connection is aborted, so the
+// client never mistakes a truncated archive for a complete one.
+func Handler(root string, opts ...Option) http.Handler {
+	return &zipHandler{root: root, opts: opts}
+}
+
+type zipHandler struct {
+	root string
+	opts []Option
+}
+
+func (h *zipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	root, err := filepath.Abs(h.root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	target := filepath.Join(root, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, "cannot read path", http.StatusInternalServerError)
+		return
+	}
+
+	o := newOptions(h.opts)
+	name, err := archiveName(target, o)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", o.format.contentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	cw := &countingWriter{w: w}
+	if err := ZipTo(cw, target, h.opts...); err != nil {
+		if cw.n == 0 {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "cannot create archive", http.StatusInternalServerError)
+			return
+		}
+		// the response has begun, so only dropping the connection can
+		// tell the client it is incomplete
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
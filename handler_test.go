@@ -0,0 +1,90 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, filepath.Join(root, "docs"), map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	srv := httptest.NewServer(Handler(root))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %s", resp.Status)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/zip" {
+		t.Errorf("expected application/zip, got %q", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename=docs.zip` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(zr.File))
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, filepath.Join(root, "docs"), map[string]string{"a.txt": "alpha"})
+
+	h := Handler(root)
+	tests := []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound},
+		{http.MethodPost, "/docs", http.StatusMethodNotAllowed},
+		{http.MethodHead, "/docs", http.StatusOK},
+		{http.MethodGet, "/../../docs", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.target, tt.want, rec.Code)
+		}
+		if tt.method == http.MethodHead && rec.Body.Len() != 0 {
+			t.Errorf("HEAD: expected no body, got %d bytes", rec.Body.Len())
+		}
+	}
+}
+
+func TestHandlerFormat(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, filepath.Join(root, "docs"), map[string]string{"a.txt": "alpha"})
+
+	rec := httptest.NewRecorder()
+	Handler(root, WithFormat(FormatTarGz)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename=docs.tar.gz` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), gzipMagic) {
+		t.Error("expected a gzip stream")
+	}
+}
@@ -0,0 +1,89 @@
+package zipper
+
+import (
+	"fmt"
+	"os"
+)
+
+// WithHardLinks makes Zip detect files that are hard links to the same
+// data and store their contents once, as WithDedup does but without
+// hashing. The link is recorded in the embedded manifest, which
+// WithHardLinks therefore enables, with ManifestEntry.HardLink naming the
+// file linked to, and Unzip recreates it as a hard link where the file
+// system allows, falling back to a copy. Hard links are only detected on
+// Unix.
+func WithHardLinks() Option {
+	return func(o *options) {
+		o.hardLinks = true
+		o.manifest = true
+	}
+}
+
+// inode identifies a file on its device.
+type inode struct {
+	dev, ino uint64
+}
+
+// hardLink is a file archived as a hard link to an earlier one.
+type hardLink struct {
+	name   string
+	target string
+}
+
+// splitHardLinks separates the files below root that are hard links to an
+// earlier file in files from the files to archive.
+func splitHardLinks(root string, files []string, o *options) ([]string, []hardLink, error) {
+	first := make(map[inode]string)
+	kept := make([]string, 0, len(files))
+	var links []hardLink
+
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if id, ok := fileID(info); ok {
+			name, err := o.entryName(root, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			if target, ok := first[id]; ok {
+				links = append(links, hardLink{name: name, target: target})
+				continue
+			}
+			first[id] = name
+		}
+
+		kept = append(kept, file)
+	}
+
+	return kept, links, nil
+}
+
+// linkEntries returns the manifest entries of links, which share the
+// contents of their targets among written.
+func linkEntries(links []hardLink, written []ManifestEntry, o *options) ([]ManifestEntry, error) {
+	byName := make(map[string]ManifestEntry, len(written))
+	for _, e := range written {
+		byName[e.Name] = e
+	}
+
+	entries := make([]ManifestEntry, 0, len(links))
+	for _, l := range links {
+		e, ok := byName[l.target]
+		if !ok {
+			return nil, fmt.Errorf("%s: hard link target %s was not archived", l.name, l.target)
+		}
+
+		if e.Duplicate == "" {
+			e.Duplicate = e.Name
+		}
+		e.Name = l.name
+		e.CompressedSize = 0
+		e.HardLink = l.target
+		e.Groups = o.groupsFor(l.name)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package zipper
+
+import "io/fs"
+
+// fileID reports no hard links where file identities are not available.
+func fileID(info fs.FileInfo) (inode, bool) {
+	return inode{}, false
+}
@@ -0,0 +1,80 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are only detected on Unix")
+	}
+
+	src := filepath.Join(t.TempDir(), "linked")
+	writeTree(t, src, map[string]string{"a.txt": "shared", "d.txt": "shared"})
+	for _, name := range []string{"b.txt", "dir/c.txt"} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Link(filepath.Join(src, "a.txt"), path); err != nil {
+			t.Skipf("cannot create hard links: %v", err)
+		}
+	}
+
+	zipPath, err := Zip(src, WithHardLinks())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	// d.txt has the same contents but is not a link
+	names, m := readManifest(t, zipPath)
+	if want := []string{"a.txt", "d.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected entries %v, got %v", want, names)
+	}
+	links := make(map[string]string)
+	for _, e := range m.Entries {
+		if e.HardLink != "" {
+			links[e.Name] = e.HardLink
+		}
+	}
+	if want := map[string]string{"b.txt": "a.txt", "dir/c.txt": "a.txt"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("expected links %v, got %v", want, links)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	a, err := os.Stat(filepath.Join(dest, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b.txt", "dir/c.txt"} {
+		info, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(a, info) {
+			t.Errorf("expected %s to be a hard link to a.txt", name)
+		}
+	}
+
+	// without the file linked to, the contents are written out
+	dest = t.TempDir()
+	if err := Unzip(zipPath, dest, WithInclude("b.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := zipptest.ReadTree(t, dest)
+	if len(got) != 1 || string(got["b.txt"]) != "shared" {
+		t.Errorf("expected only b.txt, got %q", got)
+	}
+}
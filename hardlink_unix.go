@@ -0,0 +1,18 @@
+//go:build unix
+
+package zipper
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID returns the identity of the file described by info, and whether
+// it has more than one hard link.
+func fileID(info fs.FileInfo) (inode, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink < 2 {
+		return inode{}, false
+	}
+	return inode{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
@@ -0,0 +1,21 @@
+package zipper
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// WithIncludeHidden sets whether Zip archives hidden files and
+// directories, those named with a leading dot and, on Windows, those with
+// the hidden attribute. They are included by default; the inputs
+// themselves always are.
+func WithIncludeHidden(include bool) Option {
+	return func(o *options) {
+		o.skipHidden = !include
+	}
+}
+
+// isHidden reports whether the file or directory d is hidden.
+func isHidden(d fs.DirEntry) bool {
+	return strings.HasPrefix(d.Name(), ".") || hasHiddenAttribute(d)
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package zipper
+
+import "io/fs"
+
+// hasHiddenAttribute reports false, as files are hidden by name alone
+// outside Windows.
+func hasHiddenAttribute(d fs.DirEntry) bool {
+	return false
+}
@@ -0,0 +1,38 @@
+package zipper
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestIncludeHidden(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "project")
+	writeTree(t, src, map[string]string{
+		"main.go":     "package main",
+		".env":        "SECRET=1",
+		".git/HEAD":   "ref: refs/heads/main",
+		"docs/.notes": "todo",
+		"docs/a.md":   "# a",
+	})
+
+	for _, tc := range []struct {
+		include bool
+		want    []string
+	}{
+		{true, []string{".env", ".git/HEAD", "docs/.notes", "docs/a.md", "main.go"}},
+		{false, []string{"docs/a.md", "main.go"}},
+	} {
+		files := zipptest.ReadArchive(t, zipAside(t, src, WithIncludeHidden(tc.include)))
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		if !slices.Equal(names, tc.want) {
+			t.Errorf("include %v: expected %v, got %v", tc.include, tc.want, names)
+		}
+	}
+}
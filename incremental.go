@@ -0,0 +1,154 @@
+package zipper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithBase makes Zip write an incremental archive holding only the files
+// that are new or changed since base, which is either a previous archive
+// or a manifest written by ExportManifest in ManifestJSON. A file is
+// unchanged if its size and modification time match, or failing that,
+// its SHA-256.
+//
+// WithBase enables the embedded manifest, which records the unchanged
+// files in Manifest.Inherited and the files removed since base in
+// Manifest.Deleted. Since the manifest describes the whole tree, an
+// incremental archive can itself be the base of the next one. Restoring
+// means extracting the base and then each incremental archive in order,
+// removing the deleted files after each.
+func WithBase(base string) Option {
+	return func(o *options) {
+		o.base = base
+		o.manifest = true
+	}
+}
+
+// baseDiff is what an incremental archive records about its base.
+type baseDiff struct {
+	base      string
+	inherited []ManifestEntry
+	deleted   []string
+}
+
+// diffBase returns the files below root that are new or changed since
+// the base, and what the manifest records about the rest. It returns
+// files unchanged and a nil diff when WithBase was not given.
+func diffBase(root string, files []string, o *options) ([]string, *baseDiff, error) {
+	if o.base == "" {
+		return files, nil, nil
+	}
+
+	m, err := loadBase(o.base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("base %s: %w", o.base, err)
+	}
+
+	previous := make(map[string]ManifestEntry)
+	for _, e := range append(m.Entries, m.Inherited...) {
+		if e.Name == ManifestName || strings.HasPrefix(e.Mode, "d") {
+			continue
+		}
+		previous[e.Name] = e
+	}
+
+	diff := &baseDiff{base: m.Archive}
+	changed := make([]string, 0, len(files))
+	for _, file := range files {
+		name, err := o.entryName(root, file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		e, ok := previous[name]
+		delete(previous, name)
+		if ok {
+			same, err := unchanged(file, e)
+			if err != nil {
+				return nil, nil, err
+			}
+			if same {
+				e.Groups = o.groupsFor(name)
+				diff.inherited = append(diff.inherited, e)
+				continue
+			}
+		}
+		changed = append(changed, file)
+	}
+
+	for name := range previous {
+		diff.deleted = append(diff.deleted, name)
+	}
+	sort.Strings(diff.deleted)
+
+	return changed, diff, nil
+}
+
+// unchanged reports whether file still matches its entry in the base.
+func unchanged(file string, e ManifestEntry) (bool, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	if uint64(info.Size()) != e.Size {
+		return false, nil
+	}
+
+	// archives only keep whole seconds
+	if info.ModTime().Truncate(time.Second).Equal(e.Modified.Truncate(time.Second)) {
+		return true, nil
+	}
+
+	// touched but perhaps not modified
+	if e.SHA256 == "" {
+		return false, nil
+	}
+	sum, err := hashFile(file)
+	if err != nil {
+		return false, err
+	}
+	return sum == e.SHA256, nil
+}
+
+// loadBase reads the manifest of a base archive, or a manifest exported
+// as JSON. Archives without an embedded manifest are hashed in full.
+func loadBase(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	head, err := br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	// an exported manifest is a JSON object, an archive never starts so
+	if bytes.Equal(head, []byte("{")) {
+		var m Manifest
+		if err := json.NewDecoder(br).Decode(&m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		return &m, nil
+	}
+	f.Close()
+
+	r, err := openArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readEmbeddedManifest(r.Reader)
+	r.Close()
+	if err == ErrNoManifest {
+		return buildManifest(path)
+	}
+	return m, err
+}
@@ -0,0 +1,158 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// zipAside archives src with opts and moves the archive into a temporary
+// directory, so the next archive of src does not overwrite it.
+func zipAside(t *testing.T, src string, opts ...Option) string {
+	t.Helper()
+
+	zipPath, err := Zip(src, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	moved := filepath.Join(t.TempDir(), filepath.Base(zipPath))
+	if err := os.Rename(zipPath, moved); err != nil {
+		t.Fatal(err)
+	}
+	return moved
+}
+
+// readManifest returns the entry names and embedded manifest of an
+// archive.
+func readManifest(t *testing.T, zipPath string) ([]string, *Manifest) {
+	t.Helper()
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		if f.Name != ManifestName {
+			names = append(names, f.Name)
+		}
+	}
+
+	m, err := readEmbeddedManifest(&r.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names, m
+}
+
+func entryNames(entries []ManifestEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestIncremental(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"same.txt":    "same",
+		"touched.txt": "touched",
+		"changed.txt": "before",
+		"gone.txt":    "gone",
+	})
+
+	full := zipAside(t, src, WithManifest())
+
+	if err := os.WriteFile(filepath.Join(src, "changed.txt"), []byte("after"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(src, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(src, "touched.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	incr := zipAside(t, src, WithBase(full))
+
+	names, m := readManifest(t, incr)
+	if want := []string{"changed.txt", "new.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected entries %v, got %v", want, names)
+	}
+	if want := []string{"same.txt", "touched.txt"}; !reflect.DeepEqual(entryNames(m.Inherited), want) {
+		t.Errorf("expected inherited %v, got %v", want, entryNames(m.Inherited))
+	}
+	if want := []string{"gone.txt"}; !reflect.DeepEqual(m.Deleted, want) {
+		t.Errorf("expected deleted %v, got %v", want, m.Deleted)
+	}
+	if m.Base != "src.zip" {
+		t.Errorf("expected base src.zip, got %q", m.Base)
+	}
+
+	// an incremental archive describes the whole tree, so it can be the
+	// base of the next one
+	names, m = readManifest(t, zipAside(t, src, WithBase(incr)))
+	if len(names) != 0 || len(m.Deleted) != 0 {
+		t.Errorf("expected nothing changed, got entries %v and deleted %v", names, m.Deleted)
+	}
+	if want := []string{"changed.txt", "new.txt", "same.txt", "touched.txt"}; !reflect.DeepEqual(entryNames(m.Inherited), want) {
+		t.Errorf("expected inherited %v, got %v", want, entryNames(m.Inherited))
+	}
+}
+
+func TestIncrementalBases(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta"})
+
+	// an archive without a manifest is hashed instead
+	plain := zipAside(t, src)
+
+	var buf bytes.Buffer
+	if err := ExportManifest(plain, &buf, ManifestJSON); err != nil {
+		t.Fatal(err)
+	}
+	exported := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(exported, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(src, "b.txt"), []byte("beta, changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, base := range []string{plain, exported} {
+		names, m := readManifest(t, zipAside(t, src, WithBase(base)))
+		if want := []string{"b.txt"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: expected entries %v, got %v", base, want, names)
+		}
+		if want := []string{"a.txt"}; !reflect.DeepEqual(entryNames(m.Inherited), want) {
+			t.Errorf("%s: expected inherited %v, got %v", base, want, entryNames(m.Inherited))
+		}
+	}
+}
+
+func TestIncrementalErrors(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	if _, err := Zip(src, WithBase(filepath.Join(t.TempDir(), "missing.zip"))); err == nil {
+		t.Error("expected an error for a missing base")
+	}
+	if _, err := Zip(src, WithBase(src+".zip"), WithFormat(FormatTarGz)); err == nil {
+		t.Error("expected an error for an incremental tarball")
+	}
+}
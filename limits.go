@@ -0,0 +1,160 @@
+package zipper
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Limits bounds the resources Unzip may consume, guarding against zip
+// bombs and other hostile archives. A zero field means no limit.
+type Limits struct {
+	// MaxTotalSize is the maximum number of bytes extracted in total.
+	MaxTotalSize int64
+
+	// MaxEntries is the maximum number of entries in the archive.
+	MaxEntries int
+
+	// MaxRatio is the maximum ratio of uncompressed to compressed size
+	// for any single entry.
+	MaxRatio float64
+
+	// MaxPathDepth is the maximum number of path segments in an entry
+	// name, so "a/b/c.txt" has a depth of 3.
+	MaxPathDepth int
+}
+
+// LimitError is returned by Unzip when the archive exceeds one of the
+// configured Limits. Extraction stops as soon as it is detected.
+type LimitError struct {
+	// Limit is the name of the exceeded Limits field, e.g. "MaxRatio".
+	Limit string
+
+	// Entry is the entry that pushed extraction over the limit.
+	Entry string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeded by %s", e.Limit, e.Entry)
+}
+
+// WithLimits sets resource limits applied by Unzip. It is strongly
+// recommended when extracting archives from untrusted sources.
+func WithLimits(l Limits) Option {
+	return func(o *options) {
+		o.limits = l
+	}
+}
+
+// check validates the sizes and names declared in the archive before
+// anything is written. Declared sizes can lie, so extraction also guards
+// the bytes actually produced.
+func (l Limits) check(files []*zip.File) error {
+	if l.MaxEntries > 0 && len(files) > l.MaxEntries {
+		return &LimitError{Limit: "MaxEntries", Entry: files[l.MaxEntries].Name}
+	}
+
+	var total uint64
+	for _, f := range files {
+		if l.MaxPathDepth > 0 && pathDepth(f.Name) > l.MaxPathDepth {
+			return &LimitError{Limit: "MaxPathDepth", Entry: f.Name}
+		}
+
+		total += f.UncompressedSize64
+		if l.MaxTotalSize > 0 && total > uint64(l.MaxTotalSize) {
+			return &LimitError{Limit: "MaxTotalSize", Entry: f.Name}
+		}
+
+		if l.exceedsRatio(int64(f.UncompressedSize64), int64(f.CompressedSize64)) {
+			return &LimitError{Limit: "MaxRatio", Entry: f.Name}
+		}
+	}
+
+	return nil
+}
+
+// checkDuplicates applies MaxEntries and MaxPathDepth to the duplicates
+// recorded in the manifest m, which extraction creates in addition to
+// the n entries of the archive.
+func (l Limits) checkDuplicates(m *Manifest, n int) error {
+	for _, d := range m.Entries {
+		if d.Duplicate == "" {
+			continue
+		}
+		n++
+		if err := l.checkEntry(d.Name, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkEntry validates the name of the nth entry (from 1) of an archive
+// read sequentially, for formats without a central directory to check
+// up front.
+func (l Limits) checkEntry(name string, n int) error {
+	if l.MaxEntries > 0 && n > l.MaxEntries {
+		return &LimitError{Limit: "MaxEntries", Entry: name}
+	}
+	if l.MaxPathDepth > 0 && pathDepth(name) > l.MaxPathDepth {
+		return &LimitError{Limit: "MaxPathDepth", Entry: name}
+	}
+	return nil
+}
+
+// exceedsRatio reports whether size bytes inflated from compressed bytes
+// breaks MaxRatio.
+func (l Limits) exceedsRatio(size, compressed int64) bool {
+	if l.MaxRatio <= 0 || size == 0 {
+		return false
+	}
+	if compressed <= 0 {
+		return true
+	}
+	return float64(size)/float64(compressed) > l.MaxRatio
+}
+
+// pathDepth returns the number of segments in a slash-separated name.
+func pathDepth(name string) int {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return 0
+	}
+	return strings.Count(name, "/") + 1
+}
+
+// limitReader enforces MaxTotalSize and MaxRatio on the bytes actually
+// decompressed for an entry.
+type limitReader struct {
+	r          io.Reader
+	limits     Limits
+	entry      string
+	compressed int64
+	read       int64  // bytes read from this entry
+	total      *int64 // bytes read from all entries, updated atomically
+
+	// input, if not nil, counts the compressed bytes consumed by all
+	// entries, and MaxRatio applies to the whole stream instead
+	input *int64
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	total := atomic.AddInt64(lr.total, int64(n))
+
+	if lr.limits.MaxTotalSize > 0 && total > lr.limits.MaxTotalSize {
+		return n, &LimitError{Limit: "MaxTotalSize", Entry: lr.entry}
+	}
+	if lr.input != nil {
+		if lr.limits.exceedsRatio(total, *lr.input) {
+			return n, &LimitError{Limit: "MaxRatio", Entry: lr.entry}
+		}
+	} else if lr.limits.exceedsRatio(lr.read, lr.compressed) {
+		return n, &LimitError{Limit: "MaxRatio", Entry: lr.entry}
+	}
+
+	return n, err
+}
@@ -0,0 +1,148 @@
+package zipper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnzipLimits(t *testing.T) {
+	bomb := testEntry{name: "zeros.bin", body: strings.Repeat("\x00", 1<<20)}
+
+	tests := []struct {
+		name    string
+		entries []testEntry
+		limits  Limits
+		limit   string
+	}{
+		{
+			name:    "max entries",
+			entries: []testEntry{{name: "a.txt", body: "a"}, {name: "b.txt", body: "b"}, {name: "c.txt", body: "c"}},
+			limits:  Limits{MaxEntries: 2},
+			limit:   "MaxEntries",
+		},
+		{
+			name:    "max total size",
+			entries: []testEntry{{name: "a.txt", body: strings.Repeat("a", 600)}, {name: "b.txt", body: strings.Repeat("b", 600)}},
+			limits:  Limits{MaxTotalSize: 1000},
+			limit:   "MaxTotalSize",
+		},
+		{
+			name:    "max ratio",
+			entries: []testEntry{bomb},
+			limits:  Limits{MaxRatio: 100},
+			limit:   "MaxRatio",
+		},
+		{
+			name:    "max path depth",
+			entries: []testEntry{{name: "a/b/c/d.txt", body: "d"}},
+			limits:  Limits{MaxPathDepth: 3},
+			limit:   "MaxPathDepth",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zipPath := createZip(t, tt.entries...)
+			dest := t.TempDir()
+
+			err := Unzip(zipPath, dest, WithLimits(tt.limits))
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected *LimitError, got %v", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("expected limit %s, got %s", tt.limit, limitErr.Limit)
+			}
+
+			// declared sizes are checked before anything is written
+			if got := listTree(t, dest); len(got) != 0 {
+				t.Errorf("expected nothing extracted, got %v", got)
+			}
+		})
+	}
+}
+
+func TestUnzipWithinLimits(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "a/b.txt", body: "hello"}, testEntry{name: "c.txt", body: "world"})
+	dest := t.TempDir()
+
+	limits := Limits{MaxTotalSize: 10, MaxEntries: 2, MaxRatio: 10, MaxPathDepth: 2}
+	if err := Unzip(zipPath, dest, WithLimits(limits)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "a", "b.txt")); err != nil {
+		t.Errorf("expected a/b.txt to be extracted: %v", err)
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	// declared sizes can lie, so the decompressed stream is guarded too
+	var total int64
+	lr := &limitReader{
+		r:          strings.NewReader(strings.Repeat("x", 100)),
+		limits:     Limits{MaxRatio: 10},
+		entry:      "liar.txt",
+		compressed: 5,
+		total:      &total,
+	}
+
+	buf := make([]byte, 100)
+	_, err := lr.Read(buf)
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxRatio" {
+		t.Fatalf("expected MaxRatio error, got %v", err)
+	}
+}
+
+func TestLimitsCountDuplicates(t *testing.T) {
+	// one small file, and a manifest recording many duplicates of it
+	m := Manifest{Entries: []ManifestEntry{{Name: "a.txt", Size: 1}}}
+	for i := 0; i < 20; i++ {
+		m.Entries = append(m.Entries, ManifestEntry{Name: fmt.Sprintf("d%d/x/y/z.txt", i), Size: 1, Duplicate: "a.txt"})
+	}
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath := createZip(t,
+		testEntry{name: "a.txt", body: "a"},
+		testEntry{name: ManifestName, body: string(manifest)},
+	)
+
+	for _, tt := range []struct {
+		limits Limits
+		limit  string
+	}{
+		{Limits{MaxEntries: 10}, "MaxEntries"},
+		{Limits{MaxPathDepth: 2}, "MaxPathDepth"},
+	} {
+		opt := WithLimits(tt.limits)
+		for name, extract := range map[string]func() error{
+			"unzip": func() error { return Unzip(zipPath, t.TempDir(), opt) },
+			"map": func() error {
+				_, err := ExtractToMap(zipPath, opt)
+				return err
+			},
+			"convert": func() error {
+				out, err := Convert(zipPath, FormatTarGz, opt, WithOutput(filepath.Join(t.TempDir(), "out.tar.gz")))
+				if err == nil {
+					os.Remove(out)
+				}
+				return err
+			},
+		} {
+			var le *LimitError
+			if err := extract(); !errors.As(err, &le) || le.Limit != tt.limit {
+				t.Errorf("%s: expected %s exceeded, got %v", name, tt.limit, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package zipper
+
+import (
+	"archive/zip"
+	"io/fs"
+	"time"
+)
+
+// EntryInfo describes an entry of an archive.
+type EntryInfo struct {
+	Name           string
+	Size           int64
+	CompressedSize int64
+	Mode           fs.FileMode
+	Modified       time.Time
+
+	// Method is the zip compression method, e.g. 8 for deflate.
+	Method uint16
+
+	// Encrypted is true if the entry's data is encrypted.
+	Encrypted bool
+}
+
+// List returns the entries of the archive at zipPath in archive order,
+// without extracting anything. The embedded manifest is not listed.
+//
+// Entries zipper could not extract are listed all the same; WithReport
+// records which ones and why. Other options are ignored.
+func List(zipPath string, opts ...Option) ([]EntryInfo, error) {
+	o := newOptions(opts)
+
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return listArchive(r.Reader, o), nil
+}
+
+// listArchive returns the entries of the open archive r, as List does.
+func listArchive(r *zip.Reader, o *options) []EntryInfo {
+	entries := make([]EntryInfo, 0, len(r.File))
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+
+		if o.report != nil {
+			o.report.Unsupported = append(o.report.Unsupported, unsupportedFeatures(f)...)
+		}
+
+		entries = append(entries, fileInfo(f))
+	}
+	return entries
+}
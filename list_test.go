@@ -0,0 +1,42 @@
+package zipper
+
+import (
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sizes := make(map[string]int64)
+	for _, e := range entries {
+		sizes[e.Name] = e.Size
+	}
+	if len(sizes) != 2 || sizes["a.txt"] != 5 || sizes["dir/b.txt"] != 4 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestListReport(t *testing.T) {
+	zipPath := createUnsupportedZip(t)
+
+	var report Report
+	entries, err := List(zipPath, WithReport(&report))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 4 {
+		t.Errorf("expected all 4 entries listed, got %d", len(entries))
+	}
+	if !entries[2].Encrypted || entries[1].Method != 12 {
+		t.Errorf("unexpected entry details: %+v", entries)
+	}
+	if len(report.Unsupported) != 3 {
+		t.Errorf("expected 3 unsupported features, got %v", report.Unsupported)
+	}
+}
@@ -0,0 +1,29 @@
+package zipper
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes Zip, Unzip and Extract log what they do to logger: the
+// start and end of a Zip at info level, and each entry written or
+// extracted and each file or entry left out, with the reason, at debug
+// level. Files left out by WithContinueOnError are logged as warnings.
+// Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// log logs msg at level with args as attributes, if there is a logger.
+func (o *options) log(level slog.Level, msg string, args ...any) {
+	if o.logger != nil {
+		o.logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// logSkip logs that path, a file or entry name, was left out and why.
+func (o *options) logSkip(path, reason string) {
+	o.log(slog.LevelDebug, "skipped", "path", path, "reason", reason)
+}
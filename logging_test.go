@@ -0,0 +1,49 @@
+package zipper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipLogger(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "logged")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.log": "beta"})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	zipAside(t, src, WithLogger(logger), WithExclude("*.log"))
+
+	var msgs []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record struct {
+			Msg    string `json:"msg"`
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		msg := record.Msg
+		if record.Name != "" {
+			msg += " " + record.Name
+		}
+		if record.Reason != "" {
+			msg += " " + record.Reason
+		}
+		msgs = append(msgs, msg)
+	}
+
+	want := []string{"walking", "skipped does not match patterns", "entry a.txt", "wrote archive"}
+	if len(msgs) != len(want) {
+		t.Fatalf("expected %q, got %q", want, msgs)
+	}
+	for i := range want {
+		if msgs[i] != want[i] {
+			t.Errorf("record %d: expected %q, got %q", i, want[i], msgs[i])
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package zipper
+
+// longPath returns path, which needs no special form to exceed a length
+// limit outside Windows.
+func longPath(path string) string {
+	return path
+}
+
+// shortPath returns path.
+func shortPath(path string) string {
+	return path
+}
@@ -0,0 +1,36 @@
+package zipper
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipDeepTree(t *testing.T) {
+	// a node_modules-style tree whose paths pass 260 characters
+	var deep []string
+	for i := 0; i < 12; i++ {
+		deep = append(deep, "node_modules", "package-"+strings.Repeat("x", 10))
+	}
+	name := strings.Join(deep, "/") + "/index.js"
+	if len(name) < 300 {
+		t.Fatalf("expected a name over 300 characters, got %d", len(name))
+	}
+
+	src := filepath.Join(t.TempDir(), "app")
+	writeTree(t, src, map[string]string{name: "module.exports = {}", "package.json": "{}"})
+	zipPath := zipAside(t, src)
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if string(files[name]) != "module.exports = {}" {
+		t.Errorf("expected %s in the archive, got %v", name, files)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package zipper
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// extendedPrefix marks a Windows path as extended-length, lifting the
+// 260 character limit on paths.
+const extendedPrefix = `\\?\`
+
+// longPath returns path in extended-length form, so trees deeper than
+// the path length limit, such as node_modules, can be walked and
+// extracted below it. It is returned unchanged if it cannot be made
+// absolute or is already extended.
+func longPath(path string) string {
+	if strings.HasPrefix(path, extendedPrefix) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(abs, `\\`); ok {
+		return extendedPrefix + `UNC\` + rest
+	}
+	return extendedPrefix + abs
+}
+
+// shortPath returns an extended-length path in its usual form.
+func shortPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, extendedPrefix+`UNC\`); ok {
+		return `\\` + rest
+	}
+	return strings.TrimPrefix(path, extendedPrefix)
+}
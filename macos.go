@@ -0,0 +1,32 @@
+package zipper
+
+import "strings"
+
+// WithSkipMacMetadata leaves out the metadata macOS scatters through the
+// trees it touches: .DS_Store files, AppleDouble files named "._" plus
+// the name of the file whose resource fork and extended attributes they
+// hold, and the __MACOSX directories Finder puts the AppleDouble files of
+// its archives in. Zip leaves them out of the archive, and extraction out
+// of what it writes.
+func WithSkipMacMetadata() Option {
+	return func(o *options) {
+		o.skipMacMetadata = true
+	}
+}
+
+// isMacMetadata reports whether the file or directory named elem is macOS
+// metadata.
+func isMacMetadata(elem string) bool {
+	return elem == ".DS_Store" || elem == "__MACOSX" || strings.HasPrefix(elem, "._")
+}
+
+// inMacMetadata reports whether the entry name is or lies below macOS
+// metadata.
+func inMacMetadata(name string) bool {
+	for _, elem := range strings.Split(strings.TrimSuffix(name, "/"), "/") {
+		if isMacMetadata(elem) {
+			return true
+		}
+	}
+	return false
+}
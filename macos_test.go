@@ -0,0 +1,43 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestSkipMacMetadata(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "photos")
+	writeTree(t, src, map[string]string{
+		"a.jpg":              "jpeg",
+		".DS_Store":          "finder",
+		"._a.jpg":            "appledouble",
+		"album/b.jpg":        "jpeg",
+		"album/.DS_Store":    "finder",
+		"__MACOSX/._a.jpg":   "appledouble",
+		"album/.hidden.conf": "kept",
+	})
+
+	zipPath := zipAside(t, src, WithSkipMacMetadata())
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 3 || files["a.jpg"] == nil || files["album/b.jpg"] == nil || files["album/.hidden.conf"] == nil {
+		t.Errorf("expected the metadata to be left out, got %q", files)
+	}
+
+	// as Finder makes them
+	finder := zipAside(t, src)
+	dest := t.TempDir()
+	if err := Extract(finder, dest, WithSkipMacMetadata()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, junk := range []string{".DS_Store", "._a.jpg", "album/.DS_Store", "__MACOSX"} {
+		if _, err := os.Lstat(filepath.Join(dest, junk)); err == nil {
+			t.Errorf("expected %s not to be extracted", junk)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "album", "b.jpg")); err != nil {
+		t.Errorf("expected album/b.jpg to be extracted: %v", err)
+	}
+}
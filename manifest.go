@@ -0,0 +1,308 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ManifestFormat selects the encoding written by ExportManifest.
+type ManifestFormat int
+
+const (
+	// ManifestJSON writes the manifest as a single JSON document.
+	ManifestJSON ManifestFormat = iota
+
+	// ManifestCSV writes one row per entry preceded by a header row.
+	ManifestCSV
+)
+
+// Manifest is an inventory of the entries of an archive. The manifest of
+// an incremental archive, written with WithBase, also describes the files
+// it leaves to its base.
+type Manifest struct {
+	Archive string          `json:"archive"`
+	Entries []ManifestEntry `json:"entries"`
+
+	// Base names the archive an incremental archive was made against.
+	Base string `json:"base,omitempty"`
+
+	// Inherited lists the files unchanged since the base, which are not
+	// in the archive.
+	Inherited []ManifestEntry `json:"inherited,omitempty"`
+
+	// Deleted lists the files removed since the base.
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// ManifestEntry describes a single archive entry.
+type ManifestEntry struct {
+	Name           string    `json:"name"`
+	Size           uint64    `json:"size"`
+	CompressedSize uint64    `json:"compressed_size"`
+	CRC32          uint32    `json:"crc32"`
+	SHA256         string    `json:"sha256,omitempty"`
+	Mode           string    `json:"mode"`
+	Modified       time.Time `json:"modified"`
+	Groups         []string  `json:"groups,omitempty"`
+
+	// Duplicate names the entry holding the contents of a file stored
+	// once by WithDedup, which is not in the archive itself.
+	Duplicate string `json:"duplicate,omitempty"`
+
+	// HardLink names the file a hard link recorded by WithHardLinks links
+	// to.
+	HardLink string `json:"hard_link,omitempty"`
+
+	// Sparse lists the data regions of a sparse file stored by
+	// WithSparse. The entry holds the whole file, and extraction leaves
+	// the holes between the regions unallocated.
+	Sparse []SparseRegion `json:"sparse,omitempty"`
+}
+
+// csvHeader names the columns written by ExportManifest in ManifestCSV.
+var csvHeader = []string{"name", "size", "compressed_size", "crc32", "sha256", "mode", "modified"}
+
+// ExportManifest writes a standalone manifest of every entry in the
+// archive at zipPath to out, including the SHA-256 of each file's
+// contents, so archives can be inventoried without being retained.
+func ExportManifest(zipPath string, out io.Writer, format ManifestFormat) error {
+	m, err := buildManifest(zipPath)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ManifestJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	case ManifestCSV:
+		return writeManifestCSV(out, m)
+	default:
+		return fmt.Errorf("unknown manifest format %d", format)
+	}
+}
+
+// buildManifest reads every entry of the archive at zipPath, hashing the
+// contents of each file.
+func buildManifest(zipPath string) (*Manifest, error) {
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	m := &Manifest{
+		Archive: zipPath,
+		Entries: make([]ManifestEntry, 0, len(r.File)),
+	}
+
+	for _, f := range r.File {
+		entry := ManifestEntry{
+			Name:           f.Name,
+			Size:           f.UncompressedSize64,
+			CompressedSize: f.CompressedSize64,
+			CRC32:          f.CRC32,
+			Mode:           f.Mode().String(),
+			Modified:       f.Modified.UTC(),
+		}
+
+		if !f.FileInfo().IsDir() {
+			sum, err := hashEntry(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			entry.SHA256 = sum
+		}
+
+		m.Entries = append(m.Entries, entry)
+	}
+
+	return m, nil
+}
+
+// hashEntry returns the hex encoded SHA-256 of an entry's contents.
+func hashEntry(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeManifestCSV(out io.Writer, m *Manifest) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, e := range m.Entries {
+		if err := w.Write([]string{
+			e.Name,
+			strconv.FormatUint(e.Size, 10),
+			strconv.FormatUint(e.CompressedSize, 10),
+			fmt.Sprintf("%08x", e.CRC32),
+			e.SHA256,
+			e.Mode,
+			e.Modified.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// ManifestName is the name of the manifest entry written by WithManifest.
+const ManifestName = ".zipper-manifest.json"
+
+// ErrNoManifest is returned by VerifyManifest for archives created
+// without WithManifest.
+var ErrNoManifest = errors.New("archive has no manifest")
+
+// WithManifest makes Zip store a ManifestName entry holding the SHA-256
+// of every file, which VerifyManifest can check extracted files against.
+// SHA-256 gives much stronger guarantees than the CRC-32 of each entry.
+// Unzip does not extract the manifest entry.
+func WithManifest() Option {
+	return func(o *options) {
+		o.manifest = true
+	}
+}
+
+// manifestEntry describes a written entry for an embedded manifest.
+func manifestEntry(hdr *zip.FileHeader, sum string) ManifestEntry {
+	return ManifestEntry{
+		Name:           hdr.Name,
+		Size:           hdr.UncompressedSize64,
+		CompressedSize: hdr.CompressedSize64,
+		CRC32:          hdr.CRC32,
+		SHA256:         sum,
+		Mode:           hdr.Mode().String(),
+		Modified:       hdr.Modified.UTC(),
+	}
+}
+
+// writeManifestEntry stores m as the ManifestName entry of the archive.
+func writeManifestEntry(zipw *zip.Writer, m *Manifest, o *options) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	hdr := &zip.FileHeader{
+		Name:     ManifestName,
+		Method:   zip.Deflate,
+		Modified: o.modTime(time.Now()),
+	}
+	hdr.SetMode(0644)
+
+	w, err := zipw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// readEmbeddedManifest returns the manifest stored in r, or ErrNoManifest.
+func readEmbeddedManifest(r *zip.Reader) (*Manifest, error) {
+	for _, f := range r.File {
+		if f.Name != ManifestName {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		var m Manifest
+		if err := json.NewDecoder(rc).Decode(&m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		return &m, nil
+	}
+
+	return nil, ErrNoManifest
+}
+
+// VerifyManifest checks the files extracted into dir against the SHA-256
+// hashes in the archive's embedded manifest. Files missing from dir or
+// whose contents differ are reported in a *VerifyError.
+func VerifyManifest(zipPath, dir string) error {
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	m, err := readEmbeddedManifest(r.Reader)
+	if err != nil {
+		return err
+	}
+
+	report := &VerifyError{}
+	for _, e := range m.Entries {
+		if e.SHA256 == "" {
+			continue
+		}
+
+		name, err := sanitizeName(e.Name)
+		if err != nil {
+			report.Entries = append(report.Entries, &EntryError{Name: e.Name, Err: err})
+			continue
+		}
+
+		sum, err := hashFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			report.Entries = append(report.Entries, &EntryError{Name: e.Name, Err: err})
+			continue
+		}
+
+		if sum != e.SHA256 {
+			report.Entries = append(report.Entries, &EntryError{Name: e.Name, Err: errors.New("sha256 mismatch")})
+		}
+	}
+
+	if len(report.Entries) > 0 {
+		return report
+	}
+	return nil
+}
+
+// hashFile returns the hex encoded SHA-256 of a file's contents.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,127 @@
+package zipper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestExportManifestJSON(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "a.txt", body: "alpha"},
+		testEntry{name: "dir/b.txt", body: "beta"},
+	)
+
+	var buf bytes.Buffer
+	if err := ExportManifest(zipPath, &buf, ManifestJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid JSON manifest: %v", err)
+	}
+
+	if len(m.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(m.Entries))
+	}
+
+	sum := sha256.Sum256([]byte("alpha"))
+	e := m.Entries[0]
+	if e.Name != "a.txt" || e.Size != 5 || e.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.CompressedSize == 0 || e.CRC32 == 0 || e.Mode == "" {
+		t.Errorf("expected sizes, checksum and mode to be recorded: %+v", e)
+	}
+}
+
+func TestExportManifestCSV(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "a.txt", body: "alpha"},
+		testEntry{name: "dir/b.txt", body: "beta"},
+	)
+
+	var buf bytes.Buffer
+	if err := ExportManifest(zipPath, &buf, ManifestCSV); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV manifest: %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d rows", len(rows))
+	}
+	if rows[0][0] != "name" || rows[2][0] != "dir/b.txt" || rows[2][1] != "4" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestExportManifestUnknownFormat(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "a.txt", body: "alpha"})
+
+	if err := ExportManifest(zipPath, &bytes.Buffer{}, ManifestFormat(42)); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestEmbeddedManifest(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	zipPath, err := Zip(src, WithManifest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the manifest describes the archive but is not extracted
+	if _, err := os.Stat(filepath.Join(dest, ManifestName)); !os.IsNotExist(err) {
+		t.Error("manifest entry should not be extracted")
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// tamper with one file and remove another
+	if err := os.WriteFile(filepath.Join(dest, "a.txt"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dest, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	var verr *VerifyError
+	if err := VerifyManifest(zipPath, dest); !errors.As(err, &verr) {
+		t.Fatalf("expected *VerifyError, got %v", err)
+	}
+	if len(verr.Entries) != 2 {
+		t.Errorf("expected 2 failures, got %v", verr.Entries)
+	}
+}
+
+func TestVerifyManifestWithoutManifest(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "a.txt", body: "alpha"})
+
+	if err := VerifyManifest(zipPath, t.TempDir()); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+}
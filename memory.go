@@ -0,0 +1,69 @@
+package zipper
+
+import (
+	"bytes"
+	"io"
+)
+
+// ExtractToMap reads the archive at src, detecting its format as Extract
+// does, and returns the contents of its files by entry name, cleaned and
+// slash-separated, without writing anything to disk. It suits tests and
+// servers handling small uploaded archives. Directories and symlinks are
+// left out, and of entries sharing a name the last wins. Files stored once
+// by WithDedup or WithHardLinks are returned under each of their names.
+//
+// Everything is held in memory, so archives from untrusted sources should
+// be bounded with WithLimits, whose limits apply as they do to Unzip,
+// except that MaxRatio only applies to zip entries. WithInclude,
+// WithExclude, WithPassword and WithReport apply as well.
+func ExtractToMap(src string, opts ...Option) (map[string][]byte, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var total int64
+	n := 0
+	err := readEntries(src, o, func(f entry, open func() (io.ReadCloser, error)) error {
+		n++
+		if err := o.limits.checkEntry(f.name, n); err != nil {
+			return err
+		}
+
+		name, err := sanitizeName(f.name)
+		if err != nil {
+			return &PathError{Op: "extract", Entry: f.name, Err: err}
+		}
+		if !f.mode.IsRegular() || !o.selected(name) {
+			return nil
+		}
+		name = o.normalize(name)
+
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		// only zip entries record their compressed size
+		limits := o.limits
+		if f.compressed < 0 {
+			limits.MaxRatio = 0
+		}
+
+		var buf bytes.Buffer
+		lr := &limitReader{r: rc, limits: limits, entry: f.name, compressed: f.compressed, total: &total}
+		if _, err := buf.ReadFrom(lr); err != nil {
+			return err
+		}
+
+		files[name] = buf.Bytes()
+		o.entryDone(f.info())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
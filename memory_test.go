@@ -0,0 +1,56 @@
+package zipper
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractToMap(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "upload")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta", "dir/c.log": "gamma"})
+
+	for _, format := range []Format{FormatZip, FormatTarGz} {
+		archive := zipAside(t, src, WithFormat(format))
+
+		files, err := ExtractToMap(archive, WithExclude("*.log"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if len(files) != 2 || string(files["a.txt"]) != "alpha" || string(files["dir/b.txt"]) != "beta" {
+			t.Errorf("%s: unexpected contents: %q", format, files)
+		}
+	}
+}
+
+func TestExtractToMapLimits(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "bomb")
+	writeTree(t, src, map[string]string{"a.txt": strings.Repeat("a", 10000), "b.txt": "beta"})
+	zipPath := zipAside(t, src)
+
+	_, err := ExtractToMap(zipPath, WithLimits(Limits{MaxTotalSize: 5000}))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxTotalSize" {
+		t.Errorf("expected MaxTotalSize exceeded, got %v", err)
+	}
+
+	_, err = ExtractToMap(zipPath, WithLimits(Limits{MaxRatio: 10}))
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxRatio" {
+		t.Errorf("expected MaxRatio exceeded, got %v", err)
+	}
+}
+
+func TestExtractToMapDedup(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "upload")
+	writeTree(t, src, map[string]string{"a.txt": "shared", "dir/b.txt": "shared", "c.txt": "gamma"})
+	zipPath := zipAside(t, src, WithDedup())
+
+	files, err := ExtractToMap(zipPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 3 || string(files["a.txt"]) != "shared" || string(files["dir/b.txt"]) != "shared" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
@@ -0,0 +1,155 @@
+package zipper
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// WithCollision sets what Merge does with entries of the same name in
+// more than one archive. The default is CollisionError.
+func WithCollision(policy Collision) Option {
+	return func(o *options) {
+		o.collision = policy
+	}
+}
+
+// Merge combines the zip archives at srcs into one at out, copying their
+// entries as Repack does, without decompressing them, in the order given.
+// Entries of the same name in several archives are handled as
+// WithCollision says: CollisionError fails the merge, CollisionKeepFirst
+// and CollisionKeepLast keep one and record the others in the report
+// given to WithReport, and CollisionSuffix numbers the names after the
+// first. Directory entries are merged rather than clashing. WithInclude
+// and WithExclude select the entries merged.
+//
+// Archives with an embedded manifest are refused, as files stored once by
+// WithDedup or WithHardLinks are recorded only there, and their
+// manifests cannot be combined once names clash.
+func Merge(out string, srcs []string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := validateRepack(o); err != nil {
+		return err
+	}
+	if err := checkOutput(out, o); err != nil {
+		return err
+	}
+
+	archives := make([]*archive, 0, len(srcs))
+	defer func() {
+		for _, a := range archives {
+			a.Close()
+		}
+	}()
+	for _, src := range srcs {
+		a, err := openArchive(src)
+		if err != nil {
+			return err
+		}
+		archives = append(archives, a)
+		if hasManifest(a.Reader) {
+			return fmt.Errorf("%s: archives with a manifest cannot be merged", src)
+		}
+	}
+
+	entries, err := mergeEntries(srcs, archives, o)
+	if err != nil {
+		return err
+	}
+
+	return writeRepacked(out, o, func(zipw *zip.Writer) error {
+		for _, e := range entries {
+			if err := copyEntry(zipw, e.f, e.name, o); err != nil {
+				return &EntryError{Name: e.f.Name, Err: fmt.Errorf("%s: %w", e.src, err)}
+			}
+		}
+		return nil
+	})
+}
+
+// hasManifest reports whether r has an embedded manifest.
+func hasManifest(r *zip.Reader) bool {
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			return true
+		}
+	}
+	return false
+}
+
+// mergedEntry is an entry of one of the archives Merge combines.
+type mergedEntry struct {
+	f    *zip.File
+	src  string
+	name string // the name it is merged under
+}
+
+// mergeEntries returns the entries of archives, read from srcs, to merge,
+// in order, with clashing names resolved by o.collision.
+func mergeEntries(srcs []string, archives []*archive, o *options) ([]mergedEntry, error) {
+	var all []mergedEntry
+	taken := make(map[string]bool)
+	for i, a := range archives {
+		for _, f := range a.File {
+			if !o.selectedEntry(f.Name) {
+				continue
+			}
+			all = append(all, mergedEntry{f: f, src: srcs[i], name: f.Name})
+			taken[f.Name] = true
+		}
+	}
+
+	// the last of each name wins if kept in place of the earlier ones
+	last := make(map[string]int, len(all))
+	for i, e := range all {
+		last[e.name] = i
+	}
+
+	first := make(map[string]mergedEntry, len(all))
+	merged := make([]mergedEntry, 0, len(all))
+	for i, e := range all {
+		earlier, ok := first[e.name]
+		if !ok {
+			first[e.name] = e
+		}
+		if strings.HasSuffix(e.name, "/") {
+			if !ok {
+				merged = append(merged, e)
+			}
+			continue
+		}
+
+		switch {
+		case o.collision == CollisionKeepLast:
+			if last[e.name] != i {
+				o.skipMerged(e, all[last[e.name]].src)
+				continue
+			}
+		case !ok:
+		case o.collision == CollisionSuffix:
+			e.name = suffixedName(e.name, taken)
+		case o.collision == CollisionKeepFirst:
+			o.skipMerged(e, earlier.src)
+			continue
+		default:
+			return nil, &PathError{Op: "merge", Entry: e.name, Err: fmt.Errorf("in both %s and %s: %w", earlier.src, e.src, fs.ErrExist)}
+		}
+		merged = append(merged, e)
+	}
+	return merged, nil
+}
+
+// skipMerged records in the report that e was left out for clashing with
+// the entry of the same name in src.
+func (o *options) skipMerged(e mergedEntry, src string) {
+	reason := "also in " + src
+	o.logSkip(e.src+": "+e.name, reason)
+	if o.report != nil {
+		o.report.Skipped = append(o.report.Skipped, Skipped{
+			Entry:  e.name,
+			Size:   int64(e.f.UncompressedSize64),
+			Reason: reason,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestMerge(t *testing.T) {
+	first := filepath.Join(t.TempDir(), "first")
+	writeTree(t, first, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+	second := filepath.Join(t.TempDir(), "second")
+	writeTree(t, second, map[string]string{"dir/b.txt": "beta 2", "c.txt": "gamma"})
+	srcs := []string{zipAside(t, first), zipAside(t, second)}
+
+	tests := []struct {
+		policy  Collision
+		want    map[string]string
+		skipped int
+	}{
+		{CollisionKeepFirst, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta", "c.txt": "gamma"}, 1},
+		{CollisionKeepLast, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta 2", "c.txt": "gamma"}, 1},
+		{CollisionSuffix, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta", "dir/b-1.txt": "beta 2", "c.txt": "gamma"}, 0},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(t.TempDir(), "merged.zip")
+		var report Report
+		if err := Merge(out, srcs, WithCollision(tt.policy), WithReport(&report)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.policy, err)
+		}
+
+		files := zipptest.ReadArchive(t, out)
+		if len(files) != len(tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.policy, tt.want, files)
+		}
+		for name, data := range tt.want {
+			if string(files[name]) != data {
+				t.Errorf("%s: %s: expected %q, got %q", tt.policy, name, data, files[name])
+			}
+		}
+		if len(report.Skipped) != tt.skipped {
+			t.Errorf("%s: expected %d skipped, got %v", tt.policy, tt.skipped, report.Skipped)
+		}
+	}
+
+	err := Merge(filepath.Join(t.TempDir(), "merged.zip"), srcs)
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) || pathErr.Entry != "dir/b.txt" || !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected a clash on dir/b.txt, got %v", err)
+	}
+}
+
+func TestMergeManifest(t *testing.T) {
+	first := filepath.Join(t.TempDir(), "first")
+	writeTree(t, first, map[string]string{"a.txt": "shared", "b.txt": "shared"})
+	second := filepath.Join(t.TempDir(), "second")
+	writeTree(t, second, map[string]string{"c.txt": "gamma"})
+	srcs := []string{zipAside(t, first, WithDedup()), zipAside(t, second)}
+
+	out := filepath.Join(t.TempDir(), "merged.zip")
+	if err := Merge(out, srcs); err == nil {
+		t.Error("expected an archive with a manifest to be refused")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("expected no output written")
+	}
+}
@@ -0,0 +1,121 @@
+package zipper
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Method selects how Zip compresses entries.
+type Method int
+
+const (
+	// MethodDeflate compresses every entry with deflate. It is the
+	// default.
+	MethodDeflate Method = iota
+
+	// MethodStore stores every entry uncompressed.
+	MethodStore
+
+	// MethodAuto stores entries that are not worth compressing, such as
+	// already compressed media and archives, and deflates the rest. With
+	// WithStatsCache the decision also uses ratios observed for each
+	// extension on earlier runs.
+	MethodAuto
+
+	// MethodZstd compresses every entry with zstd, which is faster than
+	// deflate for a similar ratio but needs a reader supporting zip method
+	// 93, such as 7-Zip or zipper itself.
+	MethodZstd
+)
+
+// zipZstd is the zip method number of zstd.
+const zipZstd uint16 = 93
+
+// WithMethod sets how Zip compresses entries. The default is
+// MethodDeflate.
+func WithMethod(m Method) Option {
+	return func(o *options) {
+		o.method = m
+	}
+}
+
+// WithLevel sets the compression level, from 1 for the fastest to 9 for
+// the smallest output. It applies to deflate and zstd entries, and to
+// the gzip and zstd compression of tarballs. The default, 0, is each
+// compressor's own default.
+func WithLevel(level int) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// compressor returns a writer compressing onto w with the zip method and
+// level, 0 for the default.
+func compressor(w io.Writer, method uint16, level int) (io.WriteCloser, error) {
+	switch method {
+	case zip.Store:
+		return nopWriteCloser{w}, nil
+	case zip.Deflate:
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		return flate.NewWriter(w, level)
+	case zipZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(level)), zstd.WithEncoderConcurrency(1))
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+}
+
+// zstdLevel maps a level from 1 to 9 onto the zstd encoder levels.
+func zstdLevel(level int) zstd.EncoderLevel {
+	if level == 0 {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+// decompressZstd is the zip decompressor for zstd entries.
+func decompressZstd(r io.Reader) io.ReadCloser {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return io.NopCloser(errReader{err})
+	}
+	return zr.IOReadCloser()
+}
+
+// compressedExts are extensions of formats that are already compressed.
+var compressedExts = map[string]bool{
+	".7z": true, ".apk": true, ".avi": true, ".bz2": true, ".docx": true,
+	".flac": true, ".gif": true, ".gz": true, ".heic": true, ".jar": true,
+	".jpeg": true, ".jpg": true, ".mkv": true, ".mov": true, ".mp3": true,
+	".mp4": true, ".ogg": true, ".png": true, ".pptx": true, ".rar": true,
+	".tgz": true, ".webm": true, ".webp": true, ".woff2": true, ".xlsx": true,
+	".xz": true, ".zip": true, ".zst": true,
+}
+
+// extension returns the lower cased extension of an entry name.
+func extension(name string) string {
+	return strings.ToLower(path.Ext(name))
+}
+
+// methodFor picks the zip method for the entry called name.
+func (o *options) methodFor(name string) uint16 {
+	switch o.method {
+	case MethodStore:
+		return zip.Store
+	case MethodZstd:
+		return zipZstd
+	case MethodAuto:
+		ext := extension(name)
+		if compressedExts[ext] || o.stats.incompressible(ext) {
+			return zip.Store
+		}
+	}
+	return zip.Deflate
+}
@@ -0,0 +1,178 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// entryMethods returns the method of every entry in the archive.
+func entryMethods(t *testing.T, zipPath string) map[string]uint16 {
+	t.Helper()
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	methods := make(map[string]uint16)
+	for _, f := range r.File {
+		methods[f.Name] = f.Method
+	}
+	return methods
+}
+
+// randomString returns n bytes of incompressible data.
+func randomString(t *testing.T, n int) string {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestZipMethod(t *testing.T) {
+	text := strings.Repeat("compressible text ", 100)
+
+	tests := []struct {
+		name   string
+		method Method
+		want   map[string]uint16
+	}{
+		{
+			name:   "deflate",
+			method: MethodDeflate,
+			want:   map[string]uint16{"notes.txt": zip.Deflate, "photo.jpg": zip.Deflate, "noise.bin": zip.Deflate},
+		},
+		{
+			name:   "store",
+			method: MethodStore,
+			want:   map[string]uint16{"notes.txt": zip.Store, "photo.jpg": zip.Store, "noise.bin": zip.Store},
+		},
+		{
+			name:   "auto",
+			method: MethodAuto,
+			want:   map[string]uint16{"notes.txt": zip.Deflate, "photo.jpg": zip.Store, "noise.bin": zip.Store},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "src")
+			writeTree(t, src, map[string]string{
+				"notes.txt": text,
+				"photo.jpg": text,
+				"noise.bin": randomString(t, 4096),
+			})
+
+			zipPath, err := Zip(src, WithMethod(tt.method))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(zipPath)
+
+			zipptest.AssertRoundTrip(t, zipPath, src)
+
+			got := entryMethods(t, zipPath)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s: expected method %d, got %d", name, want, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestZipMethodAutoLearns(t *testing.T) {
+	cache, err := LoadStatsCache(filepath.Join(t.TempDir(), "ratios.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(t.TempDir(), "src")
+	files := make(map[string]string)
+	for _, name := range []string{"a.dat", "b.dat", "c.dat"} {
+		files[name] = randomString(t, 2048)
+	}
+	writeTree(t, src, files)
+
+	zipPath, err := Zip(src, WithMethod(MethodAuto), WithStatsCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	stats := cache.Stats()
+	if len(stats) != 1 || stats[0].Extension != ".dat" || stats[0].Samples != 3 {
+		t.Fatalf("expected 3 samples for .dat, got %+v", stats)
+	}
+
+	// .dat is now known not to compress, so it is stored without trying
+	if !cache.incompressible(".dat") {
+		t.Error("expected .dat to be learned as incompressible")
+	}
+}
+
+func TestZipMethodZstd(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"notes.txt": strings.Repeat("compressible text ", 100),
+		"empty.txt": "",
+	})
+
+	zipPath, err := Zip(src, WithMethod(MethodZstd), WithLevel(9))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	if got := entryMethods(t, zipPath)["notes.txt"]; got != zipZstd {
+		t.Errorf("expected method %d, got %d", zipZstd, got)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
+
+func TestZipLevel(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	var b strings.Builder
+	for i := range 20000 {
+		b.WriteString(strconv.Itoa(i * i % 7919))
+	}
+	writeTree(t, src, map[string]string{"numbers.txt": b.String()})
+
+	sizes := make(map[int]int64)
+	for _, level := range []int{1, 9} {
+		zipPath, err := Zip(src, WithLevel(level), WithOutput(filepath.Join(t.TempDir(), "out.zip")))
+		if err != nil {
+			t.Fatalf("level %d: unexpected error: %v", level, err)
+		}
+		zipptest.AssertRoundTrip(t, zipPath, src)
+
+		info, err := os.Stat(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[level] = info.Size()
+	}
+	if sizes[9] >= sizes[1] {
+		t.Errorf("expected level 9 to be smaller than level 1, got %d and %d bytes", sizes[9], sizes[1])
+	}
+
+	if _, err := Zip(src, WithLevel(10)); err == nil {
+		t.Error("expected an error for level 10")
+	}
+}
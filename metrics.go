@@ -0,0 +1,152 @@
+package zipper
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives counters from Zip as it runs, for exporting to a
+// metrics system such as Prometheus or OpenTelemetry. BytesRead is called
+// from several goroutines at once.
+type Metrics interface {
+	// FileAdded is called for each entry written, whose Method counts
+	// entries by compression method.
+	FileAdded(info EntryInfo)
+
+	// BytesRead is called with the number of bytes of file contents read
+	// as they are read.
+	BytesRead(n int64)
+
+	// BytesWritten is called with the number of bytes of the archive
+	// written as they are written.
+	BytesWritten(n int64)
+
+	// Finished is called once the archive is complete, with how long Zip
+	// took.
+	Finished(d time.Duration)
+}
+
+// Summary describes an archive Zip wrote.
+type Summary struct {
+	Files        int
+	BytesRead    int64 // file contents read
+	BytesWritten int64 // archive bytes written
+	Duration     time.Duration
+
+	// Methods counts the zip entries by compression method, such as
+	// zip.Deflate.
+	Methods map[uint16]int
+}
+
+// WithMetrics makes Zip report to m as it runs.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithSummary makes Zip fill in s once it has written an archive.
+func WithSummary(s *Summary) Option {
+	return func(o *options) {
+		o.summary = s
+	}
+}
+
+// meter counts what Zip does for WithMetrics and WithSummary.
+type meter struct {
+	m             Metrics
+	read, written atomic.Int64
+	files         int
+	methods       map[uint16]int
+}
+
+// newMeter returns a meter if the options ask for one, or nil.
+func (o *options) newMeter() *meter {
+	if o.metrics == nil && o.summary == nil {
+		return nil
+	}
+	return &meter{m: o.metrics, methods: make(map[uint16]int)}
+}
+
+// fileAdded counts an entry written to the archive.
+func (o *options) fileAdded(info EntryInfo, zipped bool) {
+	if o.meter == nil {
+		return
+	}
+	o.meter.files++
+	if zipped {
+		o.meter.methods[info.Method]++
+	}
+	if o.meter.m != nil {
+		o.meter.m.FileAdded(info)
+	}
+}
+
+// finish reports that the archive Zip began writing at start is complete.
+func (o *options) finish(start time.Time) {
+	if o.meter == nil {
+		return
+	}
+	d := time.Since(start)
+	if o.meter.m != nil {
+		o.meter.m.Finished(d)
+	}
+	if o.summary != nil {
+		*o.summary = Summary{
+			Files:        o.meter.files,
+			BytesRead:    o.meter.read.Load(),
+			BytesWritten: o.meter.written.Load(),
+			Duration:     d,
+			Methods:      o.meter.methods,
+		}
+	}
+}
+
+// meterReader counts the bytes read from r, a file being archived.
+func (o *options) meterReader(r io.Reader) io.Reader {
+	if o.meter == nil {
+		return r
+	}
+	return &meteredReader{r: r, m: o.meter}
+}
+
+// meterWriter counts the bytes written to w, the archive.
+func (o *options) meterWriter(w io.Writer) io.Writer {
+	if o.meter == nil {
+		return w
+	}
+	return &meteredWriter{w: w, m: o.meter}
+}
+
+type meteredReader struct {
+	r io.Reader
+	m *meter
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.m.read.Add(int64(n))
+		if r.m.m != nil {
+			r.m.m.BytesRead(int64(n))
+		}
+	}
+	return n, err
+}
+
+type meteredWriter struct {
+	w io.Writer
+	m *meter
+}
+
+func (w *meteredWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.m.written.Add(int64(n))
+		if w.m.m != nil {
+			w.m.m.BytesWritten(int64(n))
+		}
+	}
+	return n, err
+}
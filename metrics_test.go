@@ -0,0 +1,91 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingMetrics totals what Zip reports through Metrics.
+type countingMetrics struct {
+	mu       sync.Mutex
+	files    int
+	read     int64
+	written  int64
+	finished int
+}
+
+func (m *countingMetrics) FileAdded(EntryInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files++
+}
+
+func (m *countingMetrics) BytesRead(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.read += n
+}
+
+func (m *countingMetrics) BytesWritten(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written += n
+}
+
+func (m *countingMetrics) Finished(time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished++
+}
+
+func TestZipMetrics(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "metered")
+	text := strings.Repeat("compressible ", 100)
+	writeTree(t, src, map[string]string{"a.txt": text, "b.jpg": "jpeg"})
+
+	var m countingMetrics
+	var s Summary
+	zipPath := zipAside(t, src, WithMethod(MethodAuto), WithMetrics(&m), WithSummary(&s))
+
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRead := int64(len(text) + len("jpeg"))
+	if s.Files != 2 || s.BytesRead != wantRead || s.BytesWritten != info.Size() || s.Duration <= 0 {
+		t.Errorf("unexpected summary %+v for %d bytes read and %d written", s, wantRead, info.Size())
+	}
+	if s.Methods[zip.Deflate] != 1 || s.Methods[zip.Store] != 1 {
+		t.Errorf("expected one entry of each method, got %v", s.Methods)
+	}
+	if m.files != s.Files || m.read != s.BytesRead || m.written != s.BytesWritten || m.finished != 1 {
+		t.Errorf("metrics %+v disagree with summary %+v", &m, s)
+	}
+}
+
+func TestZipMetricsStoredAfterDeflate(t *testing.T) {
+	// deflate does not shrink random data, which is then stored
+	data := make([]byte, 100000)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "random")
+	writeTree(t, src, map[string]string{"noise.txt": string(data)})
+
+	var m countingMetrics
+	var s Summary
+	zipAside(t, src, WithMethod(MethodAuto), WithMetrics(&m), WithSummary(&s))
+
+	if s.Methods[zip.Store] != 1 {
+		t.Errorf("expected the file stored, got %v", s.Methods)
+	}
+	if s.BytesRead != int64(len(data)) || m.read != s.BytesRead {
+		t.Errorf("expected %d bytes read, got %d in the summary and %d in metrics", len(data), s.BytesRead, m.read)
+	}
+}
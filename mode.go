@@ -0,0 +1,115 @@
+package zipper
+
+import (
+	"archive/zip"
+	"io/fs"
+	"strings"
+)
+
+// Creator systems of zip entries whose external attributes hold Unix
+// permissions.
+const (
+	creatorUnix   = 3
+	creatorMacOSX = 19
+)
+
+// defaultFileMode is the permissions of extracted files whose entries
+// record none, unless WithDefaultMode says otherwise.
+const defaultFileMode fs.FileMode = 0644
+
+// specialBits are the mode bits extraction clears unless
+// WithAllowSpecialBits is given.
+const specialBits = fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// WithModeMask makes extraction clear the permission bits in mask from
+// every file and directory it creates, as a umask does, so 0022 strips
+// group and other write. Files are given exactly the permissions that
+// result, whatever the process umask.
+func WithModeMask(mask fs.FileMode) Option {
+	return func(o *options) {
+		o.modeMask = mask
+	}
+}
+
+// WithDefaultMode sets the permissions extraction gives files whose
+// entries record none, such as zip entries made on Windows and those read
+// by UnzipReader, instead of 0644. Files are given exactly these
+// permissions, whatever the process umask.
+func WithDefaultMode(perm fs.FileMode) Option {
+	return func(o *options) {
+		o.defaultMode = perm
+	}
+}
+
+// WithIgnoreModes makes extraction give every file the default
+// permissions, those of WithDefaultMode or 0644, instead of the ones its
+// entry records.
+func WithIgnoreModes() Option {
+	return func(o *options) {
+		o.ignoreModes = true
+	}
+}
+
+// WithAllowSpecialBits makes extraction keep the setuid, setgid and sticky
+// bits that entries record for files. They are cleared by default, lest
+// an archive plant a setuid program, and each file they are cleared from
+// is recorded in the Report given by WithReport as an Unsupported of
+// FeatureSpecialBits, not skipped, whose Detail names the bits.
+func WithAllowSpecialBits() Option {
+	return func(o *options) {
+		o.keepSpecialBits = true
+	}
+}
+
+// recordsMode reports whether the zip entry f records Unix permissions.
+func recordsMode(f *zip.File) bool {
+	creator := f.CreatorVersion >> 8
+	return creator == creatorUnix || creator == creatorMacOSX
+}
+
+// filePerm returns the permissions to give the file extracted for f, and
+// whether to set them exactly rather than through the process umask.
+func (o *options) filePerm(f entry) (fs.FileMode, bool) {
+	perm := f.mode.Perm()
+	if f.noMode || o.ignoreModes {
+		perm = defaultFileMode
+		if o.defaultMode != 0 {
+			perm = o.defaultMode
+		}
+	}
+	perm &^= o.modeMask
+	exact := o.modeMask != 0 || o.defaultMode != 0 || o.ignoreModes
+
+	// kept bits are set exactly, after any change of owner clears them
+	if special := f.mode & specialBits; special != 0 && o.keepSpecialBits && !f.noMode && !o.ignoreModes {
+		perm |= special
+		exact = true
+	}
+	return perm, exact
+}
+
+// stripSpecialBits records that the special bits of the file f are to be
+// cleared, unless allowed.
+func (o *options) stripSpecialBits(f entry) error {
+	special := f.mode & specialBits
+	if special == 0 || o.keepSpecialBits || f.noMode || o.ignoreModes {
+		return nil
+	}
+
+	var names []string
+	for _, b := range []struct {
+		bit  fs.FileMode
+		name string
+	}{{fs.ModeSetuid, "setuid"}, {fs.ModeSetgid, "setgid"}, {fs.ModeSticky, "sticky"}} {
+		if special&b.bit != 0 {
+			names = append(names, b.name)
+		}
+	}
+	_, err := o.recordUnsupported([]Unsupported{{Entry: f.name, Feature: FeatureSpecialBits, Detail: strings.Join(names, ", ")}})
+	return err
+}
+
+// dirPerm returns the permissions to create directories with.
+func (o *options) dirPerm() fs.FileMode {
+	return 0755 &^ o.modeMask
+}
@@ -0,0 +1,101 @@
+package zipper
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUnzipModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows files have no Unix permissions")
+	}
+
+	zipPath := createZip(t,
+		testEntry{name: "bin/run.sh", body: "#!/bin/sh", mode: 0777},
+		testEntry{name: "notes.txt", body: "made on Windows"},
+	)
+
+	for _, test := range []struct {
+		name string
+		opts []Option
+		run  fs.FileMode
+		doc  fs.FileMode
+	}{
+		{"mask", []Option{WithModeMask(0027)}, 0750, 0640},
+		{"default", []Option{WithDefaultMode(0600)}, 0777, 0600},
+		{"ignored", []Option{WithIgnoreModes(), WithDefaultMode(0664), WithModeMask(0002)}, 0664, 0664},
+	} {
+		dest := t.TempDir()
+		if err := Unzip(zipPath, dest, test.opts...); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		for path, want := range map[string]fs.FileMode{"bin/run.sh": test.run, "notes.txt": test.doc} {
+			info, err := os.Stat(filepath.Join(dest, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("%s: expected %s to have mode %v, got %v", test.name, path, want, info.Mode().Perm())
+			}
+		}
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithModeMask(0077)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Errorf("expected the directory masked, got %v", info.Mode())
+	}
+
+	if err := Unzip(zipPath, t.TempDir(), WithModeMask(fs.ModeSetuid)); err == nil {
+		t.Error("expected a mask of more than permission bits to be refused")
+	}
+}
+
+func TestUnzipSpecialBits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows files have no Unix permissions")
+	}
+
+	zipPath := createZip(t,
+		testEntry{name: "bin/su", body: "#!/bin/sh", mode: fs.ModeSetuid | 0755},
+		testEntry{name: "plain", body: "plain", mode: 0644},
+	)
+
+	var report Report
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithReport(&report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "bin/su"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&specialBits != 0 || info.Mode().Perm() != 0755 {
+		t.Errorf("expected the setuid bit cleared, got %v", info.Mode())
+	}
+	want := Unsupported{Entry: "bin/su", Feature: FeatureSpecialBits, Detail: "setuid"}
+	if len(report.Unsupported) != 1 || report.Unsupported[0] != want {
+		t.Errorf("expected %v reported, got %v", want, report.Unsupported)
+	}
+
+	dest = t.TempDir()
+	if err := Unzip(zipPath, dest, WithAllowSpecialBits()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err = os.Stat(filepath.Join(dest, "bin/su")); err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&specialBits != fs.ModeSetuid || info.Mode().Perm() != 0755 {
+		t.Errorf("expected the setuid bit kept, got %v", info.Mode())
+	}
+}
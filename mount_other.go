@@ -0,0 +1,11 @@
+//go:build !unix
+
+package zipper
+
+import "io/fs"
+
+// deviceID reports no device where it is not available, so mount points
+// are not detected.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package zipper
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// deviceID returns the device holding the file described by info.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
@@ -0,0 +1,237 @@
+package zipper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// WithRename makes Zip name each entry by calling rename with the name it
+// would otherwise have, the file's slash-separated path relative to
+// inPath, for example to lowercase names or strip a prefix. The name
+// returned must be relative and stay below the archive's root, and is
+// cleaned; joining inPath and the name passed gives the file on disk.
+func WithRename(rename func(srcPath string) string) Option {
+	return func(o *options) {
+		o.rename = rename
+	}
+}
+
+// WithRootDir places every entry under the directory dir, such as
+// "myapp-1.2.3", whatever inPath is called, as release tarballs usually
+// do. It applies after WithRename, and dir may have several levels.
+func WithRootDir(dir string) Option {
+	return func(o *options) {
+		o.rootDir = dir
+	}
+}
+
+// Collision selects what WithFlatten does with files whose names clash
+// once their directories are dropped, what WithDuplicates does with
+// entries of the same name otherwise, and what Merge does with entries of
+// the same name in several archives.
+type Collision int
+
+const (
+	// CollisionError fails the archive. It is the default.
+	CollisionError Collision = iota
+
+	// CollisionSuffix numbers the names after the first, so "a.txt" is
+	// followed by "a-1.txt", "a-2.txt" and so on.
+	CollisionSuffix
+
+	// CollisionKeepFirst archives the first file of each name and leaves
+	// out the others, recording them in the report given to WithReport.
+	CollisionKeepFirst
+
+	// CollisionKeepLast archives the last file of each name and leaves
+	// out the others, as CollisionKeepFirst does.
+	CollisionKeepLast
+)
+
+func (c Collision) String() string {
+	switch c {
+	case CollisionError:
+		return "error"
+	case CollisionSuffix:
+		return "suffix"
+	case CollisionKeepFirst:
+		return "keep-first"
+	case CollisionKeepLast:
+		return "keep-last"
+	default:
+		return "unknown"
+	}
+}
+
+// WithDuplicates sets what is done with entries of the same name, which
+// Zip gives files renamed or normalized alike, Writer is given by
+// callers, and malicious or careless archives hold. Zip and Writer
+// resolve them as they name files, and extraction as it writes them, the
+// later entry replacing the earlier with CollisionKeepLast, which a
+// Writer, having written the earlier, refuses. The default is
+// CollisionError. Directory entries may repeat.
+func WithDuplicates(policy Collision) Option {
+	return func(o *options) {
+		o.duplicates = policy
+	}
+}
+
+// WithFlatten makes Zip drop the directory structure and store every file
+// at the archive's root, or under WithRootDir, by its base name after
+// WithRename. Files whose names clash are handled by policy, in the order
+// entries are written.
+func WithFlatten(policy Collision) Option {
+	return func(o *options) {
+		o.flatten = true
+		o.collision = policy
+	}
+}
+
+// entryName returns the archive name for file below root, as rewritten by
+// WithRename, flattened and placed under WithRootDir.
+func (o *options) entryName(root, file string) (string, error) {
+	name, ok := o.flattened[file]
+	if !ok {
+		var err error
+		if name, err = o.sourceName(root, file); err != nil {
+			return "", err
+		}
+		if o.flatten {
+			name = path.Base(name)
+		}
+	}
+
+	if o.rootDir != "" {
+		name = path.Join(o.rootDir, name)
+	}
+	return name, nil
+}
+
+// sourceName returns the name of file below root as rewritten by
+// WithRename, then normalized.
+func (o *options) sourceName(root, file string) (string, error) {
+	name, err := entryName(root, file)
+	if err != nil || o.rename == nil {
+		return o.normalize(name), err
+	}
+
+	renamed := o.rename(name)
+	clean, err := sanitizeName(renamed)
+	if err != nil {
+		return "", fmt.Errorf("renaming %s to %s: %w", name, renamed, err)
+	}
+	return o.normalize(clean), nil
+}
+
+// resolveNames names the files below root, in order, resolving clashes
+// by o.collision when flattening and by o.duplicates otherwise. It
+// returns the files to archive.
+func resolveNames(root string, files []string, o *options) ([]string, error) {
+	policy := o.duplicates
+	if o.flatten {
+		policy = o.collision
+	}
+
+	sources := make([]string, len(files))
+	taken := make(map[string]bool, len(files))
+	for i, file := range files {
+		name, err := o.sourceName(root, file)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = name
+		taken[o.targetName(name)] = true
+	}
+
+	// keeping the last of each name is keeping the first from the end
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+		if policy == CollisionKeepLast {
+			order[i] = len(files) - 1 - i
+		}
+	}
+
+	o.flattened = make(map[string]string, len(files))
+	first := make(map[string]int, len(files))
+	kept := make([]string, 0, len(files))
+	for _, i := range order {
+		file := files[i]
+		name := o.targetName(sources[i])
+		if j, ok := first[name]; ok {
+			switch {
+			case policy == CollisionSuffix:
+				name = suffixedName(name, taken)
+			case policy == CollisionKeepFirst || policy == CollisionKeepLast:
+				reason := "flattens to the same name as " + sources[j]
+				if !o.flatten {
+					reason = "is named the same as " + files[j]
+				}
+				if err := o.skipClash(file, sources[i], reason); err != nil {
+					return nil, err
+				}
+				continue
+			case o.flatten:
+				return nil, fmt.Errorf("%s and %s both flatten to %s", sources[j], sources[i], name)
+			default:
+				return nil, fmt.Errorf("%s and %s are both named %s: %w", files[j], file, name, fs.ErrExist)
+			}
+		} else {
+			first[name] = i
+		}
+
+		o.flattened[file] = name
+		kept = append(kept, file)
+	}
+	if policy == CollisionKeepLast {
+		slices.Reverse(kept)
+	}
+	return kept, nil
+}
+
+// targetName returns the name a file named source is archived under,
+// before clashes are resolved.
+func (o *options) targetName(source string) string {
+	if o.flatten {
+		return path.Base(source)
+	}
+	return source
+}
+
+// skipClash records in the report that file, named source, was left out
+// for the reason given.
+func (o *options) skipClash(file, source, reason string) error {
+	o.logSkip(file, reason)
+	if o.report == nil {
+		return nil
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	o.report.Skipped = append(o.report.Skipped, Skipped{
+		Entry:  source,
+		Size:   info.Size(),
+		Reason: reason,
+	})
+	return nil
+}
+
+// suffixedName returns name numbered with the first suffix not in taken,
+// and takes it.
+func suffixedName(name string, taken map[string]bool) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		numbered := fmt.Sprintf("%s-%d%s", stem, n, ext)
+		if !taken[numbered] {
+			taken[numbered] = true
+			return numbered
+		}
+	}
+}
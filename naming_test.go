@@ -0,0 +1,134 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipRename(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "renamed")
+	writeTree(t, src, map[string]string{
+		"README.md":       "readme",
+		"build/out/A.BIN": "binary",
+	})
+
+	var seen []string
+	zipPath := zipAside(t, src, WithManifest(), WithRename(func(srcPath string) string {
+		seen = append(seen, srcPath)
+		return strings.ToLower(strings.TrimPrefix(srcPath, "build/out/"))
+	}))
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if string(files["readme.md"]) != "readme" || string(files["a.bin"]) != "binary" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+	_, m := readManifest(t, zipPath)
+	if got := entryNames(m.Entries); len(got) != 2 || got[0] != "a.bin" || got[1] != "readme.md" {
+		t.Errorf("expected the manifest to use the new names, got %v", got)
+	}
+
+	// the callback gets the names the entries would otherwise have
+	for _, name := range seen {
+		if name != "README.md" && name != "build/out/A.BIN" {
+			t.Errorf("unexpected source path %q", name)
+		}
+	}
+}
+
+func TestZipRenameInvalid(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "escaping")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	for _, name := range []string{"", "../a.txt", "/etc/a.txt"} {
+		_, err := Zip(src, WithRename(func(string) string { return name }))
+		if err == nil {
+			os.Remove("escaping.zip")
+			t.Errorf("expected renaming to %q to fail", name)
+		}
+	}
+}
+
+func TestZipRootDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "checkout")
+	writeTree(t, src, map[string]string{"README.md": "readme", "cmd/main.go": "package main"})
+
+	zipPath := zipAside(t, src, WithRootDir("myapp-1.2.3/"), WithRename(strings.ToUpper))
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 2 || string(files["myapp-1.2.3/README.MD"]) != "readme" || string(files["myapp-1.2.3/CMD/MAIN.GO"]) != "package main" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+
+	for _, dir := range []string{"..", "/abs", "."} {
+		if _, err := Zip(src, WithRootDir(dir)); err == nil {
+			os.Remove("checkout.zip")
+			t.Errorf("expected root directory %q to be rejected", dir)
+		}
+	}
+}
+
+func TestZipFlatten(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "artifacts")
+	writeTree(t, src, map[string]string{
+		"a/app.bin":    "first",
+		"b/app.bin":    "second",
+		"b/app-1.bin":  "real",
+		"c/d/app.bin":  "third",
+		"c/readme.txt": "readme",
+	})
+
+	tests := []struct {
+		policy  Collision
+		want    map[string]string
+		skipped []string
+	}{
+		{CollisionSuffix, map[string]string{
+			"app.bin":    "first",
+			"app-1.bin":  "real",
+			"app-2.bin":  "second",
+			"app-3.bin":  "third",
+			"readme.txt": "readme",
+		}, nil},
+		{CollisionKeepFirst, map[string]string{
+			"app.bin":    "first",
+			"app-1.bin":  "real",
+			"readme.txt": "readme",
+		}, []string{"b/app.bin", "c/d/app.bin"}},
+		{CollisionKeepLast, map[string]string{
+			"app.bin":    "third",
+			"app-1.bin":  "real",
+			"readme.txt": "readme",
+		}, []string{"b/app.bin", "a/app.bin"}},
+	}
+	for _, tt := range tests {
+		report := &Report{}
+		zipPath := zipAside(t, src, WithFlatten(tt.policy), WithOrder(OrderName), WithReport(report))
+
+		files := zipptest.ReadArchive(t, zipPath)
+		if len(files) != len(tt.want) {
+			t.Errorf("%s: unexpected contents: %q", tt.policy, files)
+		}
+		for name, contents := range tt.want {
+			if string(files[name]) != contents {
+				t.Errorf("%s: expected %s to hold %q, got %q", tt.policy, name, contents, files[name])
+			}
+		}
+
+		var skipped []string
+		for _, s := range report.Skipped {
+			skipped = append(skipped, s.Entry)
+		}
+		if strings.Join(skipped, ",") != strings.Join(tt.skipped, ",") {
+			t.Errorf("%s: expected %v skipped, got %v", tt.policy, tt.skipped, skipped)
+		}
+	}
+
+	_, err := Zip(src, WithFlatten(CollisionError))
+	if err == nil || !strings.Contains(err.Error(), "a/app.bin and b/app.bin both flatten to app.bin") {
+		os.Remove("artifacts.zip")
+		t.Errorf("expected a clash to fail, got %v", err)
+	}
+}
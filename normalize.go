@@ -0,0 +1,60 @@
+package zipper
+
+import "golang.org/x/text/unicode/norm"
+
+// Normalization selects the Unicode normalization form of entry names.
+//
+// The same accented name can be spelled with precomposed characters or
+// with base characters followed by combining marks. macOS has long
+// favoured the decomposed form and Linux keeps whatever it is given, so
+// a file archived on one may exist on the other under a name that looks
+// the same but does not match.
+type Normalization int
+
+const (
+	// NormalizeNone keeps names as they are. It is the default.
+	NormalizeNone Normalization = iota
+
+	// NormalizeNFC composes names, the form most Linux and Windows
+	// software produces and expects.
+	NormalizeNFC
+
+	// NormalizeNFD decomposes names, the form older macOS file systems
+	// store.
+	NormalizeNFD
+)
+
+func (n Normalization) String() string {
+	switch n {
+	case NormalizeNone:
+		return "none"
+	case NormalizeNFC:
+		return "nfc"
+	case NormalizeNFD:
+		return "nfd"
+	default:
+		return "unknown"
+	}
+}
+
+// WithNormalization normalizes entry names to form: those Zip and Writer
+// give files when archiving, and those extraction writes files under.
+// Include and exclude patterns match the names as they are in the
+// archive.
+func WithNormalization(form Normalization) Option {
+	return func(o *options) {
+		o.normalization = form
+	}
+}
+
+// normalize returns name in the normalization form chosen.
+func (o *options) normalize(name string) string {
+	switch o.normalization {
+	case NormalizeNFC:
+		return norm.NFC.String(name)
+	case NormalizeNFD:
+		return norm.NFD.String(name)
+	default:
+		return name
+	}
+}
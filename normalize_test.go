@@ -0,0 +1,38 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+const (
+	cafeNFC = "café.txt"
+	cafeNFD = "café.txt"
+)
+
+func TestNormalization(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "names")
+	writeTree(t, src, map[string]string{cafeNFD: "decomposed"})
+
+	files := zipptest.ReadArchive(t, zipAside(t, src))
+	if files[cafeNFD] == nil {
+		t.Errorf("expected the name to be kept, got %q", files)
+	}
+
+	zipPath := zipAside(t, src, WithNormalization(NormalizeNFC))
+	files = zipptest.ReadArchive(t, zipPath)
+	if string(files[cafeNFC]) != "decomposed" {
+		t.Errorf("expected the name to be composed, got %q", files)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithNormalization(NormalizeNFD)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, cafeNFD)); err != nil {
+		t.Errorf("expected the file extracted under the decomposed name: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package zipper
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io/fs"
+	"time"
+)
+
+// ntfsExtraID tags the NTFS extra field, which records a file's
+// modification, access and creation times to 100 nanoseconds.
+const ntfsExtraID = 0x000a
+
+// filetimeOffset is the number of 100 nanosecond intervals between the
+// Windows FILETIME epoch, 1601, and the Unix epoch.
+const filetimeOffset = 116444736000000000
+
+// fileTimes are the times of a file the NTFS extra field records.
+type fileTimes struct {
+	modified time.Time
+	accessed time.Time
+	created  time.Time
+}
+
+// entryTimes returns the times recorded for a file with info, modified at
+// modified as recorded. Access and creation times are those of the file
+// where the platform reports them, and otherwise the modification time,
+// as they are in a reproducible archive.
+func (o *options) entryTimes(info fs.FileInfo, modified time.Time) fileTimes {
+	t := fileTimes{modified: modified, accessed: modified, created: modified}
+	if o.reproducible {
+		return t
+	}
+	if accessed, created := statTimes(info); !accessed.IsZero() {
+		t.accessed, t.created = accessed, created
+	}
+	return t
+}
+
+// ntfsExtra returns the NTFS extra field recording t.
+func ntfsExtra(t fileTimes) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 36)
+	le.PutUint16(b[0:], ntfsExtraID)
+	le.PutUint16(b[2:], 32)
+	// 4 reserved bytes, then the attribute holding the three times
+	le.PutUint16(b[8:], 1)
+	le.PutUint16(b[10:], 24)
+	le.PutUint64(b[12:], toFiletime(t.modified))
+	le.PutUint64(b[20:], toFiletime(t.accessed))
+	le.PutUint64(b[28:], toFiletime(t.created))
+	return b
+}
+
+// parseNTFS returns the times recorded in the data of an NTFS extra
+// field, if it holds them.
+func parseNTFS(field []byte) (fileTimes, bool) {
+	le := binary.LittleEndian
+	if len(field) < 4 {
+		return fileTimes{}, false
+	}
+	attrs := field[4:]
+	for len(attrs) >= 4 {
+		tag, size := le.Uint16(attrs), int(le.Uint16(attrs[2:]))
+		if len(attrs) < 4+size {
+			break
+		}
+		if tag == 1 && size >= 24 {
+			return fileTimes{
+				modified: fromFiletime(le.Uint64(attrs[4:])),
+				accessed: fromFiletime(le.Uint64(attrs[12:])),
+				created:  fromFiletime(le.Uint64(attrs[20:])),
+			}, true
+		}
+		attrs = attrs[4+size:]
+	}
+	return fileTimes{}, false
+}
+
+// zipTimes returns the times of the zip entry f, from its NTFS extra
+// field if it has one, in the zone archive/zip gives its modification
+// time, and otherwise that time alone.
+func zipTimes(f *zip.File) fileTimes {
+	extra := f.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if id == ntfsExtraID {
+			if t, ok := parseNTFS(extra[4 : 4+size]); ok {
+				loc := f.Modified.Location()
+				return fileTimes{modified: t.modified.In(loc), accessed: t.accessed.In(loc), created: t.created.In(loc)}
+			}
+		}
+		extra = extra[4+size:]
+	}
+	return fileTimes{modified: f.Modified}
+}
+
+func toFiletime(t time.Time) uint64 {
+	return uint64(t.Unix()*1e7 + int64(t.Nanosecond()/100) + filetimeOffset)
+}
+
+func fromFiletime(ft uint64) time.Time {
+	ticks := int64(ft) - filetimeOffset
+	return time.Unix(ticks/1e7, ticks%1e7*100).UTC()
+}
@@ -0,0 +1,68 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNTFSTimesRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "precise")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	modified := time.Date(2024, 5, 17, 9, 30, 15, 123456700, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := zipAside(t, src)
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := zipTimes(r.File[0])
+	r.Close()
+	if !got.modified.Equal(modified) {
+		t.Errorf("expected the NTFS field to record %v, got %v", modified, got.modified)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil || !entries[0].Modified.Equal(modified) {
+		t.Errorf("expected List to report %v, got %+v, %v", modified, entries, err)
+	}
+
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, extract := range []func(dest string) error{
+		func(dest string) error { return Unzip(zipPath, dest) },
+		func(dest string) error { return UnzipReader(bytes.NewReader(data), dest) },
+	} {
+		dest := t.TempDir()
+		if err := extract(dest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		info, err := os.Stat(filepath.Join(dest, "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(modified) {
+			t.Errorf("expected modified %v, got %v", modified, info.ModTime())
+		}
+	}
+}
+
+func TestFiletime(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, 999999900, time.UTC),
+		time.Date(2024, 5, 17, 9, 30, 15, 123456700, time.UTC),
+	} {
+		if got := fromFiletime(toFiletime(want)); !got.Equal(want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package zipper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option configures optional behaviour of Zip and Unzip.
+type Option func(*options)
+
+type options struct {
+	timeZone     TimeZonePolicy
+	maxOpenFiles int
+	maxWorkers   int
+
+	readBufferSize  int
+	writeBufferSize int
+
+	bandwidth  int64
+	readLimit  *limiter
+	writeLimit *limiter
+
+	manifest     bool
+	method       Method
+	level        int
+	stats        *StatsCache
+	groups       []entryGroup
+	splitSize    int64
+	sfxStub      string
+	format       Format
+	sink         Sink
+	output       string
+	exclusive    bool
+	sync         bool
+	ownership    bool
+	base         string
+	resume       bool
+	dedup        bool
+	hardLinks    bool
+	sparse       bool
+	reproducible bool
+	password     string
+	epoch        time.Time
+	order        Order
+
+	oneFileSystem bool
+	maxDepth      int
+	minSize       int64
+	maxSize       int64
+	filter        func(string, fs.DirEntry) bool
+	rename        func(string) string
+	rootDir       string
+	normalization Normalization
+	flatten       bool
+	collision     Collision
+	duplicates    Collision
+	flattened     map[string]string // entry names by file, once resolved
+
+	continueOnError bool
+	failed          []*PathError // files left out, with continueOnError
+	fileTimeout     time.Duration
+	ctx             context.Context
+
+	includes        []string
+	excludes        []string
+	skipMacMetadata bool
+	skipHidden      bool
+	specialFiles    SpecialFilePolicy
+	limits          Limits
+	overwrite       OverwritePolicy
+	overwriteFunc   func(Conflict) OverwritePolicy
+	targetFS        WritableFS
+	cleanup         CleanupPolicy
+	components      map[string]bool
+	remaps          []remap
+	remapFunc       func(string) string
+	modeMask        fs.FileMode
+	defaultMode     fs.FileMode
+	ignoreModes     bool
+	keepSpecialBits bool
+	report          *Report
+
+	dryRun   func(PlannedEntry)
+	onEntry  func(EntryInfo)
+	progress *tracker
+	logger   *slog.Logger
+	metrics  Metrics
+	summary  *Summary
+	meter    *meter
+
+	httpClient *http.Client
+	sha256     string
+	retries    int
+	checkInput func() error // run once the entries are written
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		timeZone: TimeZoneLocal,
+		retries:  defaultRetries,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.bandwidth > 0 {
+		o.readLimit = newLimiter(o.bandwidth)
+		o.writeLimit = newLimiter(o.bandwidth)
+	}
+	o.meter = o.newMeter()
+	return o
+}
+
+// validate checks option values that cannot be checked when set.
+func (o *options) validate() error {
+	if o.sfxStub != "" && o.splitSize > 0 {
+		return errors.New("a self-extracting archive cannot be split")
+	}
+	if o.sink != nil && o.splitSize > 0 {
+		return errors.New("an archive written to a sink cannot be split")
+	}
+	if o.resume && (o.splitSize > 0 || o.sink != nil || o.sfxStub != "" || o.format != FormatZip) {
+		return errors.New("a resumable archive must be a single zip file")
+	}
+	if o.reproducible && o.sparse {
+		return errors.New("sparse layouts cannot be reproduced")
+	}
+	if o.format != FormatZip && o.base != "" {
+		return fmt.Errorf("incremental archives require zip format, not %s", o.format)
+	}
+	if o.format != FormatZip && (o.manifest || o.sfxStub != "") {
+		return fmt.Errorf("manifests and self-extractors require zip format, not %s", o.format)
+	}
+	if o.fileTimeout < 0 {
+		return fmt.Errorf("invalid file timeout %v", o.fileTimeout)
+	}
+	if err := o.validateRemaps(); err != nil {
+		return err
+	}
+	if o.modeMask&^fs.ModePerm != 0 || o.defaultMode&^fs.ModePerm != 0 {
+		return errors.New("mode masks and default modes take permission bits only")
+	}
+	if o.level < 0 || o.level > 9 {
+		return fmt.Errorf("invalid compression level %d, want 1 to 9", o.level)
+	}
+	if o.password != "" {
+		if o.format != FormatZip {
+			return fmt.Errorf("encryption requires zip format, not %s", o.format)
+		}
+		if o.resume || o.manifest {
+			return errors.New("an encrypted archive cannot be resumed or carry a manifest")
+		}
+	}
+
+	if o.rootDir != "" {
+		if o.format == FormatGzip {
+			return errors.New("a gzip file has no directories to place its file under")
+		}
+		if dir, err := sanitizeName(o.rootDir); err != nil || dir == "." {
+			return fmt.Errorf("invalid root directory %q", o.rootDir)
+		}
+	}
+
+	if o.sha256 != "" {
+		if b, err := hex.DecodeString(o.sha256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid sha256 checksum %q", o.sha256)
+		}
+	}
+
+	var patterns []string
+	patterns = append(patterns, o.includes...)
+	patterns = append(patterns, o.excludes...)
+	for _, g := range o.groups {
+		patterns = append(patterns, g.patterns...)
+	}
+
+	for _, p := range patterns {
+		if err := validatePattern(p); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// selected reports whether the entry name passes the include and exclude
+// patterns, and is not macOS metadata left out by WithSkipMacMetadata.
+func (o *options) selected(name string) bool {
+	if o.skipMacMetadata && inMacMetadata(name) {
+		return false
+	}
+
+	if len(o.includes) > 0 {
+		included := false
+		for _, p := range o.includes {
+			if match(p, name) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	return !o.excluded(name)
+}
+
+// selectedEntry is selected for the name of an archive entry, taken in
+// the form it is extracted under, so ./docs/a.md and docs\a.md match
+// docs/**. Names sanitizeName refuses are taken as they are, for
+// extraction to refuse them in turn.
+func (o *options) selectedEntry(name string) bool {
+	if clean, err := sanitizeName(name); err == nil {
+		name = clean
+	}
+	return o.selected(name)
+}
+
+// excluded reports whether the entry name matches an exclude pattern.
+func (o *options) excluded(name string) bool {
+	for _, p := range o.excludes {
+		if match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTimeZonePolicy sets the time zone entry modification times are
+// recorded in. The default is TimeZoneLocal.
+func WithTimeZonePolicy(p TimeZonePolicy) Option {
+	return func(o *options) {
+		o.timeZone = p
+	}
+}
+
+// WithInclude limits Unzip to entries matching at least one of the glob
+// patterns. Patterns may use "**" to match any number of directories, and
+// patterns without a slash match the base name at any depth. It may be
+// given more than once.
+//
+// Zip matches the patterns against the paths of files relative to inPath,
+// before WithRename and the like.
+func WithInclude(patterns ...string) Option {
+	return func(o *options) {
+		o.includes = append(o.includes, patterns...)
+	}
+}
+
+// WithExclude makes Unzip skip entries matching any of the glob patterns,
+// using the same syntax as WithInclude. Excludes win over includes.
+//
+// Zip skips files the same way, and does not descend into directories
+// matching a pattern, so "node_modules" or ".git" leave out whole trees.
+func WithExclude(patterns ...string) Option {
+	return func(o *options) {
+		o.excludes = append(o.excludes, patterns...)
+	}
+}
+
+// WithMaxWorkers bounds the number of files Zip compresses at once, and
+// so the CPU it uses. The default is GOMAXPROCS. WithMaxOpenFiles may
+// lower it further.
+//
+// Given n above 1, Unzip and Extract also write up to n files of a zip
+// archive at once, which they otherwise write one at a time. Entries are
+// still placed in order, so directories exist before the files in them,
+// but files are reported to WithOnEntry as they complete.
+func WithMaxWorkers(n int) Option {
+	return func(o *options) {
+		o.maxWorkers = n
+	}
+}
+
+// WithMaxOpenFiles bounds the number of files Zip holds open at once while
+// compressing entries in parallel. By default half of the process limit
+// on open files (RLIMIT_NOFILE) is used where it can be detected.
+func WithMaxOpenFiles(n int) Option {
+	return func(o *options) {
+		o.maxOpenFiles = n
+	}
+}
@@ -0,0 +1,102 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Order selects the order in which Zip writes entries.
+type Order int
+
+const (
+	// OrderWalk writes entries in the order the directory walk visits
+	// them, which is lexical within each directory. It is the default,
+	// except with WithReproducible, which defaults to OrderName.
+	OrderWalk Order = iota
+
+	// OrderName sorts entries by name, byte by byte.
+	OrderName
+
+	// OrderDirsFirst sorts the contents of each directory's
+	// subdirectories before its own files, each group by name, as file
+	// managers list them.
+	OrderDirsFirst
+
+	// OrderLargestFirst sorts entries by decreasing size, then by name.
+	// Starting the longest compressions first keeps the workers busy to
+	// the end, so it is usually the fastest order for large trees.
+	OrderLargestFirst
+)
+
+// WithOrder sets the order in which Zip writes entries. Every order but
+// OrderWalk is independent of the file system's listing order.
+func WithOrder(order Order) Option {
+	return func(o *options) {
+		o.order = order
+	}
+}
+
+func (order Order) String() string {
+	switch order {
+	case OrderWalk:
+		return "walk"
+	case OrderName:
+		return "name"
+	case OrderDirsFirst:
+		return "dirs-first"
+	case OrderLargestFirst:
+		return "largest-first"
+	default:
+		return "unknown"
+	}
+}
+
+// sortFiles sorts files, which share one root, into the order entries
+// are written in.
+func sortFiles(files []string, order Order) error {
+	switch order {
+	case OrderName:
+		sort.SliceStable(files, func(i, j int) bool {
+			return filepath.ToSlash(files[i]) < filepath.ToSlash(files[j])
+		})
+	case OrderDirsFirst:
+		sort.SliceStable(files, func(i, j int) bool {
+			return dirsFirst(filepath.ToSlash(files[i]), filepath.ToSlash(files[j]))
+		})
+	case OrderLargestFirst:
+		sizes := make(map[string]int64, len(files))
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			sizes[file] = info.Size()
+		}
+		sort.SliceStable(files, func(i, j int) bool {
+			a, b := files[i], files[j]
+			if sizes[a] != sizes[b] {
+				return sizes[a] > sizes[b]
+			}
+			return filepath.ToSlash(a) < filepath.ToSlash(b)
+		})
+	}
+	return nil
+}
+
+// dirsFirst reports whether the slash-separated path a sorts before b
+// when subdirectories come before files.
+func dirsFirst(a, b string) bool {
+	for {
+		aDir, aRest, aNested := strings.Cut(a, "/")
+		bDir, bRest, bNested := strings.Cut(b, "/")
+		if aDir != bDir || !aNested || !bNested {
+			if aNested != bNested {
+				return aNested
+			}
+			return aDir < bDir
+		}
+		a, b = aRest, bRest
+	}
+}
@@ -0,0 +1,72 @@
+package zipper
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithOrder(t *testing.T) {
+	tree := map[string]string{
+		"b.txt":       "small",
+		"a/z.txt":     strings.Repeat("z", 300),
+		"a/sub/y.txt": "y",
+		"a.txt":       strings.Repeat("a", 200),
+		"c/x.txt":     strings.Repeat("x", 100),
+	}
+
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{OrderWalk, []string{"a/sub/y.txt", "a/z.txt", "a.txt", "b.txt", "c/x.txt"}},
+		{OrderName, []string{"a.txt", "a/sub/y.txt", "a/z.txt", "b.txt", "c/x.txt"}},
+		{OrderDirsFirst, []string{"a/sub/y.txt", "a/z.txt", "c/x.txt", "a.txt", "b.txt"}},
+		{OrderLargestFirst, []string{"a/z.txt", "a.txt", "c/x.txt", "b.txt", "a/sub/y.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "ordered")
+			writeTree(t, src, tree)
+
+			zipPath := zipAside(t, src, WithOrder(tt.order))
+			r, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			var got []string
+			for _, f := range r.File {
+				got = append(got, f.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDirsFirst(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a/x", "a.txt", true},
+		{"z/x", "a.txt", true},
+		{"a.txt", "b.txt", true},
+		{"a/b/c", "a/c", true},
+		{"a/c", "a/b/c", false},
+		{"a/b", "a/c", true},
+		{"b/x", "a/x", false},
+	}
+
+	for _, tt := range tests {
+		if got := dirsFirst(tt.a, tt.b); got != tt.want {
+			t.Errorf("dirsFirst(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
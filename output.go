@@ -0,0 +1,128 @@
+package zipper
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// tempSuffix is appended to the archive's path to name the file it is
+// written to until complete.
+const tempSuffix = ".tmp"
+
+// WithOutput sets where Zip and Convert write the archive instead of the
+// current directory. If path is an existing directory, or ends in a path
+// separator, the archive is written there under its usual name, and
+// otherwise path is the archive's own path. With WithSink, it is the
+// name the archive is stored under.
+func WithOutput(path string) Option {
+	return func(o *options) {
+		o.output = path
+	}
+}
+
+// WithOutputExclusive makes Zip and Convert fail with an error wrapping
+// fs.ErrExist if the archive already exists, instead of replacing it.
+// WithOverwrite governs the files extraction writes, not archives.
+func WithOutputExclusive() Option {
+	return func(o *options) {
+		o.exclusive = true
+	}
+}
+
+// outputPath returns the path of the archive usually named name.
+func (o *options) outputPath(name string) string {
+	if o.output == "" {
+		return name
+	}
+
+	if os.IsPathSeparator(o.output[len(o.output)-1]) {
+		return filepath.Join(o.output, name)
+	}
+	if info, err := os.Stat(o.output); err == nil && info.IsDir() {
+		return filepath.Join(o.output, name)
+	}
+	return o.output
+}
+
+// checkOutput fails if the archive at dstPath exists and must not be
+// replaced.
+func checkOutput(dstPath string, o *options) error {
+	if !o.exclusive || o.sink != nil {
+		return nil
+	}
+	if _, err := os.Lstat(dstPath); err == nil {
+		return &fs.PathError{Op: "create", Path: dstPath, Err: fs.ErrExist}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// createOutput opens the destination for the archive at dstPath, paced
+// to the write limit, if any.
+func createOutput(dstPath string, o *options) (Upload, error) {
+	out, err := openOutput(dstPath, o)
+	if err != nil || o.writeLimit == nil {
+		return out, err
+	}
+
+	// skipping over the entries kept by a resumed archive is free
+	if r, ok := out.(*resumableOutput); ok {
+		r.w = o.throttleWriter(r.f)
+		return r, nil
+	}
+	return throttledUpload{Upload: out, w: o.throttleWriter(out)}, nil
+}
+
+// openOutput opens the destination for the archive at dstPath.
+func openOutput(dstPath string, o *options) (Upload, error) {
+	if o.sink != nil {
+		return o.sink.Create(dstPath)
+	}
+	if o.resume {
+		r, err := openResumable(dstPath)
+		if err != nil {
+			return nil, err
+		}
+		r.sync = o.sync
+		return r, nil
+	}
+	if o.splitSize > 0 {
+		s := newSplitOutput(dstPath, o.splitSize)
+		s.sync = o.sync
+		return s, nil
+	}
+
+	f, err := os.Create(dstPath + tempSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return &fileOutput{f: f, dstPath: dstPath, sync: o.sync}, nil
+}
+
+// fileOutput writes the archive to a temporary file beside dstPath and
+// renames it once complete, so the archive's name never refers to a
+// partial file.
+type fileOutput struct {
+	f       *os.File
+	dstPath string
+	sync    bool // flush to stable storage, for WithSync
+}
+
+func (o *fileOutput) Write(p []byte) (int, error) {
+	return o.f.Write(p)
+}
+
+func (o *fileOutput) Commit() error {
+	if err := syncClose(o.f, o.sync); err != nil {
+		os.Remove(o.f.Name())
+		return err
+	}
+	return renameSynced(o.f.Name(), o.dstPath, o.sync)
+}
+
+func (o *fileOutput) Abort() {
+	o.f.Close()
+	os.Remove(o.f.Name())
+}
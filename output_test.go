@@ -0,0 +1,67 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipOutput(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "project")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	outDir := t.TempDir()
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{outDir, filepath.Join(outDir, "project.zip")},
+		{filepath.Join(outDir, "new") + string(filepath.Separator), filepath.Join(outDir, "new", "project.zip")},
+		{filepath.Join(outDir, "release.zip"), filepath.Join(outDir, "release.zip")},
+	}
+	if err := os.Mkdir(filepath.Join(outDir, "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		zipPath, err := Zip(src, WithOutput(tt.output))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.output, err)
+		}
+		if zipPath != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.output, tt.want, zipPath)
+		}
+		if files := zipptest.ReadArchive(t, zipPath); string(files["a.txt"]) != "alpha" {
+			t.Errorf("%s: unexpected contents: %q", tt.output, files)
+		}
+	}
+}
+
+func TestZipOutputExclusive(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "project")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	zipPath := filepath.Join(t.TempDir(), "project.zip")
+	if err := os.WriteFile(zipPath, []byte("existing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Zip(src, WithOutput(zipPath), WithOutputExclusive())
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected fs.ErrExist, got %v", err)
+	}
+	if data, _ := os.ReadFile(zipPath); string(data) != "existing" {
+		t.Error("expected the existing archive to be left alone")
+	}
+
+	// without the option it is replaced
+	if _, err := Zip(src, WithOutput(zipPath)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if files := zipptest.ReadArchive(t, zipPath); string(files["a.txt"]) != "alpha" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
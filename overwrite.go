@@ -0,0 +1,129 @@
+package zipper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OverwritePolicy decides what Unzip does when an entry's destination
+// already exists.
+type OverwritePolicy int
+
+const (
+	// OverwriteError fails the extraction with an error wrapping
+	// fs.ErrExist. It is the default.
+	OverwriteError OverwritePolicy = iota
+
+	// OverwriteSkip leaves the existing file untouched.
+	OverwriteSkip
+
+	// OverwriteAlways replaces the existing file.
+	OverwriteAlways
+
+	// OverwriteIfNewer replaces the existing file only when the entry
+	// was modified after it. As entries absent from the destination are
+	// written too, it updates a mostly unchanged tree from an archive,
+	// decompressing only the entries that changed.
+	OverwriteIfNewer
+
+	// OverwriteRename extracts the entry next to the existing file under
+	// a free name with a numeric suffix, e.g. "report (1).txt".
+	OverwriteRename
+)
+
+// WithOverwrite sets the policy applied when Unzip would write over an
+// existing file. The default is OverwriteError.
+func WithOverwrite(p OverwritePolicy) Option {
+	return func(o *options) {
+		o.overwrite = p
+	}
+}
+
+// Conflict describes an entry whose destination already exists, as
+// passed to the function given to WithOverwriteFunc.
+type Conflict struct {
+	// Name is the entry's name in the archive, and Modified its
+	// modification time.
+	Name     string
+	Modified time.Time
+
+	// Path is the destination, and Existing describes the file there.
+	Path     string
+	Existing fs.FileInfo
+}
+
+// WithOverwriteFunc makes Unzip call decide for each entry whose
+// destination already exists, applying the policy it returns to that
+// entry alone, for example to ask the user. It takes precedence over
+// WithOverwrite. Existing directories always fail the extraction.
+func WithOverwriteFunc(decide func(Conflict) OverwritePolicy) Option {
+	return func(o *options) {
+		o.overwriteFunc = decide
+	}
+}
+
+// resolveConflict applies the overwrite policy to the destination path of
+// f. It returns the path to write to, or "" if the entry should be
+// skipped. Any existing file that is to be replaced is removed, so
+// extraction never writes through links or into other hard links.
+func (e *extractor) resolveConflict(f entry, path string) (string, error) {
+	info, err := e.lstat(path)
+	if os.IsNotExist(err) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return "", &fs.PathError{Op: "extract", Path: path, Err: fs.ErrExist}
+	}
+
+	policy := e.o.overwrite
+	if e.o.overwriteFunc != nil {
+		policy = e.o.overwriteFunc(Conflict{Name: f.name, Modified: f.modified, Path: path, Existing: info})
+	}
+
+	switch policy {
+	case OverwriteSkip:
+		e.skipped(f, "exists")
+		return "", nil
+	case OverwriteAlways:
+	case OverwriteIfNewer:
+		if !f.modified.After(info.ModTime()) {
+			e.skipped(f, "exists and is not older")
+			return "", nil
+		}
+	case OverwriteRename:
+		return e.freeName(path)
+	default:
+		return "", &fs.PathError{Op: "extract", Path: path, Err: fs.ErrExist}
+	}
+
+	if e.o.dryRun == nil {
+		if err := e.remove(path); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// freeName returns the first "name (n).ext" next to path that does not
+// exist.
+func (e *extractor) freeName(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := e.lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestUnzipOverwritePolicy(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"report.txt": "from archive"})
+
+	// the archived file carries the current time, so a file dated in the
+	// past is older and one dated in the future is newer
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		policy    OverwritePolicy
+		existing  time.Time
+		wantError bool
+		want      map[string]string
+	}{
+		{
+			name:      "error",
+			policy:    OverwriteError,
+			existing:  past,
+			wantError: true,
+			want:      map[string]string{"report.txt": "on disk"},
+		},
+		{
+			name:     "skip",
+			policy:   OverwriteSkip,
+			existing: past,
+			want:     map[string]string{"report.txt": "on disk"},
+		},
+		{
+			name:     "always",
+			policy:   OverwriteAlways,
+			existing: future,
+			want:     map[string]string{"report.txt": "from archive"},
+		},
+		{
+			name:     "if newer replaces older file",
+			policy:   OverwriteIfNewer,
+			existing: past,
+			want:     map[string]string{"report.txt": "from archive"},
+		},
+		{
+			name:     "if newer keeps newer file",
+			policy:   OverwriteIfNewer,
+			existing: future,
+			want:     map[string]string{"report.txt": "on disk"},
+		},
+		{
+			name:     "rename",
+			policy:   OverwriteRename,
+			existing: past,
+			want:     map[string]string{"report.txt": "on disk", "report (1).txt": "from archive"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			existing := filepath.Join(dest, "report.txt")
+			if err := os.WriteFile(existing, []byte("on disk"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(existing, tt.existing, tt.existing); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Unzip(zipPath, dest, WithOverwrite(tt.policy))
+			if tt.wantError {
+				if !errors.Is(err, fs.ErrExist) {
+					t.Errorf("expected error wrapping fs.ErrExist, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, content := range tt.want {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil {
+					t.Errorf("reading %s: %v", name, err)
+					continue
+				}
+				if string(data) != content {
+					t.Errorf("%s: expected %q, got %q", name, content, data)
+				}
+			}
+
+			if got := listTree(t, dest); len(got) != len(tt.want) {
+				t.Errorf("expected %d files, got %v", len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestUnzipOverwriteFunc(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "new a", "b.txt": "new b", "c.txt": "new c"})
+
+	dest := t.TempDir()
+	writeTree(t, dest, map[string]string{"a.txt": "old a", "b.txt": "old b"})
+
+	var asked []string
+	decide := func(c Conflict) OverwritePolicy {
+		asked = append(asked, c.Name)
+		if c.Path != filepath.Join(dest, c.Name) || c.Existing.Size() != 5 {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+		if c.Name == "a.txt" {
+			return OverwriteAlways
+		}
+		return OverwriteSkip
+	}
+	if err := Unzip(zipPath, dest, WithOverwrite(OverwriteError), WithOverwriteFunc(decide)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(asked)
+	if len(asked) != 2 || asked[0] != "a.txt" || asked[1] != "b.txt" {
+		t.Errorf("expected to be asked about a.txt and b.txt, got %v", asked)
+	}
+	want := map[string]string{"a.txt": "new a", "b.txt": "old b", "c.txt": "new c"}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+}
+
+func TestFreeName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "a (1).txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := &extractor{o: newOptions(nil)}
+	got, err := e.freeName(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "a (2).txt"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestUnzipUpdate(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta", "c.txt": "gamma"})
+	zipPath := zipAside(t, src)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	// one file went stale, one was edited since, one is missing
+	past := time.Now().Add(-time.Hour)
+	writeTree(t, dest, map[string]string{"a.txt": "stale", "b.txt": "edited"})
+	if err := os.Chtimes(filepath.Join(dest, "a.txt"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dest, "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	var last Progress
+	report := func(p Progress) { last = p }
+	if err := Unzip(zipPath, dest, WithOverwrite(OverwriteIfNewer), WithProgress(report)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.txt": "alpha", "b.txt": "edited", "c.txt": "gamma"}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+	if last.Bytes != last.Total || last.Total != 14 {
+		t.Errorf("expected progress to count the skipped entry, got %+v", last)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package zipper
+
+import "io/fs"
+
+// fileOwner reports no owner where files are not owned by number.
+func fileOwner(info fs.FileInfo) (owner, bool) {
+	return owner{}, false
+}
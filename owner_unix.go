@@ -0,0 +1,17 @@
+//go:build unix
+
+package zipper
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the owner of the file with info.
+func fileOwner(info fs.FileInfo) (owner, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return owner{}, false
+	}
+	return owner{uid: int(st.Uid), gid: int(st.Gid)}, true
+}
@@ -0,0 +1,100 @@
+package zipper
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"os"
+)
+
+// unixExtraID tags the Info-ZIP Unix extra field, which records the owner
+// and group of a file by number.
+const unixExtraID = 0x7875
+
+// owner is the user and group owning a file, by number.
+type owner struct {
+	uid, gid int
+}
+
+// WithPreserveOwnership makes Zip record the owner and group of each file,
+// by number, in an Info-ZIP Unix extra field, and extraction restore
+// those recorded there or in tar headers. Only root may give files away,
+// so without privilege extracted files are left owned by the user
+// running the extraction, as tar does, and the recorded owners are
+// ignored.
+func WithPreserveOwnership() Option {
+	return func(o *options) {
+		o.ownership = true
+	}
+}
+
+// unixExtra returns the Info-ZIP Unix extra field recording u.
+func unixExtra(u owner) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 15)
+	le.PutUint16(b[0:], unixExtraID)
+	le.PutUint16(b[2:], 11)
+	b[4] = 1 // version
+	b[5] = 4
+	le.PutUint32(b[6:], uint32(u.uid))
+	b[10] = 4
+	le.PutUint32(b[11:], uint32(u.gid))
+	return b
+}
+
+// parseUnixExtra returns the owner recorded in the data of an Info-ZIP
+// Unix extra field, whose numbers may be of any size up to 8 bytes.
+func parseUnixExtra(field []byte) (owner, bool) {
+	if len(field) < 2 || field[0] != 1 {
+		return owner{}, false
+	}
+	uid, rest, ok := readUnixID(field[1:])
+	if !ok {
+		return owner{}, false
+	}
+	gid, _, ok := readUnixID(rest)
+	if !ok {
+		return owner{}, false
+	}
+	return owner{uid: uid, gid: gid}, true
+}
+
+// readUnixID reads a number preceded by its size in bytes from b,
+// returning what follows it.
+func readUnixID(b []byte) (int, []byte, bool) {
+	if len(b) < 1 || int(b[0]) > 8 || len(b) < 1+int(b[0]) {
+		return 0, nil, false
+	}
+	var id uint64
+	for i := int(b[0]); i > 0; i-- {
+		id = id<<8 | uint64(b[i])
+	}
+	return int(id), b[1+int(b[0]):], true
+}
+
+// zipOwner returns the owner the zip entry f records, or nil.
+func zipOwner(f *zip.File) *owner {
+	extra := f.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if id == unixExtraID {
+			if u, ok := parseUnixExtra(extra[4 : 4+size]); ok {
+				return &u
+			}
+		}
+		extra = extra[4+size:]
+	}
+	return nil
+}
+
+// chown gives the file at path, for the entry f, the owner f records,
+// with WithPreserveOwnership and when privileged to.
+func (e *extractor) chown(f entry, path string) error {
+	if !e.o.ownership || f.owner == nil || os.Geteuid() != 0 {
+		return nil
+	}
+	return e.lchown(path, f.owner.uid, f.owner.gid)
+}
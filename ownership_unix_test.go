@@ -0,0 +1,87 @@
+//go:build unix
+
+package zipper
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestUnixExtra(t *testing.T) {
+	u, ok := parseUnixExtra(unixExtra(owner{uid: 1000, gid: 70000})[4:])
+	if !ok || u.uid != 1000 || u.gid != 70000 {
+		t.Errorf("unexpected owner %v", u)
+	}
+
+	// Info-ZIP may record numbers in as few as one byte
+	if u, ok := parseUnixExtra([]byte{1, 1, 42, 2, 0x10, 0x27}); !ok || u.uid != 42 || u.gid != 10000 {
+		t.Errorf("unexpected owner %v", u)
+	}
+	if _, ok := parseUnixExtra([]byte{1, 4, 0}); ok {
+		t.Error("expected a truncated field to be rejected")
+	}
+}
+
+func TestPreserveOwnership(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "owned")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	zipPath := zipAside(t, src, WithPreserveOwnership())
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	u := zipOwner(zr.File[0])
+	if u == nil || u.uid != os.Getuid() || u.gid != os.Getgid() {
+		t.Fatalf("expected the owner recorded, got %v", u)
+	}
+
+	if os.Geteuid() != 0 {
+		t.Skip("restoring owners requires root")
+	}
+
+	// give the entry away by rewriting the archive
+	crafted := filepath.Join(t.TempDir(), "crafted.zip")
+	out, err := os.Create(crafted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	hdr := zr.File[0].FileHeader
+	hdr.Extra = unixExtra(owner{uid: 4321, gid: 8765})
+	w, err := zw.CreateHeader(&hdr)
+	if err == nil {
+		_, err = w.Write([]byte("alpha"))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	out.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, preserve := range []bool{false, true} {
+		var opts []Option
+		if preserve {
+			opts = append(opts, WithPreserveOwnership())
+		}
+		dest := t.TempDir()
+		if err := Unzip(crafted, dest, opts...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		info, err := os.Stat(filepath.Join(dest, "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := info.Sys().(*syscall.Stat_t)
+		if got := st.Uid == 4321 && st.Gid == 8765; got != preserve {
+			t.Errorf("preserving %v: unexpected owner %d:%d", preserve, st.Uid, st.Gid)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package zipper
+
+import "sync"
+
+// extractWorkers returns how many files Unzip may write at once: one
+// unless WithMaxWorkers asks for more, within the open file budget.
+func (o *options) extractWorkers() int {
+	if o.maxWorkers <= 1 {
+		return 1
+	}
+	return o.workers()
+}
+
+// writePool writes the files of an extraction concurrently, once the
+// entries have been placed one by one, in order. Its methods do nothing
+// on a nil pool.
+type writePool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[string]bool // paths being written
+	err     error           // the first write to fail
+}
+
+func newWritePool(n int) *writePool {
+	return &writePool{sem: make(chan struct{}, n), pending: make(map[string]bool)}
+}
+
+// run calls write to write the file at path once a worker is free. It
+// fails with the error of an earlier write, if any, instead.
+func (p *writePool) run(path string, write func() error) error {
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p.sem <- struct{}{}
+	p.mu.Lock()
+	p.pending[path] = true
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		err := write()
+
+		p.mu.Lock()
+		delete(p.pending, path)
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		p.mu.Unlock()
+		<-p.sem
+	}()
+	return nil
+}
+
+// settle waits for the writes in flight if one of them is to path, so
+// that it can be inspected or removed.
+func (p *writePool) settle(path string) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	busy := p.pending[path]
+	p.mu.Unlock()
+	if busy {
+		return p.wait()
+	}
+	return nil
+}
+
+// wait waits for the writes in flight, returning the first error.
+func (p *writePool) wait() error {
+	if p == nil {
+		return nil
+	}
+
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
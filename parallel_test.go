@@ -0,0 +1,58 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestUnzipParallel(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		files[fmt.Sprintf("d%d/sub%d/f%d.txt", i%4, i%3, i)] = randomString(t, 1000+i)
+	}
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, files)
+	zipPath := zipAside(t, src)
+
+	dest := t.TempDir()
+	var entries int
+	onEntry := func(EntryInfo) { entries++ }
+	if err := Unzip(zipPath, dest, WithMaxWorkers(8), WithOnEntry(onEntry)); err != nil {
+		t.Fatal(err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+	if entries < len(files) {
+		t.Errorf("expected every file reported, got %d of %d", entries, len(files))
+	}
+
+	// files of the same name land one after the other
+	zipPath = createZip(t,
+		testEntry{name: "a.txt", body: "first"},
+		testEntry{name: "./a.txt", body: "second"},
+	)
+	dest = t.TempDir()
+	if err := Unzip(zipPath, dest, WithMaxWorkers(4), WithDuplicates(CollisionKeepLast)); err != nil {
+		t.Fatal(err)
+	}
+	if got := zipptest.ReadTree(t, dest); len(got) != 1 || string(got["a.txt"]) != "second" {
+		t.Errorf("expected the later entry kept, got %q", got)
+	}
+}
+
+func TestUnzipParallelLimits(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("f%d.txt", i)] = randomString(t, 1000)
+	}
+	zipPath := zipTree(t, files)
+
+	var le *LimitError
+	err := Unzip(zipPath, t.TempDir(), WithMaxWorkers(4), WithLimits(Limits{MaxTotalSize: 5000}))
+	if !errors.As(err, &le) || le.Limit != "MaxTotalSize" {
+		t.Errorf("expected the total size limit to stop the extraction, got %v", err)
+	}
+}
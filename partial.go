@@ -0,0 +1,66 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+)
+
+// WithContinueOnError makes Zip leave out files and directories it cannot
+// read, such as those it lacks permission for or that vanish while it
+// runs, instead of failing. The archive of everything else is still
+// written, and Zip returns its path along with a *PartialError listing
+// what was left out and why. Failures writing the archive still abort it.
+func WithContinueOnError() Option {
+	return func(o *options) {
+		o.continueOnError = true
+	}
+}
+
+// PartialError is returned by Zip with WithContinueOnError when files
+// could not be read. The archive holds every other file.
+type PartialError struct {
+	Failed []*PathError
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d files could not be archived: %s", len(e.Failed), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual failures, so errors.Is can match causes
+// such as fs.ErrPermission.
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// unreadable records that path could not be read and returns nil with
+// WithContinueOnError, if err is a failure to read it, or else returns
+// err.
+func (o *options) unreadable(path string, err error) error {
+	var pe *fs.PathError
+	if !o.continueOnError || !errors.As(err, &pe) || pe.Path != path {
+		return err
+	}
+	o.failed = append(o.failed, &PathError{Op: pe.Op, Entry: path, Err: pe.Err})
+	o.log(slog.LevelWarn, "skipped unreadable file", "path", path, "error", pe.Err)
+	return nil
+}
+
+// partialError returns the files left out with WithContinueOnError as a
+// *PartialError, or nil if there are none.
+func (o *options) partialError() error {
+	if len(o.failed) == 0 {
+		return nil
+	}
+	return &PartialError{Failed: o.failed}
+}
@@ -0,0 +1,47 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipContinueOnError(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "partial")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	// a dangling link cannot be read
+	broken := filepath.Join(src, "dir", "broken")
+	if err := os.Symlink("missing", broken); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Zip(src, WithOutput(filepath.Join(t.TempDir(), "fail.zip"))); err == nil {
+		t.Fatal("expected an error without WithContinueOnError")
+	}
+
+	for _, format := range []Format{FormatZip, FormatTarGz} {
+		zipPath, err := Zip(src, WithContinueOnError(), WithFormat(format), WithOutput(filepath.Join(t.TempDir(), "out"+format.ext())))
+		var partial *PartialError
+		if !errors.As(err, &partial) {
+			t.Fatalf("%s: expected a PartialError, got %v", format, err)
+		}
+		if len(partial.Failed) != 1 || partial.Failed[0].Entry != broken || !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected %s to fail, got %v", format, broken, err)
+		}
+		if zipPath == "" {
+			t.Fatalf("%s: expected the archive's path", format)
+		}
+
+		if format == FormatZip {
+			files := zipptest.ReadArchive(t, zipPath)
+			if len(files) != 2 || string(files["dir/b.txt"]) != "beta" {
+				t.Errorf("unexpected contents: %q", files)
+			}
+		}
+	}
+}
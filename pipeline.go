@@ -0,0 +1,370 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+// filesPerWorker is the number of descriptors a compression worker may
+// hold at once: the source file and a spill file.
+const filesPerWorker = 2
+
+// zipVersion20 is the format version needed for deflate and directories.
+const zipVersion20 = 20
+
+// errAborted marks entries never compressed because an earlier one failed.
+var errAborted = errors.New("aborted")
+
+// compressed is an entry compressed ahead of being written to the archive.
+type compressed struct {
+	hdr    *zip.FileHeader
+	data   *spillBuffer
+	sha256 string // hex digest of the contents, if requested
+	err    error
+
+	// the data regions of a sparse file
+	sparse []SparseRegion
+}
+
+// manifestEntry describes the entry for an embedded manifest.
+func (c compressed) manifestEntry() ManifestEntry {
+	e := manifestEntry(c.hdr, c.sha256)
+	e.Sparse = c.sparse
+	return e
+}
+
+// workers returns how many files may be compressed concurrently, up to
+// the configured maximum or GOMAXPROCS, keeping open descriptors within
+// the configured or detected budget.
+func (o *options) workers() int {
+	budget := o.maxOpenFiles
+	if budget <= 0 {
+		// leave half the process limit to the rest of the program
+		budget = openFileLimit() / 2
+	}
+
+	n := runtime.GOMAXPROCS(0)
+	if o.maxWorkers > 0 {
+		n = o.maxWorkers
+	}
+	if budget > 0 && budget/filesPerWorker < n {
+		n = budget / filesPerWorker
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// writeEntries compresses files concurrently and writes them to zipw in
+// their original order. At most o.workers() entries are in flight, whether
+// being compressed or waiting to be written. Files whose contents are in
+// seen, if not nil, are recorded as duplicates instead of written, and
+// done, if not nil, is called after each entry is written. It returns a
+// manifest entry for each file.
+func writeEntries(zipw *zip.Writer, root string, files []string, seen dedup, done func(compressed) error, o *options) ([]ManifestEntry, error) {
+	results := make([]chan compressed, len(files))
+	for i := range results {
+		results[i] = make(chan compressed, 1)
+	}
+
+	sem := make(chan struct{}, o.workers())
+	stop := make(chan struct{})
+
+	go func() {
+		for i, file := range files {
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				for ; i < len(files); i++ {
+					results[i] <- compressed{err: errAborted}
+				}
+				return
+			}
+
+			go func(i int, file string) {
+				results[i] <- compressFileTimed(root, file, o)
+			}(i, file)
+		}
+	}()
+
+	// drain every result, even after a failure, so spill files are removed
+	var err error
+	written := make([]ManifestEntry, 0, len(files))
+	for i := range results {
+		c := <-results[i]
+		if c.err != errAborted {
+			<-sem
+		}
+
+		if err == nil {
+			var original string
+			if c.err != nil {
+				err = o.unreadable(files[i], c.err)
+			} else if original = seen.original(c); original == "" {
+				err = writeCompressed(zipw, c, o)
+				if err == nil && done != nil {
+					err = done(c)
+				}
+			}
+			if err != nil {
+				close(stop)
+			} else if c.err == nil {
+				entry := c.manifestEntry()
+				entry.Groups = o.groupsFor(entry.Name)
+				info := headerInfo(c.hdr)
+				if original != "" {
+					entry.CompressedSize = 0
+					entry.Duplicate = original
+					info.CompressedSize = 0
+				}
+				written = append(written, entry)
+				o.fileAdded(info, true)
+				o.entryDone(info)
+			}
+		}
+
+		if c.data != nil {
+			c.data.Release()
+		}
+	}
+
+	return written, err
+}
+
+// compressFile compresses a single file into memory or a spill file.
+func compressFile(root, file string, o *options) compressed {
+	f, err := os.Open(file)
+	if err != nil {
+		return compressed{err: err}
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return compressed{err: err}
+	}
+
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return compressed{err: err}
+	}
+	if info.Mode()&fs.ModeNamedPipe != 0 {
+		// read with SpecialRead
+		hdr.SetMode(info.Mode().Perm())
+	}
+
+	name, err := o.entryName(root, file)
+	if err != nil {
+		return compressed{err: err}
+	}
+
+	hdr.Name = name
+	hdr.Modified = o.modTime(info.ModTime())
+	hdr.Extra = append(hdr.Extra, ntfsExtra(o.entryTimes(info, hdr.Modified))...)
+	if o.ownership {
+		if u, ok := fileOwner(info); ok {
+			hdr.Extra = append(hdr.Extra, unixExtra(u)...)
+		}
+	}
+	if o.reproducible {
+		hdr.SetMode(normalizeMode(hdr.Mode()))
+	}
+
+	var regions []SparseRegion
+	if o.sparse {
+		regions = sparseRegions(f, info.Size())
+	}
+
+	method := o.methodFor(name)
+	c, err := encodeFile(f, hdr, method, regions, false, o)
+	if err != nil {
+		return compressed{err: err}
+	}
+
+	if o.method == MethodAuto && method == zip.Deflate {
+		o.stats.observe(extension(name), hdr.UncompressedSize64, hdr.CompressedSize64)
+
+		// data that did not shrink is stored instead, read again without
+		// being counted twice
+		if hdr.CompressedSize64 >= hdr.UncompressedSize64 {
+			c.data.Release()
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return compressed{err: err}
+			}
+			if c, err = encodeFile(f, hdr, zip.Store, regions, true, o); err != nil {
+				return compressed{err: err}
+			}
+		}
+	}
+
+	return c
+}
+
+// encodeFile compresses the file f with method, reading only the data
+// regions of a sparse file. Its reads count toward progress, metrics and
+// the bandwidth limit unless reread says f was read in full before.
+func encodeFile(f *os.File, hdr *zip.FileHeader, method uint16, regions []SparseRegion, reread bool, o *options) (compressed, error) {
+	var r io.Reader = f
+	if regions != nil {
+		info, err := f.Stat()
+		if err != nil {
+			return compressed{}, err
+		}
+		r = newSparseReader(f, regions, info.Size())
+	}
+	if !reread {
+		r = o.source(r)
+	}
+
+	c, err := encodeEntry(r, hdr, method, o.manifest, o)
+	if err != nil {
+		return compressed{}, err
+	}
+	c.sparse = regions
+	return c, nil
+}
+
+// encodeEntry compresses r with method into a new spill buffer, filling in
+// the method, checksum and sizes of hdr.
+func encodeEntry(r io.Reader, hdr *zip.FileHeader, method uint16, withHash bool, o *options) (compressed, error) {
+	buf := newSpillBuffer(spillThreshold)
+
+	enc, err := compressor(buf, method, o.level)
+	if err != nil {
+		buf.Release()
+		return compressed{}, err
+	}
+
+	crc := crc32.NewIEEE()
+	w := io.MultiWriter(enc, crc)
+
+	var sum hash.Hash
+	if withHash {
+		sum = sha256.New()
+		w = io.MultiWriter(w, sum)
+	}
+
+	n, err := o.copy(w, r)
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		buf.Release()
+		return compressed{}, err
+	}
+
+	hdr.Method = method
+	hdr.CRC32 = crc.Sum32()
+	hdr.UncompressedSize64 = uint64(n)
+	hdr.CompressedSize64 = uint64(buf.Len())
+
+	c := compressed{hdr: hdr, data: buf}
+	if sum != nil {
+		c.sha256 = hex.EncodeToString(sum.Sum(nil))
+	}
+	return c, nil
+}
+
+// nopWriteCloser adds a no-op Close to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// entryName returns the slash-separated archive name for file below root.
+func entryName(root, file string) (string, error) {
+	relPath, err := filepath.Rel(root, file)
+	if err != nil {
+		return "", err
+	}
+
+	// a single file is relative to itself, store it by name
+	if relPath == "." {
+		relPath = filepath.Base(file)
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
+
+// writeCompressed copies a compressed entry into the archive as is.
+func writeCompressed(zipw *zip.Writer, c compressed, o *options) error {
+	prepareRawHeader(c.hdr)
+	if o.password != "" {
+		return writeEncrypted(zipw, c, o)
+	}
+
+	w, err := zipw.CreateRaw(c.hdr)
+	if err != nil {
+		return err
+	}
+
+	r, err := c.data.Reader()
+	if err != nil {
+		return err
+	}
+
+	_, err = o.copy(w, r)
+	return err
+}
+
+// prepareRawHeader fills in what zip.Writer.CreateHeader would, but
+// CreateRaw does not: the version fields, the UTF-8 flag, the MS-DOS time
+// fields and the extended timestamp field.
+func prepareRawHeader(fh *zip.FileHeader) {
+	fh.CreatorVersion = fh.CreatorVersion&0xff00 | zipVersion20
+	fh.ReaderVersion = zipVersion20
+
+	if !isASCII(fh.Name) && utf8.ValidString(fh.Name) {
+		fh.Flags |= 0x800
+	}
+
+	if fh.Modified.IsZero() {
+		return
+	}
+
+	fh.ModifiedDate, fh.ModifiedTime = msDosTime(fh.Modified)
+
+	// extended timestamp: tag, size, flags (mtime present), mtime
+	var ext [9]byte
+	binary.LittleEndian.PutUint16(ext[0:], 0x5455)
+	binary.LittleEndian.PutUint16(ext[2:], 5)
+	ext[4] = 1
+	binary.LittleEndian.PutUint32(ext[5:], uint32(fh.Modified.Unix()))
+	fh.Extra = append(fh.Extra, ext[:]...)
+}
+
+// msDosTime converts t to MS-DOS date and time fields in t's location.
+// Times before 1980 cannot be represented and are clamped.
+func msDosTime(t time.Time) (date, tm uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	tm = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, tm
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package zipper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestWorkers(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+
+	tests := []struct {
+		name         string
+		maxOpenFiles int
+		maxWorkers   int
+		want         int
+	}{
+		{name: "single descriptor still makes progress", maxOpenFiles: 1, want: 1},
+		{name: "budget bounds workers", maxOpenFiles: 4, want: min(2, procs)},
+		{name: "large budget bounded by procs", maxOpenFiles: 1 << 16, want: procs},
+		{name: "workers bounded", maxOpenFiles: 1 << 16, maxWorkers: 1, want: 1},
+		{name: "workers beyond procs", maxOpenFiles: 1 << 16, maxWorkers: procs + 3, want: procs + 3},
+		{name: "budget bounds configured workers", maxOpenFiles: 4, maxWorkers: 8, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions([]Option{WithMaxOpenFiles(tt.maxOpenFiles), WithMaxWorkers(tt.maxWorkers)})
+			if got := o.workers(); got != tt.want {
+				t.Errorf("expected %d workers, got %d", tt.want, got)
+			}
+		})
+	}
+
+	// the detected default must always allow at least one worker
+	if got := newOptions(nil).workers(); got < 1 {
+		t.Errorf("expected at least one worker by default, got %d", got)
+	}
+}
+
+func TestZipParallel(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 64; i++ {
+		files[fmt.Sprintf("dir%d/file%d.txt", i%5, i)] = strings.Repeat(fmt.Sprintf("line %d\n", i), i*50)
+	}
+
+	for _, maxOpenFiles := range []int{2, 8, 0} {
+		t.Run(fmt.Sprintf("max open files %d", maxOpenFiles), func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "src")
+			writeTree(t, src, files)
+
+			zipPath, err := Zip(src, WithMaxOpenFiles(maxOpenFiles))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(zipPath)
+
+			zipptest.AssertRoundTrip(t, zipPath, src)
+		})
+	}
+}
+
+func TestSpillBuffer(t *testing.T) {
+	b := newSpillBuffer(8)
+	defer b.Release()
+
+	if _, err := b.Write([]byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	if b.file != nil {
+		t.Fatal("expected data to stay in memory below the limit")
+	}
+
+	if _, err := b.Write([]byte("56789")); err != nil {
+		t.Fatal(err)
+	}
+	if b.file == nil {
+		t.Fatal("expected data to spill to disk above the limit")
+	}
+	spillPath := b.file.Name()
+
+	r, err := b.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "123456789" || b.Len() != 9 {
+		t.Errorf("expected %q, got %q (len %d)", "123456789", data, b.Len())
+	}
+
+	b.Release()
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Error("expected spill file to be removed on release")
+	}
+}
+
+func TestMsDosTime(t *testing.T) {
+	date, tm := msDosTime(time.Date(2024, 3, 15, 22, 30, 14, 0, time.UTC))
+	if want := uint16(15 | 3<<5 | 44<<9); date != want {
+		t.Errorf("expected date %#x, got %#x", want, date)
+	}
+	if want := uint16(7 | 30<<5 | 22<<11); tm != want {
+		t.Errorf("expected time %#x, got %#x", want, tm)
+	}
+
+	// dates before the MS-DOS epoch are clamped
+	date, tm = msDosTime(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	if date != 1|1<<5 || tm != 0 {
+		t.Errorf("expected 1980-01-01, got date %#x time %#x", date, tm)
+	}
+}
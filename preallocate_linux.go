@@ -0,0 +1,21 @@
+//go:build linux
+
+package zipper
+
+import (
+	"os"
+	"syscall"
+)
+
+// preallocate reserves size bytes of disk for f without changing its
+// length, so the file is laid out contiguously where possible and a full
+// disk is found before it is written.
+func preallocate(f *os.File, size int64) error {
+	const keepSize = 0x1 // FALLOC_FL_KEEP_SIZE
+	for {
+		err := syscall.Fallocate(int(f.Fd()), keepSize, 0, size)
+		if err != syscall.EINTR {
+			return os.NewSyscallError("fallocate", err)
+		}
+	}
+}
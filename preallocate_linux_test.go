@@ -0,0 +1,53 @@
+//go:build linux
+
+package zipper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPreallocate(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	const size = 1 << 20
+	if err := preallocate(f, size); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("the file system cannot preallocate")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("expected the length kept, got %d", info.Size())
+	}
+	if blocks := info.Sys().(*syscall.Stat_t).Blocks; blocks*512 < size {
+		t.Errorf("expected %d bytes allocated, got %d", size, blocks*512)
+	}
+}
+
+func TestUnzipPreallocated(t *testing.T) {
+	data := randomString(t, 256<<10)
+	zipPath := zipTree(t, map[string]string{"big.bin": data, "empty.txt": ""})
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, want := range map[string]string{"big.bin": data, "empty.txt": ""} {
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s: expected %d bytes, got %d (%v)", name, len(want), len(got), err)
+		}
+	}
+}
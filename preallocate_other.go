@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+
+package zipper
+
+import (
+	"errors"
+	"os"
+)
+
+// preallocate cannot reserve disk space on this platform.
+func preallocate(f *os.File, size int64) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package zipper
+
+import "os"
+
+// preallocate extends f to size bytes, which SetEndOfFile allocates
+// before it is written, so a full disk is found early.
+func preallocate(f *os.File, size int64) error {
+	return f.Truncate(size)
+}
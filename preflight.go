@@ -0,0 +1,195 @@
+package zipper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PreflightReport describes what extracting a manifest's entries into a
+// directory would run into, without writing anything.
+type PreflightReport struct {
+	// Conflicts lists destination paths that already exist.
+	Conflicts []string
+
+	// RequiredBytes is the total size of the files to be extracted.
+	RequiredBytes uint64
+
+	// AvailableBytes is the free space on the destination file system,
+	// or 0 if it could not be determined.
+	AvailableBytes uint64
+
+	// Problems lists everything that would make extraction fail, such as
+	// unsafe entry names, unwritable directories or a lack of space.
+	Problems []PreflightProblem
+}
+
+// PreflightProblem is a single issue found by PreflightExtract.
+type PreflightProblem struct {
+	// Path is the entry name or destination path concerned.
+	Path string
+
+	// Err describes the problem.
+	Err error
+}
+
+// OK reports whether extraction is expected to succeed without replacing
+// any existing file.
+func (r *PreflightReport) OK() bool {
+	return len(r.Conflicts) == 0 && len(r.Problems) == 0
+}
+
+// ReadManifest parses a manifest written by ExportManifest in either
+// format, detecting which from its contents.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	br := bufio.NewReader(r)
+	for {
+		c, _, err := br.ReadRune()
+		if err != nil {
+			return nil, err
+		}
+		// skip leading whitespace and any byte order mark
+		if unicode.IsSpace(c) || c == '\ufeff' {
+			continue
+		}
+		if err := br.UnreadRune(); err != nil {
+			return nil, err
+		}
+		if c == '{' {
+			var m Manifest
+			if err := json.NewDecoder(br).Decode(&m); err != nil {
+				return nil, err
+			}
+			return &m, nil
+		}
+		return readManifestCSV(br)
+	}
+}
+
+func readManifestCSV(r io.Reader) (*Manifest, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("invalid manifest: unexpected CSV header")
+	}
+
+	m := &Manifest{Entries: make([]ManifestEntry, 0, len(rows)-1)}
+	for i, row := range rows[1:] {
+		e, err := parseManifestRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: row %d: %w", i+2, err)
+		}
+		m.Entries = append(m.Entries, e)
+	}
+
+	return m, nil
+}
+
+func parseManifestRow(row []string) (ManifestEntry, error) {
+	size, err := strconv.ParseUint(row[1], 10, 64)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	compressed, err := strconv.ParseUint(row[2], 10, 64)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	crc, err := strconv.ParseUint(row[3], 16, 32)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	modified, err := time.Parse(time.RFC3339, row[6])
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return ManifestEntry{
+		Name:           row[0],
+		Size:           size,
+		CompressedSize: compressed,
+		CRC32:          uint32(crc),
+		SHA256:         row[4],
+		Mode:           row[5],
+		Modified:       modified,
+	}, nil
+}
+
+// PreflightExtract checks, ahead of extraction, whether the entries of a
+// previously exported manifest could be extracted into destDir: which
+// files already exist, how much space is needed and available, and which
+// directories could not be written to. Nothing is written to disk.
+func PreflightExtract(m *Manifest, destDir string) (*PreflightReport, error) {
+	dest := filepath.Clean(destDir)
+	report := &PreflightReport{}
+	checked := make(map[string]bool)
+
+	for _, e := range m.Entries {
+		name, err := sanitizeName(e.Name)
+		if err != nil {
+			report.Problems = append(report.Problems, PreflightProblem{Path: e.Name, Err: err})
+			continue
+		}
+
+		path := filepath.Join(dest, filepath.FromSlash(name))
+		isDir := strings.HasSuffix(e.Name, "/") || strings.HasPrefix(e.Mode, "d")
+
+		info, err := os.Lstat(path)
+		switch {
+		case err == nil && !(isDir && info.IsDir()):
+			report.Conflicts = append(report.Conflicts, path)
+		case err != nil && !os.IsNotExist(err):
+			report.Problems = append(report.Problems, PreflightProblem{Path: path, Err: err})
+		}
+
+		if !isDir {
+			report.RequiredBytes += e.Size
+		}
+
+		// the closest existing ancestor is where new files get created
+		dir := existingAncestor(filepath.Dir(path))
+		if !checked[dir] {
+			checked[dir] = true
+			if !writable(dir) {
+				report.Problems = append(report.Problems, PreflightProblem{
+					Path: dir,
+					Err:  fmt.Errorf("directory is not writable"),
+				})
+			}
+		}
+	}
+
+	report.AvailableBytes = freeSpace(existingAncestor(dest))
+	if report.AvailableBytes > 0 && report.RequiredBytes > report.AvailableBytes {
+		report.Problems = append(report.Problems, PreflightProblem{
+			Path: dest,
+			Err:  fmt.Errorf("insufficient space: need %d bytes, %d available", report.RequiredBytes, report.AvailableBytes),
+		})
+	}
+
+	return report, nil
+}
+
+// existingAncestor returns path or the closest of its ancestors that
+// exists.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
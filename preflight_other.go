@@ -0,0 +1,17 @@
+//go:build !unix
+
+package zipper
+
+import "os"
+
+// writable reports whether the current user may create files in dir, by
+// creating and removing an empty probe file.
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".zipper-probe-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
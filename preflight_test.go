@@ -0,0 +1,96 @@
+package zipper
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadManifestRoundTrip(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	for _, format := range []ManifestFormat{ManifestJSON, ManifestCSV} {
+		var buf bytes.Buffer
+		if err := ExportManifest(zipPath, &buf, format); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		m, err := ReadManifest(&buf)
+		if err != nil {
+			t.Fatalf("format %d: unexpected error: %v", format, err)
+		}
+
+		if len(m.Entries) != 2 {
+			t.Fatalf("format %d: expected 2 entries, got %d", format, len(m.Entries))
+		}
+		for _, e := range m.Entries {
+			if e.SHA256 == "" || e.Size == 0 || e.Modified.IsZero() {
+				t.Errorf("format %d: incomplete entry %+v", format, e)
+			}
+		}
+	}
+}
+
+func TestReadManifestInvalid(t *testing.T) {
+	if _, err := ReadManifest(strings.NewReader("not,a,manifest\n")); err == nil {
+		t.Error("expected error for unknown CSV header")
+	}
+}
+
+func TestPreflightExtract(t *testing.T) {
+	m := &Manifest{Entries: []ManifestEntry{
+		{Name: "docs/", Mode: "drwxr-xr-x"},
+		{Name: "docs/readme.md", Size: 100, Mode: "-rw-r--r--"},
+		{Name: "config.yaml", Size: 50, Mode: "-rw-r--r--"},
+		{Name: "../escape.txt", Size: 1, Mode: "-rw-r--r--"},
+	}}
+
+	dest := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dest, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "config.yaml"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := PreflightExtract(m, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// an existing directory is not a conflict for a directory entry
+	if len(report.Conflicts) != 1 || report.Conflicts[0] != filepath.Join(dest, "config.yaml") {
+		t.Errorf("expected config.yaml conflict, got %v", report.Conflicts)
+	}
+
+	if report.RequiredBytes != 150 {
+		t.Errorf("expected 150 required bytes, got %d", report.RequiredBytes)
+	}
+
+	if len(report.Problems) != 1 || report.Problems[0].Path != "../escape.txt" {
+		t.Errorf("expected unsafe name to be reported, got %v", report.Problems)
+	}
+
+	if report.OK() {
+		t.Error("expected report with conflicts not to be OK")
+	}
+}
+
+func TestPreflightExtractInsufficientSpace(t *testing.T) {
+	dest := t.TempDir()
+	if freeSpace(dest) == 0 {
+		t.Skip("free space cannot be determined on this platform")
+	}
+
+	m := &Manifest{Entries: []ManifestEntry{{Name: "huge.img", Size: 1 << 62}}}
+	report, err := PreflightExtract(m, filepath.Join(dest, "missing", "subdir"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.OK() || len(report.Problems) != 1 {
+		t.Errorf("expected a single space problem, got %v", report.Problems)
+	}
+}
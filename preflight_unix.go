@@ -0,0 +1,11 @@
+//go:build unix
+
+package zipper
+
+import "syscall"
+
+// writable reports whether the current user may create files in dir.
+func writable(dir string) bool {
+	const wOK = 0x2
+	return syscall.Access(dir, wOK) == nil
+}
@@ -0,0 +1,171 @@
+package zipper
+
+import (
+	"archive/zip"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often WithProgress reports at most.
+const progressInterval = 100 * time.Millisecond
+
+// Progress reports how far Zip, Unzip or Extract has got.
+type Progress struct {
+	// Bytes is the amount of file data handled so far: read from the
+	// files being archived, or written to the files being extracted,
+	// counting those of entries skipped, for example as up to date.
+	Bytes int64
+
+	// Total is the amount expected in all, estimated from file sizes
+	// before starting, or -1 if it is not known, as when extracting a
+	// tarball.
+	Total int64
+
+	// Elapsed is the time since the operation started.
+	Elapsed time.Duration
+}
+
+// WithOnEntry makes Zip call done after writing each entry to the
+// archive, and Unzip and Extract after extracting each file, directory
+// or symlink, in order unless WithMaxWorkers has them write files in
+// parallel, for example to list them as they go. Entries
+// resumed by WithResume are not reported again.
+//
+// Tarballs and gzip files record no compressed size per entry, so
+// CompressedSize is -1 for their entries, as is Size for a compressed
+// stream whose size is not known up front.
+func WithOnEntry(done func(EntryInfo)) Option {
+	return func(o *options) {
+		o.onEntry = done
+	}
+}
+
+// WithProgress makes Zip, Unzip and Extract call report as file data is
+// read or written, at most every 100ms and once more when Bytes reaches
+// Total, for example to draw a progress bar. Zip reads several files at
+// once, but report is called by one goroutine at a time.
+func WithProgress(report func(Progress)) Option {
+	return func(o *options) {
+		o.progress = &tracker{report: report, start: time.Now(), total: -1}
+	}
+}
+
+// tracker counts the bytes handled for WithProgress. Its methods do
+// nothing on a nil tracker.
+type tracker struct {
+	report func(Progress)
+	start  time.Time
+
+	mu    sync.Mutex
+	last  time.Time
+	bytes int64
+	total int64
+}
+
+// setTotal sets the amount of data expected.
+func (t *tracker) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.total = total
+	t.mu.Unlock()
+}
+
+// expectFiles sets the amount of data expected to the size of files.
+func (t *tracker) expectFiles(files []string) {
+	if t == nil {
+		return
+	}
+
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	t.setTotal(total)
+}
+
+// add counts n bytes handled, reporting if it is time to.
+func (t *tracker) add(n int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.bytes += n
+	now := time.Now()
+	if now.Sub(t.last) < progressInterval && t.bytes != t.total {
+		return
+	}
+	t.last = now
+	t.report(Progress{Bytes: t.bytes, Total: t.total, Elapsed: now.Sub(t.start)})
+}
+
+// trackReader returns r counting the bytes read for WithProgress, if it
+// is given.
+func (o *options) trackReader(r io.Reader) io.Reader {
+	if o.progress == nil {
+		return r
+	}
+	return &trackedReader{r: r, t: o.progress}
+}
+
+// source returns the reader of a file being archived, paced and tracked
+// as the options require.
+func (o *options) source(r io.Reader) io.Reader {
+	return o.meterReader(o.trackReader(o.throttleReader(r)))
+}
+
+// trackedReader counts the bytes read through it.
+type trackedReader struct {
+	r io.Reader
+	t *tracker
+}
+
+func (r *trackedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.add(int64(n))
+	}
+	return n, err
+}
+
+// entryDone reports an entry to the WithOnEntry callback and logger, if
+// any.
+func (o *options) entryDone(info EntryInfo) {
+	o.log(slog.LevelDebug, "entry", "name", info.Name, "size", info.Size, "compressed", info.CompressedSize)
+	if o.onEntry != nil {
+		o.onEntry(info)
+	}
+}
+
+// headerInfo describes the zip entry with header hdr.
+func headerInfo(hdr *zip.FileHeader) EntryInfo {
+	return EntryInfo{
+		Name:           hdr.Name,
+		Size:           int64(hdr.UncompressedSize64),
+		CompressedSize: int64(hdr.CompressedSize64),
+		Mode:           hdr.Mode(),
+		Modified:       hdr.Modified,
+		Method:         hdr.Method,
+	}
+}
+
+// info describes the entry for WithOnEntry.
+func (f entry) info() EntryInfo {
+	return EntryInfo{
+		Name:           f.name,
+		Size:           f.size,
+		CompressedSize: f.compressed,
+		Mode:           f.mode,
+		Modified:       f.modified,
+	}
+}
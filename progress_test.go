@@ -0,0 +1,122 @@
+package zipper
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOnEntry(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "reported")
+	writeTree(t, src, map[string]string{
+		"a.txt":     "alpha",
+		"dir/b.txt": "beta",
+		"dup.txt":   "alpha",
+	})
+
+	var zipped []EntryInfo
+	zipPath := zipAside(t, src, WithDedup(), WithOnEntry(func(info EntryInfo) {
+		zipped = append(zipped, info)
+	}))
+
+	if len(zipped) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", zipped)
+	}
+	for _, info := range zipped {
+		if info.Method != zip.Deflate || info.Size == 0 {
+			t.Errorf("unexpected entry %+v", info)
+		}
+		if info.Name == "dup.txt" && info.CompressedSize != 0 {
+			t.Errorf("expected a duplicate to take no space, got %+v", info)
+		}
+	}
+
+	var extracted []string
+	if err := Unzip(zipPath, t.TempDir(), WithOnEntry(func(info EntryInfo) {
+		extracted = append(extracted, info.Name)
+	})); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(extracted)
+	if len(extracted) != 3 || extracted[0] != "a.txt" || extracted[1] != "dir/b.txt" || extracted[2] != "dup.txt" {
+		t.Errorf("unexpected extracted entries %v", extracted)
+	}
+}
+
+func TestOnEntryTarball(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "reported")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	var zipped []EntryInfo
+	zipAside(t, src, WithFormat(FormatTarGz), WithOnEntry(func(info EntryInfo) {
+		zipped = append(zipped, info)
+	}))
+	if len(zipped) != 1 || zipped[0].Name != "a.txt" || zipped[0].Size != 5 || zipped[0].CompressedSize != -1 {
+		t.Errorf("unexpected entries %+v", zipped)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "progress")
+	writeTree(t, src, map[string]string{
+		"a.txt":     strings.Repeat("a", 1000),
+		"dir/b.txt": strings.Repeat("b", 2000),
+		"c.txt":     strings.Repeat("c", 3000),
+	})
+
+	var reports []Progress
+	zipPath := zipAside(t, src, WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	}))
+
+	if len(reports) == 0 {
+		t.Fatal("expected progress reports")
+	}
+	last := reports[len(reports)-1]
+	if last.Bytes != 6000 || last.Total != 6000 {
+		t.Errorf("expected to finish at 6000 of 6000 bytes, got %+v", last)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Bytes < reports[i-1].Bytes {
+			t.Errorf("progress went backwards: %+v", reports)
+		}
+	}
+
+	reports = nil
+	if err := Unzip(zipPath, t.TempDir(), WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) == 0 || reports[len(reports)-1] != (Progress{Bytes: 6000, Total: 6000, Elapsed: reports[len(reports)-1].Elapsed}) {
+		t.Errorf("expected to finish at 6000 of 6000 bytes, got %+v", reports)
+	}
+}
+
+func TestProgressStoredAfterDeflate(t *testing.T) {
+	// deflate does not shrink random data, which is then stored
+	data := make([]byte, 100000)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "random")
+	writeTree(t, src, map[string]string{"noise.txt": string(data)})
+
+	o := newOptions([]Option{WithMethod(MethodAuto), WithProgress(func(Progress) {})})
+	o.progress.setTotal(int64(len(data)))
+	c := compressFile(src, filepath.Join(src, "noise.txt"), o)
+	if c.err != nil {
+		t.Fatal(c.err)
+	}
+	defer c.data.Release()
+
+	if c.hdr.Method != zip.Store {
+		t.Errorf("expected the file stored, got method %d", c.hdr.Method)
+	}
+	if o.progress.bytes != o.progress.total {
+		t.Errorf("expected to finish at %d of %d bytes, got %d", o.progress.total, o.progress.total, o.progress.bytes)
+	}
+}
@@ -0,0 +1,122 @@
+package zipper
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+)
+
+// largestEntries is how many entries a CompressionReport lists as the
+// largest.
+const largestEntries = 10
+
+// CompressionReport summarizes how well the entries of an archive
+// compressed.
+type CompressionReport struct {
+	Entries      int
+	Uncompressed uint64
+	Compressed   uint64
+
+	// Extensions and Directories total the entries by lowercased
+	// extension and by the directory holding them, "." for the archive's
+	// root, largest first. Samples counts the entries of an extension.
+	Extensions  []ExtensionStats
+	Directories []DirectoryStats
+
+	// Largest lists the largest entries, largest first.
+	Largest []EntryInfo
+}
+
+// Ratio returns compressed size over uncompressed size, so lower is
+// better and 1 means no saving.
+func (r *CompressionReport) Ratio() float64 {
+	return ExtensionStats{Uncompressed: r.Uncompressed, Compressed: r.Compressed}.Ratio()
+}
+
+// Saved returns how many bytes compression saved, negative if the entries
+// grew.
+func (r *CompressionReport) Saved() int64 {
+	return int64(r.Uncompressed) - int64(r.Compressed)
+}
+
+// DirectoryStats is the compression of the entries directly in one
+// directory of an archive.
+type DirectoryStats struct {
+	Directory    string
+	Entries      int
+	Uncompressed uint64
+	Compressed   uint64
+}
+
+// Ratio returns compressed size over uncompressed size, as
+// ExtensionStats.Ratio does.
+func (s DirectoryStats) Ratio() float64 {
+	return ExtensionStats{Uncompressed: s.Uncompressed, Compressed: s.Compressed}.Ratio()
+}
+
+// CompressionStats reports how well the entries of the archive at zipPath
+// compressed, overall, by extension and by directory, and which entries
+// are largest, without extracting anything. Directory entries and the
+// embedded manifest are left out.
+func CompressionStats(zipPath string) (*CompressionReport, error) {
+	entries, err := List(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CompressionReport{}
+	exts := make(map[string]*ExtensionStats)
+	dirs := make(map[string]*DirectoryStats)
+	var files []EntryInfo
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name, "/") {
+			continue
+		}
+		files = append(files, e)
+
+		size, compressed := uint64(e.Size), uint64(e.CompressedSize)
+		report.Entries++
+		report.Uncompressed += size
+		report.Compressed += compressed
+
+		ext := extension(e.Name)
+		s, ok := exts[ext]
+		if !ok {
+			s = &ExtensionStats{Extension: ext}
+			exts[ext] = s
+		}
+		s.Samples++
+		s.Uncompressed += size
+		s.Compressed += compressed
+
+		dir := path.Dir(e.Name)
+		d, ok := dirs[dir]
+		if !ok {
+			d = &DirectoryStats{Directory: dir}
+			dirs[dir] = d
+		}
+		d.Entries++
+		d.Uncompressed += size
+		d.Compressed += compressed
+	}
+
+	for _, s := range exts {
+		report.Extensions = append(report.Extensions, *s)
+	}
+	slices.SortFunc(report.Extensions, func(a, b ExtensionStats) int {
+		return cmp.Or(cmp.Compare(b.Uncompressed, a.Uncompressed), strings.Compare(a.Extension, b.Extension))
+	})
+	for _, d := range dirs {
+		report.Directories = append(report.Directories, *d)
+	}
+	slices.SortFunc(report.Directories, func(a, b DirectoryStats) int {
+		return cmp.Or(cmp.Compare(b.Uncompressed, a.Uncompressed), strings.Compare(a.Directory, b.Directory))
+	})
+
+	slices.SortStableFunc(files, func(a, b EntryInfo) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+	report.Largest = files[:min(len(files), largestEntries)]
+	return report, nil
+}
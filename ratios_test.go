@@ -0,0 +1,36 @@
+package zipper
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompressionStats(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "stats")
+	writeTree(t, src, map[string]string{
+		"a.txt":       strings.Repeat("alpha ", 1000),
+		"docs/b.txt":  strings.Repeat("beta ", 100),
+		"docs/c.json": `{"c": 1}`,
+	})
+	zipPath := zipAside(t, src)
+
+	report, err := CompressionStats(zipPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Entries != 3 || report.Uncompressed != 6508 || report.Saved() <= 0 || report.Ratio() >= 1 {
+		t.Errorf("unexpected totals: %d entries, %d bytes, %d saved", report.Entries, report.Uncompressed, report.Saved())
+	}
+
+	if len(report.Extensions) != 2 || report.Extensions[0].Extension != ".txt" || report.Extensions[0].Samples != 2 {
+		t.Errorf("unexpected extensions: %+v", report.Extensions)
+	}
+	if len(report.Directories) != 2 || report.Directories[0].Directory != "." || report.Directories[1].Entries != 2 {
+		t.Errorf("unexpected directories: %+v", report.Directories)
+	}
+	if len(report.Largest) != 3 || report.Largest[0].Name != "a.txt" || report.Largest[2].Name != "docs/c.json" {
+		t.Errorf("unexpected largest entries: %+v", report.Largest)
+	}
+}
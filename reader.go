@@ -0,0 +1,100 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+)
+
+// Reader is an open zip archive, possibly split across volumes, for
+// callers doing more with it than a single List, Verify or Unzip, each of
+// which would open it again. The options given to OpenReader apply to
+// every call, WithPassword decrypting encrypted entries.
+type Reader struct {
+	o *options
+	a *archive
+}
+
+// OpenReader opens the zip archive at zipPath. The Reader must be closed
+// when done with.
+func OpenReader(zipPath string, opts ...Option) (*Reader, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	a, err := openArchive(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{o: o, a: a}, nil
+}
+
+// Close closes the archive.
+func (r *Reader) Close() error {
+	return r.a.Close()
+}
+
+// SetPassword sets the password decrypting entries, as WithPassword does,
+// such as once Entries shows some are encrypted.
+func (r *Reader) SetPassword(password string) {
+	r.o.password = password
+}
+
+// Comment returns the archive's comment.
+func (r *Reader) Comment() string {
+	return r.a.Comment
+}
+
+// Entries returns the entries of the archive in archive order, as List
+// does.
+func (r *Reader) Entries() []EntryInfo {
+	return listArchive(r.a.Reader, r.o)
+}
+
+// Open returns the contents of the entry called name, decrypted if need
+// be. Reading them to EOF checks their CRC-32. Several entries may be
+// open and read at once. Files stored once by WithDedup or WithHardLinks
+// are opened by any of their names.
+func (r *Reader) Open(name string) (io.ReadCloser, error) {
+	if f := r.file(name); f != nil {
+		return r.o.opener(f)()
+	}
+
+	// duplicates are read from the entry holding their contents
+	m, err := readEmbeddedManifest(r.a.Reader)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return nil, err
+	}
+	if m != nil {
+		for _, e := range m.Entries {
+			if e.Name != name || e.Duplicate == "" {
+				continue
+			}
+			if f := r.file(e.Duplicate); f != nil {
+				return r.o.opener(f)()
+			}
+		}
+	}
+	return nil, &PathError{Op: "open", Entry: name, Err: ErrNotFound}
+}
+
+// file returns the entry called name, or nil if there is none.
+func (r *Reader) file(name string) *zip.File {
+	for _, f := range r.a.File {
+		if f.Name == name && f.Name != ManifestName {
+			return f
+		}
+	}
+	return nil
+}
+
+// ExtractAll extracts the archive into the directory dest, as Unzip does.
+func (r *Reader) ExtractAll(dest string) error {
+	return unzipArchive(r.a.Reader, dest, r.o)
+}
+
+// Verify checks the integrity of the archive, as Verify does.
+func (r *Reader) Verify() error {
+	return verifyArchive(r.a, r.o)
+}
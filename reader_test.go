@@ -0,0 +1,76 @@
+package zipper
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestReader(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "secret")
+	writeTree(t, src, map[string]string{"a.txt": "attack at dawn", "dir/b.txt": "beta"})
+	zipPath := zipAside(t, src, WithPassword("hunter2"))
+
+	r, err := OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	entries := r.Entries()
+	if len(entries) != 2 || !entries[0].Encrypted {
+		t.Fatalf("expected 2 encrypted entries, got %+v", entries)
+	}
+	if _, err := r.Open("a.txt"); !errors.Is(err, ErrPassword) {
+		t.Errorf("expected ErrPassword without a password, got %v", err)
+	}
+
+	r.SetPassword("hunter2")
+	rc, err := r.Open("a.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "attack at dawn" {
+		t.Errorf("expected the contents, got %q, %v", data, err)
+	}
+
+	if _, err := r.Open("missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := r.Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := r.ExtractAll(dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
+
+func TestReaderDuplicates(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dedup")
+	writeTree(t, src, map[string]string{"a.txt": "shared", "dir/b.txt": "shared"})
+	zipPath := zipAside(t, src, WithDedup())
+
+	r, err := OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	rc, err := r.Open("dir/b.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "shared" {
+		t.Errorf("expected the contents, got %q, %v", data, err)
+	}
+}
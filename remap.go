@@ -0,0 +1,76 @@
+package zipper
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// remap moves the entries below the directory from to the directory to.
+type remap struct {
+	from, to string
+}
+
+// WithRemap makes extraction write the entries below the directory
+// oldPrefix, in the archive, below newPrefix instead, relocating them
+// without moving files afterwards, for example "build/" to "app/". An
+// empty newPrefix moves them up to the destination itself. It may be
+// given more than once, and the longest prefix matching an entry
+// applies. Include and exclude patterns match the names as they are in
+// the archive.
+func WithRemap(oldPrefix, newPrefix string) Option {
+	return func(o *options) {
+		o.remaps = append(o.remaps, remap{from: oldPrefix, to: newPrefix})
+	}
+}
+
+// WithRemapFunc makes extraction write each entry under the name remap
+// returns, given its sanitized slash-separated name after WithRemap, or
+// skip it if remap returns "". The name returned is sanitized in turn,
+// and so must stay below the destination.
+func WithRemapFunc(remap func(name string) string) Option {
+	return func(o *options) {
+		o.remapFunc = remap
+	}
+}
+
+// validateRemaps checks the prefixes given to WithRemap, cleaning them.
+func (o *options) validateRemaps() error {
+	for i, r := range o.remaps {
+		from, err := sanitizeName(r.from)
+		if err != nil || from == "." {
+			return fmt.Errorf("invalid remapped prefix %q", r.from)
+		}
+		to := "."
+		if r.to != "" {
+			if to, err = sanitizeName(r.to); err != nil {
+				return fmt.Errorf("invalid remapped prefix %q", r.to)
+			}
+		}
+		o.remaps[i] = remap{from: from, to: to}
+	}
+	return nil
+}
+
+// remapName returns the name the sanitized entry name is written under,
+// or "" if the entry is to be skipped.
+func (o *options) remapName(name string) (string, error) {
+	var best *remap
+	for i, r := range o.remaps {
+		if (name == r.from || strings.HasPrefix(name, r.from+"/")) && (best == nil || len(r.from) > len(best.from)) {
+			best = &o.remaps[i]
+		}
+	}
+	if best != nil {
+		name = path.Join(best.to, strings.TrimPrefix(name, best.from))
+	}
+
+	if o.remapFunc == nil {
+		return name, nil
+	}
+	mapped := o.remapFunc(name)
+	if mapped == "" {
+		return "", nil
+	}
+	return sanitizeName(mapped)
+}
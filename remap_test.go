@@ -0,0 +1,55 @@
+package zipper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestUnzipRemap(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "build/app.bin", body: "app"},
+		testEntry{name: "build/docs/readme.txt", body: "docs"},
+		testEntry{name: "builder/keep.txt", body: "keep"},
+		testEntry{name: "other.txt", body: "other"},
+	)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithRemap("build/", "app"), WithRemap("build/docs", "")); err != nil {
+		t.Fatal(err)
+	}
+	got := zipptest.ReadTree(t, dest)
+	want := map[string]string{"app/app.bin": "app", "readme.txt": "docs", "builder/keep.txt": "keep", "other.txt": "other"}
+	if len(got) != len(want) {
+		t.Errorf("expected %d files, got %q", len(want), got)
+	}
+	for name, body := range want {
+		if string(got[name]) != body {
+			t.Errorf("expected %s to hold %q, got %q", name, body, got[name])
+		}
+	}
+
+	dest = t.TempDir()
+	upper := func(name string) string {
+		if strings.HasPrefix(name, "app/") {
+			return ""
+		}
+		return strings.ToUpper(name)
+	}
+	if err := Unzip(zipPath, dest, WithRemap("build", "app"), WithRemapFunc(upper)); err != nil {
+		t.Fatal(err)
+	}
+	if names := listTree(t, dest); strings.Join(names, ",") != "BUILDER/KEEP.TXT,OTHER.TXT" {
+		t.Errorf("expected the remapped entries skipped and the rest renamed, got %v", names)
+	}
+
+	escape := func(string) string { return "../escaped.txt" }
+	if err := Unzip(zipPath, t.TempDir(), WithRemapFunc(escape)); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("expected a name escaping the destination to be refused, got %v", err)
+	}
+	if err := Unzip(zipPath, t.TempDir(), WithRemap("../build", "app")); err == nil {
+		t.Error("expected an invalid prefix to be refused")
+	}
+}
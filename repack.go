@@ -0,0 +1,317 @@
+package zipper
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// Repack rewrites the zip archive at src, copying the entries selected by
+// WithInclude and WithExclude as they are stored, without decompressing
+// and compressing them again, so even large archives are rewritten about
+// as fast as they can be read. It replaces src unless WithOutput says
+// otherwise, and returns the path written. Deleting entries is a Repack
+// with WithExclude:
+//
+//	zipper.Repack("backup.zip", zipper.WithExclude("logs/*"))
+//
+// Encrypted entries are copied still encrypted, without the password. An
+// embedded manifest is rewritten to describe the entries kept. Files
+// stored once by WithDedup or WithHardLinks keep their contents when the
+// entry holding them is left out, as it is then copied under the name of
+// the first duplicate kept.
+func Repack(src string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if err := validateRepack(o); err != nil {
+		return "", err
+	}
+
+	r, err := openArchive(src)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	dstPath := src
+	if o.output != "" {
+		dstPath = o.outputPath(filepath.Base(src))
+	}
+	if err := checkOutput(dstPath, o); err != nil {
+		return "", err
+	}
+
+	m, err := readEmbeddedManifest(r.Reader)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return "", err
+	}
+	kept, m := keptEntries(r.Reader, m, o.selectedEntry)
+
+	err = writeRepacked(dstPath, o, func(zipw *zip.Writer) error {
+		if err := copyEntries(zipw, kept, o); err != nil {
+			return err
+		}
+		if m == nil {
+			return nil
+		}
+		m.Archive = filepath.Base(dstPath)
+		return writeManifestEntry(zipw, m, o)
+	})
+	if err != nil {
+		return "", err
+	}
+	return dstPath, nil
+}
+
+// Append adds the files and directories at inPaths to the zip archive at
+// archive, naming and compressing them as ZipPaths does, while its
+// existing entries are copied across as Repack copies them. An entry
+// named the same as an added file is replaced by it. WithInclude and
+// WithExclude select the files added, as they do for Zip. An embedded
+// manifest is rewritten as by Repack, with the added files recorded.
+func Append(archive string, inPaths []string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := validateRepack(o); err != nil {
+		return err
+	}
+
+	r, err := openArchive(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	m, err := readEmbeddedManifest(r.Reader)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return err
+	}
+	// the added files are hashed for the manifest
+	o.manifest = m != nil
+
+	root, inputs, err := splitInputs(inPaths)
+	if err != nil {
+		return err
+	}
+	files, err := collectFiles(root, inputs, archive, o)
+	if err != nil {
+		return err
+	}
+
+	added := make(map[string]bool, len(files))
+	for _, file := range files {
+		name, err := o.entryName(root, file)
+		if err != nil {
+			return err
+		}
+		added[name] = true
+	}
+
+	kept, m := keptEntries(r.Reader, m, func(name string) bool {
+		return !added[name]
+	})
+
+	return writeRepacked(archive, o, func(zipw *zip.Writer) error {
+		if err := copyEntries(zipw, kept, o); err != nil {
+			return err
+		}
+		written, err := writeEntries(zipw, root, files, nil, nil, o)
+		if err != nil || m == nil {
+			return err
+		}
+		m.Archive = filepath.Base(archive)
+		m.Entries = append(m.Entries, written...)
+		return writeManifestEntry(zipw, m, o)
+	})
+}
+
+// validateRepack checks the options of Repack and Append, which write
+// plain zip archives.
+func validateRepack(o *options) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	if o.resume {
+		return errors.New("only Zip can be resumed")
+	}
+	if o.format != FormatZip || o.sfxStub != "" || o.manifest {
+		return errors.New("repacked archives are plain zip archives")
+	}
+	return nil
+}
+
+// writeRepacked writes a zip archive to dstPath, with the entries fill
+// writes, replacing it only once complete.
+func writeRepacked(dstPath string, o *options, fill func(zipw *zip.Writer) error) error {
+	out, err := createOutput(dstPath, o)
+	if err != nil {
+		return err
+	}
+
+	// discard partial output on failure
+	completed := false
+	defer func() {
+		if !completed {
+			out.Abort()
+		}
+	}()
+
+	bw := o.bufferedWriter(out)
+	defer releaseWriter(bw)
+	zipw := zip.NewWriter(bw)
+
+	if err := fill(zipw); err != nil {
+		return err
+	}
+	if err := zipw.Close(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	if err := out.Commit(); err != nil {
+		return err
+	}
+	completed = true
+	return nil
+}
+
+// keptEntry is an entry of an archive being rewritten, copied as it is
+// stored.
+type keptEntry struct {
+	f *zip.File
+
+	// dup is the duplicate the entry is copied as, in place of the file
+	// holding its contents; nil if copied as itself
+	dup *ManifestEntry
+}
+
+// keptEntries returns the entries of r that keep selects by name, and the
+// manifest m of r rewritten to describe them, or nil if r has none. The
+// records of the files left out are dropped. An entry holding the
+// contents of duplicates kept is copied as the first of them when left
+// out itself, and the others refer to that one instead. Likewise, the
+// first hard link kept to a file left out takes its place as the file the
+// others link to.
+func keptEntries(r *zip.Reader, m *Manifest, keep func(name string) bool) ([]keptEntry, *Manifest) {
+	var kept []keptEntry
+	stored := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+		stored[f.Name] = f
+		if keep(f.Name) {
+			kept = append(kept, keptEntry{f: f})
+		}
+	}
+	if m == nil {
+		return kept, nil
+	}
+
+	rewritten := *m
+	rewritten.Entries = make([]ManifestEntry, 0, len(m.Entries))
+	moved := make(map[string]string)  // holders left out, by the duplicate in their place
+	linked := make(map[string]string) // link targets left out, by the link in their place
+	for _, e := range m.Entries {
+		if !keep(e.Name) {
+			continue
+		}
+
+		if e.Duplicate != "" && !keep(e.Duplicate) {
+			if name, ok := moved[e.Duplicate]; ok {
+				e.Duplicate = name
+			} else if f, ok := stored[e.Duplicate]; ok {
+				moved[e.Duplicate] = e.Name
+				e.Duplicate = ""
+				e.CompressedSize = f.CompressedSize64
+				dup := e
+				kept = append(kept, keptEntry{f: f, dup: &dup})
+			}
+		}
+		if e.HardLink != "" && !keep(e.HardLink) {
+			name, ok := linked[e.HardLink]
+			if !ok {
+				linked[e.HardLink] = e.Name
+			}
+			e.HardLink = name
+		}
+		rewritten.Entries = append(rewritten.Entries, e)
+	}
+	return kept, &rewritten
+}
+
+// copyEntries copies the entries kept into zipw as they are stored.
+func copyEntries(zipw *zip.Writer, kept []keptEntry, o *options) error {
+	for _, k := range kept {
+		hdr := k.f.FileHeader
+		if k.dup != nil {
+			hdr.Name = k.dup.Name
+			if perm, ok := parsePerm(k.dup.Mode); ok {
+				hdr.SetMode(hdr.Mode()&^fs.ModePerm | perm)
+			}
+			// the times of the file holding the contents go with it
+			hdr.Extra = withoutExtra(withoutExtra(hdr.Extra, extTimeExtraID), ntfsExtraID)
+			hdr.Modified = k.dup.Modified
+			prepareRawHeader(&hdr)
+		}
+		if err := copyRaw(zipw, k.f, hdr, o); err != nil {
+			return &EntryError{Name: hdr.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+// copyEntry copies f into zipw under name, without decompressing it.
+func copyEntry(zipw *zip.Writer, f *zip.File, name string, o *options) error {
+	hdr := f.FileHeader
+	hdr.Name = name
+	return copyRaw(zipw, f, hdr, o)
+}
+
+// copyRaw copies the data of f into zipw as the entry hdr describes,
+// without decompressing it.
+func copyRaw(zipw *zip.Writer, f *zip.File, hdr zip.FileHeader, o *options) error {
+	// zip.Writer adds its own zip64 field to the entries that need it
+	hdr.Extra = withoutExtra(hdr.Extra, zip64ExtraID)
+
+	w, err := zipw.CreateRaw(&hdr)
+	if err != nil {
+		return err
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return err
+	}
+
+	n, err := o.copy(w, raw)
+	if err != nil {
+		return err
+	}
+	if uint64(n) != f.CompressedSize64 {
+		return fmt.Errorf("copied %d of %d bytes: %w", n, f.CompressedSize64, io.ErrUnexpectedEOF)
+	}
+
+	o.entryDone(headerInfo(&hdr))
+	return nil
+}
+
+// withoutExtra returns the extra block without the fields tagged id.
+func withoutExtra(extra []byte, id uint16) []byte {
+	var kept []byte
+	for len(extra) >= 4 {
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if binary.LittleEndian.Uint16(extra) != id {
+			kept = append(kept, extra[:4+size]...)
+		}
+		extra = extra[4+size:]
+	}
+	return kept
+}
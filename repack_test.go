@@ -0,0 +1,193 @@
+package zipper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestRepack(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "repack")
+	writeTree(t, src, map[string]string{
+		"a.txt":      strings.Repeat("alpha", 100),
+		"logs/b.log": "beta",
+		"dir/c.txt":  "gamma",
+	})
+	zipPath := zipAside(t, src, WithPassword("secret"))
+
+	got, err := Repack(zipPath, WithExclude("logs/*"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != zipPath {
+		t.Errorf("expected %s replaced, got %s", zipPath, got)
+	}
+
+	// entries are copied still encrypted
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithPassword("secret")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files := zipptest.ReadTree(t, dest)
+	if len(files) != 2 || string(files["dir/c.txt"]) != "gamma" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
+
+func TestRepackOutput(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "repack")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta"})
+	zipPath := zipAside(t, src, WithManifest())
+
+	out := filepath.Join(t.TempDir(), "copy.zip")
+	if _, err := Repack(zipPath, WithOutput(out)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the manifest is kept, and the source untouched
+	files := zipptest.ReadArchive(t, out)
+	if len(files) != 3 || files[ManifestName] == nil {
+		t.Errorf("unexpected contents: %q", files)
+	}
+	dest := t.TempDir()
+	if err := Unzip(out, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyManifest(out, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(zipptest.ReadArchive(t, zipPath)) != 3 {
+		t.Error("expected the source archive unchanged")
+	}
+}
+
+func TestRepackDedup(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dedup")
+	writeTree(t, src, map[string]string{
+		"a.txt":     "shared",
+		"b.txt":     "shared",
+		"dir/c.txt": "shared",
+		"d.txt":     "other",
+	})
+	zipPath := zipAside(t, src, WithDedup())
+
+	// a.txt holds the contents of the others
+	if _, err := Repack(zipPath, WithExclude("a.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names, m := readManifest(t, zipPath)
+	if want := []string{"d.txt", "b.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected entries %v, got %v", want, names)
+	}
+	for _, e := range m.Entries {
+		if e.Name == "a.txt" || e.Name == "dir/c.txt" && e.Duplicate != "b.txt" {
+			t.Errorf("unexpected manifest entry %+v", e)
+		}
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	want := map[string]string{"b.txt": "shared", "dir/c.txt": "shared", "d.txt": "other"}
+	if got := zipptest.ReadTree(t, dest); len(got) != len(want) || string(got["dir/c.txt"]) != "shared" {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRepackHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are only detected on Unix")
+	}
+
+	src := filepath.Join(t.TempDir(), "linked")
+	writeTree(t, src, map[string]string{"a.txt": "shared"})
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(src, name)); err != nil {
+			t.Skipf("cannot create hard links: %v", err)
+		}
+	}
+	zipPath := zipAside(t, src, WithHardLinks())
+
+	if _, err := Repack(zipPath, WithExclude("a.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := os.Stat(filepath.Join(dest, "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := os.Stat(filepath.Join(dest, "c.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(b, c) {
+		t.Error("expected the remaining links to share a file")
+	}
+	if got := zipptest.ReadTree(t, dest); len(got) != 2 || string(got["c.txt"]) != "shared" {
+		t.Errorf("unexpected contents: %q", got)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "base")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta"})
+	zipPath := zipAside(t, src)
+
+	more := filepath.Join(t.TempDir(), "more")
+	writeTree(t, more, map[string]string{"b.txt": "beta 2", "c.txt": "gamma"})
+	if err := Append(zipPath, []string{filepath.Join(more, "b.txt"), filepath.Join(more, "c.txt")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := zipptest.ReadArchive(t, zipPath)
+	want := map[string]string{"a.txt": "alpha", "b.txt": "beta 2", "c.txt": "gamma"}
+	if len(files) != len(want) {
+		t.Fatalf("expected %q, got %q", want, files)
+	}
+	for name, data := range want {
+		if string(files[name]) != data {
+			t.Errorf("%s: expected %q, got %q", name, data, files[name])
+		}
+	}
+	if _, err := os.Stat(zipPath + tempSuffix); !os.IsNotExist(err) {
+		t.Error("expected no temporary file left behind")
+	}
+}
+
+func TestAppendManifest(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "base")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta"})
+	zipPath := zipAside(t, src, WithManifest())
+
+	more := filepath.Join(t.TempDir(), "more")
+	writeTree(t, more, map[string]string{"b.txt": "beta 2", "c.txt": "gamma"})
+	if err := Append(zipPath, []string{filepath.Join(more, "b.txt"), filepath.Join(more, "c.txt")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, m := readManifest(t, zipPath)
+	if len(m.Entries) != 3 {
+		t.Errorf("expected every file recorded once, got %+v", m.Entries)
+	}
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
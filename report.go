@@ -0,0 +1,189 @@
+package zipper
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Feature names an archive feature that zipper cannot fully honor.
+type Feature string
+
+const (
+	// FeatureMethod is a compression method with no registered
+	// decompressor.
+	FeatureMethod Feature = "compression method"
+
+	// FeatureEncryption is an encrypted entry.
+	FeatureEncryption Feature = "encryption"
+
+	// FeatureExtraField is an extra field whose metadata is ignored.
+	FeatureExtraField Feature = "extra field"
+
+	// FeatureEntryType is a kind of entry that cannot be extracted, such
+	// as a device node in a tarball.
+	FeatureEntryType Feature = "entry type"
+
+	// FeatureSpecialBits is the setuid, setgid or sticky bit of a file,
+	// which extraction clears unless WithAllowSpecialBits is given.
+	FeatureSpecialBits Feature = "special mode bits"
+)
+
+// Unsupported records a feature of an entry that could not be honored.
+type Unsupported struct {
+	Entry   string
+	Feature Feature
+
+	// Detail describes the specific variant, e.g. "method 93".
+	Detail string
+
+	// Skipped is true if the entry was left out entirely, and false if
+	// only the feature was ignored.
+	Skipped bool
+}
+
+func (u Unsupported) String() string {
+	s := fmt.Sprintf("%s: unsupported %s (%s)", u.Entry, u.Feature, u.Detail)
+	if u.Skipped {
+		s += ", skipped"
+	}
+	return s
+}
+
+// Skipped records a file Zip left out of the archive by request.
+type Skipped struct {
+	Entry string
+	Size  int64
+
+	// Reason says which option left it out, e.g. "larger than 1024 bytes".
+	Reason string
+}
+
+func (s Skipped) String() string {
+	return fmt.Sprintf("%s: %s", s.Entry, s.Reason)
+}
+
+// Report collects what List and Unzip could not fully honor, and what Zip
+// left out.
+type Report struct {
+	Unsupported []Unsupported
+	Skipped     []Skipped
+}
+
+// WithReport makes List, Unzip and Extract record unsupported features in r
+// instead of failing. Entries using an unknown compression method or
+// encryption are skipped, and unknown extra fields are ignored; each is
+// appended to r.Unsupported. Without a report Unzip fails on the first
+// entry it cannot read.
+//
+// Zip appends the files that WithMinSize and WithMaxSize leave out to
+// r.Skipped.
+func WithReport(r *Report) Option {
+	return func(o *options) {
+		o.report = r
+	}
+}
+
+// knownExtras are the extra field IDs whose metadata is either honored by
+// archive/zip or irrelevant to extraction.
+var knownExtras = map[uint16]bool{
+	0x0001: true, // zip64
+	0x000a: true, // NTFS times
+	0x000d: true, // PKWARE Unix
+	0x5455: true, // extended timestamp
+	0x5855: true, // Info-ZIP Unix, old
+	0x7875: true, // Info-ZIP Unix uid/gid
+	0xcafe: true, // JAR marker
+	0x9901: true, // WinZip AES, reported as encryption
+}
+
+// unsupportedFeatures inspects f for features that cannot be honored.
+func unsupportedFeatures(f *zip.File) []Unsupported {
+	var found []Unsupported
+
+	switch {
+	case f.Flags&0x1 != 0:
+		detail := "traditional"
+		if f.Method == methodAES {
+			detail = "AES"
+		} else if f.Flags&0x40 != 0 {
+			detail = "strong"
+		}
+		found = append(found, Unsupported{Entry: f.Name, Feature: FeatureEncryption, Detail: detail, Skipped: true})
+	case f.Method != zip.Store && f.Method != zip.Deflate:
+		// a decompressor may have been registered for it
+		rc, err := f.Open()
+		if err == nil {
+			rc.Close()
+		} else if errors.Is(err, zip.ErrAlgorithm) {
+			found = append(found, Unsupported{
+				Entry:   f.Name,
+				Feature: FeatureMethod,
+				Detail:  fmt.Sprintf("method %d", f.Method),
+				Skipped: true,
+			})
+		}
+	}
+
+	for _, id := range extraIDs(f.Extra) {
+		if !knownExtras[id] {
+			found = append(found, Unsupported{Entry: f.Name, Feature: FeatureExtraField, Detail: fmt.Sprintf("0x%04x", id)})
+		}
+	}
+
+	return found
+}
+
+// extraIDs returns the IDs of the fields in an extra block.
+func extraIDs(extra []byte) []uint16 {
+	var ids []uint16
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		ids = append(ids, id)
+		extra = extra[4+size:]
+	}
+	return ids
+}
+
+// checkSupported reports whether f can be read, recording its
+// unsupported features.
+func (o *options) checkSupported(f *zip.File) (bool, error) {
+	found := unsupportedFeatures(f)
+
+	// encrypted entries are readable given the password
+	if o.password != "" && decryptable(f) {
+		found = slices.DeleteFunc(found, func(u Unsupported) bool {
+			return u.Feature == FeatureEncryption
+		})
+	}
+	return o.recordUnsupported(found)
+}
+
+// recordUnsupported records the unsupported features of an entry in the
+// report if there is one, and reports whether the entry can still be
+// read. Without a report an entry that cannot be read is an error.
+func (o *options) recordUnsupported(found []Unsupported) (bool, error) {
+	ok := true
+	for _, u := range found {
+		if u.Skipped {
+			ok = false
+			if o.report == nil {
+				return false, &EntryError{
+					Name: u.Entry,
+					Err:  fmt.Errorf("unsupported %s (%s): %w", u.Feature, u.Detail, errors.ErrUnsupported),
+				}
+			}
+			o.logSkip(u.Entry, fmt.Sprintf("unsupported %s (%s)", u.Feature, u.Detail))
+		}
+		if o.report != nil {
+			o.report.Unsupported = append(o.report.Unsupported, u)
+		}
+	}
+	return ok, nil
+}
@@ -0,0 +1,106 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// createUnsupportedZip writes an archive with a readable entry, an entry
+// using an unknown method, an encrypted entry, and a readable entry with
+// an unknown extra field.
+func createUnsupportedZip(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "unsupported.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	raw := []*zip.FileHeader{
+		{Name: "plain.txt", Method: zip.Store},
+		{Name: "bzip2.bin", Method: 12},
+		{Name: "secret.txt", Method: zip.Store, Flags: 0x1},
+		{Name: "extra.txt", Method: zip.Store, Extra: []byte{0x42, 0x42, 2, 0, 'h', 'i'}},
+	}
+	for _, hdr := range raw {
+		hdr.CompressedSize64 = 4
+		hdr.UncompressedSize64 = 4
+		hdr.CRC32 = 0xd87f7e0c // "test"
+		w, err := zw.CreateRaw(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("test")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestUnzipReport(t *testing.T) {
+	zipPath := createUnsupportedZip(t)
+	dest := t.TempDir()
+
+	var report Report
+	if err := Unzip(zipPath, dest, WithReport(&report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Unsupported{
+		{Entry: "bzip2.bin", Feature: FeatureMethod, Detail: "method 12", Skipped: true},
+		{Entry: "secret.txt", Feature: FeatureEncryption, Detail: "traditional", Skipped: true},
+		{Entry: "extra.txt", Feature: FeatureExtraField, Detail: "0x4242"},
+	}
+	if len(report.Unsupported) != len(want) {
+		t.Fatalf("expected %v, got %v", want, report.Unsupported)
+	}
+	for i := range want {
+		if report.Unsupported[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], report.Unsupported[i])
+		}
+	}
+
+	got := zipptest.ReadTree(t, dest)
+	if len(got) != 2 || string(got["plain.txt"]) != "test" || string(got["extra.txt"]) != "test" {
+		t.Errorf("expected only the readable entries, got %v", got)
+	}
+}
+
+func TestUnzipUnsupportedWithoutReport(t *testing.T) {
+	zipPath := createUnsupportedZip(t)
+
+	err := Unzip(zipPath, t.TempDir())
+
+	var entryErr *EntryError
+	if !errors.As(err, &entryErr) || entryErr.Name != "bzip2.bin" {
+		t.Fatalf("expected an entry error for bzip2.bin, got %v", err)
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
+
+func TestExtraIDs(t *testing.T) {
+	extra := []byte{
+		0x55, 0x54, 1, 0, 0, // extended timestamp
+		0x42, 0x42, 0, 0, // empty unknown field
+		0x01, 0x00, 9, 0, // truncated
+	}
+
+	ids := extraIDs(extra)
+	if len(ids) != 2 || ids[0] != 0x5455 || ids[1] != 0x4242 {
+		t.Errorf("expected [0x5455 0x4242], got %x", ids)
+	}
+}
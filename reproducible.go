@@ -0,0 +1,49 @@
+package zipper
+
+import (
+	"io/fs"
+	"time"
+)
+
+// reproducibleEpoch is the earliest time a zip entry can record, which
+// WithReproducible dates entries with by default.
+var reproducibleEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// WithReproducible makes Zip write byte-identical archives from the same
+// input, whatever the file system's timestamps, ownership and listing
+// order, as build caches and supply-chain attestations require.
+//
+// Every entry, and the manifest, is dated modified, typically taken from
+// SOURCE_DATE_EPOCH, or 1980-01-01 UTC if it is zero. Permissions
+// are normalized to 0644, or 0755 for files executable by anyone,
+// tarballs record no ownership, and entries are sorted by name unless
+// WithOrder chooses another order. The time zone policy is ignored.
+// Sparse layouts depend on the file system, so WithReproducible cannot
+// be combined with WithSparse.
+func WithReproducible(modified time.Time) Option {
+	return func(o *options) {
+		if modified.IsZero() {
+			modified = reproducibleEpoch
+		}
+		o.reproducible = true
+		o.epoch = modified.UTC()
+	}
+}
+
+// modTime returns the modification time recorded for a file modified at
+// t.
+func (o *options) modTime(t time.Time) time.Time {
+	if o.reproducible {
+		return o.epoch
+	}
+	return o.timeZone.apply(t)
+}
+
+// normalizeMode returns the permissions of a file in a reproducible
+// archive.
+func normalizeMode(mode fs.FileMode) fs.FileMode {
+	if mode&0111 != 0 {
+		return mode.Type() | 0755
+	}
+	return mode.Type() | 0644
+}
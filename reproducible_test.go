@@ -0,0 +1,88 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// reproducibleTree writes the same files under a directory named build,
+// with modification times and permissions depending on variant.
+func reproducibleTree(t *testing.T, variant int) string {
+	t.Helper()
+
+	src := filepath.Join(t.TempDir(), "build")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "bin/run": "#!/bin/sh", "z/b.txt": "beta"})
+
+	modified := time.Now().Add(-time.Duration(variant) * time.Hour)
+	modes := map[string]os.FileMode{"a.txt": 0600 | os.FileMode(variant)<<3, "bin/run": 0700 | os.FileMode(variant)}
+	for name, mode := range modes {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.txt", "bin/run", "z/b.txt"} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+func TestReproducible(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"zip", []Option{WithManifest()}},
+		{"tar.gz", []Option{WithFormat(FormatTarGz)}},
+		{"tar.zst", []Option{WithFormat(FormatTarZst)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var archives [][]byte
+			for variant := 0; variant < 2; variant++ {
+				src := reproducibleTree(t, variant*4)
+				path := zipAside(t, src, append(tc.opts, WithReproducible(time.Time{}))...)
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				archives = append(archives, data)
+			}
+
+			if !bytes.Equal(archives[0], archives[1]) {
+				t.Error("expected identical archives from identical input")
+			}
+		})
+	}
+}
+
+func TestReproducibleEntries(t *testing.T) {
+	epoch := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	zipPath := zipAside(t, reproducibleTree(t, 0), WithReproducible(epoch))
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	want := map[string]os.FileMode{"a.txt": 0644, "bin/run": 0755, "z/b.txt": 0644}
+	for _, f := range r.File {
+		if !f.Modified.Equal(epoch) {
+			t.Errorf("%s: expected modification time %v, got %v", f.Name, epoch, f.Modified)
+		}
+		if f.Mode().Perm() != want[f.Name] {
+			t.Errorf("%s: expected mode %v, got %v", f.Name, want[f.Name], f.Mode().Perm())
+		}
+	}
+
+	if _, err := Zip(t.TempDir(), WithReproducible(epoch), WithSparse()); err == nil {
+		t.Error("expected an error for reproducible sparse archives")
+	}
+}
@@ -0,0 +1,312 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// journalSuffix is appended to the archive's path to name the journal of
+// a resumable Zip.
+const journalSuffix = ".journal"
+
+// WithResume makes Zip resumable, for archives of huge trees that take
+// hours to write. Each entry written is recorded in a journal next to the
+// archive, "<archive>.journal", and if Zip fails or is interrupted the
+// partial archive, "<archive>.tmp", and its journal are kept rather than
+// removed.
+//
+// Calling Zip again with WithResume verifies the entries already in the
+// partial archive against the journal, checking their headers and
+// checksums, and continues after the last good one instead of starting
+// over. Files are matched to journaled entries by name, so a file changed
+// after it was archived is not archived again. The journal is removed
+// once the archive is complete.
+//
+// The archive must be a single zip file, so WithResume cannot be combined
+// with WithSplitSize, WithSink, WithSelfExtractor or another format, and
+// only applies to Zip.
+func WithResume() Option {
+	return func(o *options) {
+		o.resume = true
+	}
+}
+
+// journalEntry records an entry written to a resumable archive, which
+// occupies the bytes from Start to End.
+type journalEntry struct {
+	Header *zip.FileHeader `json:"header"`
+	SHA256 string          `json:"sha256,omitempty"`
+	Start  int64           `json:"start"`
+	End    int64           `json:"end"`
+
+	// the layout of a sparse file
+	Sparse []SparseRegion `json:"sparse,omitempty"`
+}
+
+// resumableOutput writes an archive that can be resumed, passing over the
+// entries kept from an earlier attempt.
+type resumableOutput struct {
+	dstPath string
+	f       *os.File  // the partial archive
+	w       io.Writer // f, or a throttle in front of it
+	journal *os.File
+	sync    bool // flush to stable storage, for WithSync
+
+	done  []journalEntry // verified entries of the earlier attempt
+	start int64          // where the verified entries end
+	pos   int64          // bytes of the archive produced so far
+	last  int64          // where the last journaled entry ends
+}
+
+// openResumable opens the partial archive for dstPath for writing, keeping
+// the entries of an earlier attempt that pass verification.
+func openResumable(dstPath string) (*resumableOutput, error) {
+	f, err := os.OpenFile(dstPath+tempSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := readJournal(dstPath + journalSuffix)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	done = verifyJournal(f, done)
+
+	r := &resumableOutput{dstPath: dstPath, f: f, w: f, done: done}
+	if n := len(done); n > 0 {
+		r.start = done[n-1].End
+	}
+
+	// keep only the verified entries in the journal
+	if r.journal, err = os.Create(dstPath + journalSuffix); err == nil {
+		enc := json.NewEncoder(r.journal)
+		for _, e := range done {
+			if err = enc.Encode(e); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		_, err = f.Seek(r.start, io.SeekStart)
+	}
+	if err != nil {
+		r.Abort()
+		return nil, err
+	}
+	return r, nil
+}
+
+// readJournal returns the entries recorded in the journal at path, which
+// may not exist. A torn last line from an interrupted write is ignored.
+func readJournal(path string) ([]journalEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var e journalEntry
+		if err := dec.Decode(&e); err != nil || e.Header == nil {
+			return entries, nil
+		}
+		entries = append(entries, e)
+	}
+}
+
+// verifyJournal returns the leading entries of the journal that are
+// intact in the partial archive f.
+func verifyJournal(f *os.File, entries []journalEntry) []journalEntry {
+	var end int64
+	for i, e := range entries {
+		if e.Start != end || verifyEntry(f, e) != nil {
+			return entries[:i]
+		}
+		end = e.End
+	}
+	return entries
+}
+
+// verifyEntry checks the local header and contents of a journaled entry.
+func verifyEntry(f *os.File, e journalEntry) error {
+	hdr := e.Header
+	size := int64(hdr.CompressedSize64)
+
+	var local [30]byte
+	if _, err := f.ReadAt(local[:], e.Start); err != nil {
+		return err
+	}
+	nameLen := int64(binary.LittleEndian.Uint16(local[26:]))
+	extraLen := int64(binary.LittleEndian.Uint16(local[28:]))
+	if binary.LittleEndian.Uint32(local[:]) != 0x04034b50 || e.Start+30+nameLen+extraLen+size != e.End {
+		return zip.ErrFormat
+	}
+
+	name := make([]byte, nameLen)
+	if _, err := f.ReadAt(name, e.Start+30); err != nil {
+		return err
+	}
+	if string(name) != hdr.Name {
+		return zip.ErrFormat
+	}
+
+	var r io.Reader = io.NewSectionReader(f, e.End-size, size)
+	switch hdr.Method {
+	case zip.Store:
+	case zip.Deflate:
+		fr := flate.NewReader(r)
+		defer fr.Close()
+		r = fr
+	case zipZstd:
+		zr := decompressZstd(r)
+		defer zr.Close()
+		r = zr
+	default:
+		return zip.ErrAlgorithm
+	}
+
+	crc := crc32.NewIEEE()
+	n, err := io.Copy(crc, r)
+	if err != nil {
+		return err
+	}
+	if uint64(n) != hdr.UncompressedSize64 || crc.Sum32() != hdr.CRC32 {
+		return zip.ErrChecksum
+	}
+	return nil
+}
+
+// replay writes the verified entries through zipw, whose bytes are
+// already in place, so the central directory lists them.
+func (r *resumableOutput) replay(zipw *zip.Writer) error {
+	for _, e := range r.done {
+		hdr := *e.Header
+		w, err := zipw.CreateRaw(&hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, zeroReader{}, int64(hdr.CompressedSize64)); err != nil {
+			return err
+		}
+	}
+
+	if err := zipw.Flush(); err != nil {
+		return err
+	}
+	if r.pos != r.start {
+		return errors.New("partial archive does not match its journal")
+	}
+	r.last = r.pos
+	return nil
+}
+
+// remaining returns the files below root not yet in the archive.
+func (r *resumableOutput) remaining(root string, files []string, o *options) []string {
+	done := make(map[string]bool, len(r.done))
+	for _, e := range r.done {
+		done[e.Header.Name] = true
+	}
+
+	left := make([]string, 0, len(files))
+	for _, file := range files {
+		if name, err := o.entryName(root, file); err != nil || !done[name] {
+			left = append(left, file)
+		}
+	}
+	return left
+}
+
+// written returns the manifest entries of the verified entries.
+func (r *resumableOutput) written(o *options) []ManifestEntry {
+	entries := make([]ManifestEntry, 0, len(r.done))
+	for _, e := range r.done {
+		c := compressed{hdr: e.Header, sha256: e.SHA256, sparse: e.Sparse}
+		entry := c.manifestEntry()
+		entry.Groups = o.groupsFor(entry.Name)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// record journals the entry just written through zipw.
+func (r *resumableOutput) record(zipw *zip.Writer, c compressed) error {
+	if err := zipw.Flush(); err != nil {
+		return err
+	}
+
+	e := journalEntry{
+		Header: c.hdr,
+		SHA256: c.sha256,
+		Start:  r.last,
+		End:    r.pos,
+		Sparse: c.sparse,
+	}
+	r.last = r.pos
+	return json.NewEncoder(r.journal).Encode(e)
+}
+
+func (r *resumableOutput) Write(p []byte) (int, error) {
+	n := len(p)
+
+	// the verified entries are already in place
+	if skip := r.start - r.pos; skip > 0 {
+		if int64(len(p)) <= skip {
+			r.pos += int64(len(p))
+			return n, nil
+		}
+		p = p[skip:]
+		r.pos = r.start
+	}
+
+	m, err := r.w.Write(p)
+	r.pos += int64(m)
+	if err != nil {
+		return n - len(p) + m, err
+	}
+	return n, nil
+}
+
+func (r *resumableOutput) Commit() error {
+	// an earlier attempt may have left more behind
+	err := r.f.Truncate(r.pos)
+	if cerr := syncClose(r.f, r.sync); err == nil {
+		err = cerr
+	}
+	r.journal.Close()
+	if err == nil {
+		err = renameSynced(r.f.Name(), r.dstPath, r.sync)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(r.journal.Name())
+}
+
+// Abort keeps the partial archive and journal for the next attempt.
+func (r *resumableOutput) Abort() {
+	r.f.Close()
+	if r.journal != nil {
+		r.journal.Close()
+	}
+}
+
+// zeroReader reads an endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
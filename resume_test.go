@@ -0,0 +1,164 @@
+package zipper
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// interruptedZip starts a resumable archive of src that fails at the
+// dangling symlink "m-broken", leaving a partial archive and journal. It
+// returns the archive's final path.
+func interruptedZip(t *testing.T, src string) string {
+	t.Helper()
+
+	link := filepath.Join(src, "m-broken")
+	if err := os.Symlink("missing", link); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Base(src) + ".zip"
+	t.Cleanup(func() {
+		os.Remove(zipPath)
+		os.Remove(zipPath + tempSuffix)
+		os.Remove(zipPath + journalSuffix)
+	})
+
+	if _, err := Zip(src, WithResume()); err == nil {
+		t.Fatal("expected the broken symlink to fail the archive")
+	}
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(zipPath + tempSuffix); err != nil {
+		t.Fatalf("expected the partial archive to be kept: %v", err)
+	}
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		t.Fatal("expected no archive under the final name")
+	}
+	return zipPath
+}
+
+func TestResume(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "resumed")
+	writeTree(t, src, map[string]string{
+		"a.txt":     "alpha",
+		"b.txt":     "beta",
+		"dir/c.txt": "gamma",
+		"z.txt":     "zeta",
+	})
+	zipPath := interruptedZip(t, src)
+
+	done, err := readJournal(zipPath + journalSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 3 {
+		t.Fatalf("expected 3 journaled entries, got %d", len(done))
+	}
+
+	// entries already written are kept as they were
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("ALPHA"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Zip(src, WithResume(), WithManifest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(zipPath + journalSuffix); !os.IsNotExist(err) {
+		t.Error("expected the journal to be removed")
+	}
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if string(files["a.txt"]) != "alpha" || string(files["z.txt"]) != "zeta" || string(files["dir/c.txt"]) != "gamma" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+
+	// the manifest covers the entries of both attempts
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResumeDamaged(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "damaged")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "b.txt": "beta", "c.txt": "gamma"})
+	zipPath := interruptedZip(t, src)
+
+	done, err := readJournal(zipPath + journalSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) < 2 {
+		t.Fatalf("expected at least 2 journaled entries, got %d", len(done))
+	}
+
+	// damage the second entry and tear the journal
+	data, err := os.ReadFile(zipPath + tempSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[done[1].End-1] ^= 0xff
+	if err := os.WriteFile(zipPath+tempSuffix, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := os.OpenFile(zipPath+journalSuffix, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.WriteString(`{"header":{"Na`)
+	journal.Close()
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), bytes.ToUpper([]byte(name)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Zip(src, WithResume()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the first entry is kept, the damaged one is written again
+	files := zipptest.ReadArchive(t, zipPath)
+	if string(files["a.txt"]) != "alpha" || string(files["b.txt"]) != "B.TXT" || string(files["c.txt"]) != "gamma" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
+
+func TestResumeFresh(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "fresh")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	zipPath, err := Zip(src, WithResume())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	zipptest.AssertRoundTrip(t, zipPath, src)
+	if _, err := os.Stat(zipPath + journalSuffix); !os.IsNotExist(err) {
+		t.Error("expected the journal to be removed")
+	}
+}
+
+func TestResumeInvalid(t *testing.T) {
+	src := t.TempDir()
+
+	if _, err := Zip(src, WithResume(), WithSplitSize(minSplitSize)); err == nil {
+		t.Error("expected an error for a split resumable archive")
+	}
+	if _, err := Zip(src, WithResume(), WithFormat(FormatTarGz)); err == nil {
+		t.Error("expected an error for a resumable tarball")
+	}
+	if err := ZipTo(&bytes.Buffer{}, src, WithResume()); err == nil {
+		t.Error("expected an error for a resumable stream")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package zipper
+
+// openFileLimit returns 0 as there is no per-process descriptor limit to
+// detect on this platform.
+func openFileLimit() int {
+	return 0
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package zipper
+
+import "syscall"
+
+// openFileLimit returns the soft limit on open file descriptors for the
+// process, or 0 if it cannot be determined.
+func openFileLimit() int {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0
+	}
+	if rl.Cur > 1<<20 {
+		return 1 << 20
+	}
+	return int(rl.Cur)
+}
@@ -0,0 +1,128 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+)
+
+// PartialMarker is the name of the empty file CleanupMark leaves in the
+// destination of a failed extraction.
+const PartialMarker = ".partial"
+
+// CleanupPolicy decides what extraction does with the files it wrote when
+// it fails partway.
+type CleanupPolicy int
+
+const (
+	// CleanupKeep leaves everything written so far in place. It is the
+	// default.
+	CleanupKeep CleanupPolicy = iota
+
+	// CleanupRollback removes the files, links and directories the
+	// extraction created, the destination included, newest first. Files
+	// replaced under WithOverwrite are not restored.
+	CleanupRollback
+
+	// CleanupMark keeps everything written so far and marks the
+	// destination as incomplete with an empty PartialMarker file, which
+	// a later successful extraction with CleanupMark removes.
+	CleanupMark
+)
+
+func (p CleanupPolicy) String() string {
+	switch p {
+	case CleanupKeep:
+		return "keep"
+	case CleanupRollback:
+		return "rollback"
+	case CleanupMark:
+		return "mark"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCleanupPolicy sets what Unzip and the other extraction functions
+// do with their partial output when they fail, canceled or not. The
+// default is CleanupKeep.
+func WithCleanupPolicy(p CleanupPolicy) Option {
+	return func(o *options) {
+		o.cleanup = p
+	}
+}
+
+// mkdirAll creates the directory path and any parents missing below dest,
+// recording those it creates for CleanupRollback.
+func (e *extractor) mkdirAll(path string) error {
+	var created []string
+	if e.o.cleanup == CleanupRollback {
+		for dir := path; dir != e.dest && within(e.dest, dir); dir = filepath.Dir(dir) {
+			if _, err := e.lstat(dir); err == nil {
+				break
+			}
+			created = append(created, dir)
+		}
+	}
+
+	if err := e.target().MkdirAll(path, e.o.dirPerm()); err != nil {
+		return err
+	}
+	slices.Reverse(created)
+	e.mu.Lock()
+	e.created = append(e.created, created...)
+	e.mu.Unlock()
+	return nil
+}
+
+// record notes that the extraction created the file at path, for
+// CleanupRollback.
+func (e *extractor) record(path string) {
+	if e.o.cleanup == CleanupRollback {
+		e.mu.Lock()
+		e.created = append(e.created, path)
+		e.mu.Unlock()
+	}
+}
+
+// abort applies the cleanup policy if the extraction did not finish.
+// Failures to clean up are logged, leaving the extraction's own error to
+// be returned.
+func (e *extractor) abort() {
+	// writes in flight finish first
+	e.pool.wait()
+	if e.done || e.o.dryRun != nil {
+		return
+	}
+
+	switch e.o.cleanup {
+	case CleanupRollback:
+		for i := len(e.created) - 1; i >= 0; i-- {
+			if err := e.remove(e.created[i]); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				e.o.log(slog.LevelWarn, "rolling back", "path", e.created[i], "error", err)
+			}
+		}
+	case CleanupMark:
+		f, err := e.target().Create(filepath.Join(e.dest, PartialMarker), 0644)
+		if err == nil {
+			err = f.Close()
+		}
+		if err != nil {
+			e.o.log(slog.LevelWarn, "marking partial extraction", "path", e.dest, "error", err)
+		}
+	}
+}
+
+// unmark removes the marker of an earlier failed extraction into dest,
+// for CleanupMark.
+func (e *extractor) unmark() error {
+	if e.o.cleanup != CleanupMark || e.o.dryRun != nil {
+		return nil
+	}
+	if err := e.remove(filepath.Join(e.dest, PartialMarker)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnzipCleanupPolicy(t *testing.T) {
+	failing := createZip(t,
+		testEntry{name: "dir/sub/a.txt", body: "a"},
+		testEntry{name: "b.txt", body: "b"},
+		testEntry{name: "../evil.txt", body: "evil"},
+	)
+
+	dest := filepath.Join(t.TempDir(), "new", "out")
+	if err := Unzip(failing, dest, WithCleanupPolicy(CleanupRollback)); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected the extraction to fail, got %v", err)
+	}
+	if _, err := os.Stat(dest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the destination created to be removed, got %v", err)
+	}
+
+	dest = t.TempDir()
+	writeTree(t, dest, map[string]string{"keep.txt": "mine", "dir/other.txt": "mine"})
+	if err := Unzip(failing, dest, WithCleanupPolicy(CleanupRollback)); err == nil {
+		t.Fatal("expected the extraction to fail")
+	}
+	if got := listTree(t, dest); len(got) != 2 || got[0] != "dir/other.txt" || got[1] != "keep.txt" {
+		t.Errorf("expected only the files already there to remain, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "dir", "sub")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the directory created to be removed, got %v", err)
+	}
+
+	dest = t.TempDir()
+	if err := Unzip(failing, dest, WithCleanupPolicy(CleanupMark)); err == nil {
+		t.Fatal("expected the extraction to fail")
+	}
+	if got := listTree(t, dest); len(got) != 3 || got[0] != PartialMarker {
+		t.Errorf("expected the partial output to be kept and marked, got %v", got)
+	}
+
+	complete := createZip(t, testEntry{name: "b.txt", body: "b"})
+	if err := Unzip(complete, dest, WithCleanupPolicy(CleanupMark), WithOverwrite(OverwriteAlways)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, PartialMarker)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a successful extraction to remove the marker, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package s3 provides a zipper.Sink that streams archives to Amazon S3,
+// or any service speaking its API, with a multipart upload, so an archive
+// is uploaded as it is written and never stored locally.
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+const (
+	// MinPartSize is the smallest part S3 accepts, except for the last.
+	MinPartSize = 5 << 20
+
+	// DefaultPartSize is the part size used when Config.PartSize is 0.
+	DefaultPartSize = 8 << 20
+
+	// maxParts is the most parts an upload may have.
+	maxParts = 10000
+)
+
+// Config describes the bucket archives are uploaded to.
+type Config struct {
+	Bucket string
+	Region string
+
+	// Prefix is prepended to the archive name to form the object key,
+	// e.g. "backups/".
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint is the base URL of an S3-compatible service, such as
+	// "http://localhost:9000". Objects are then addressed by path,
+	// otherwise the bucket's AWS virtual-hosted endpoint is used.
+	Endpoint string
+
+	// PartSize is the size of each uploaded part, and the memory held per
+	// upload. It is raised to MinPartSize if below it.
+	PartSize int64
+
+	// Client sends the requests. The default is http.DefaultClient.
+	Client *http.Client
+}
+
+// Error is an error response from S3.
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// Sink uploads archives to a bucket.
+type Sink struct {
+	cfg   Config
+	creds *credentials
+	base  *url.URL
+}
+
+var _ zipper.Sink = (*Sink)(nil)
+
+// New returns a Sink uploading to the bucket described by cfg.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3: bucket and region are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3: credentials are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", cfg.Bucket, cfg.Region)
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint: %w", err)
+	}
+	if cfg.Endpoint != "" {
+		base.Path += "/" + cfg.Bucket
+	}
+
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	cfg.PartSize = max(cfg.PartSize, MinPartSize)
+
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	return &Sink{
+		cfg: cfg,
+		creds: &credentials{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+			region:          cfg.Region,
+		},
+		base: base,
+	}, nil
+}
+
+// Create starts a multipart upload of the object Prefix+name.
+func (s *Sink) Create(name string) (zipper.Upload, error) {
+	u := &upload{s: s, key: s.cfg.Prefix + name}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.do(http.MethodPost, u.key, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return nil, err
+	}
+	u.id = result.UploadID
+
+	return u, nil
+}
+
+// do sends a signed request for the object key, decoding an XML response
+// into out unless it is nil.
+func (s *Sink) do(method, key string, query url.Values, body []byte, out any) error {
+	_, err := s.doHeader(method, key, query, body, out)
+	return err
+}
+
+// doHeader is do, also returning the response headers.
+func (s *Sink) doHeader(method, key string, query url.Values, body []byte, out any) (http.Header, error) {
+	u := *s.base
+	u.Path += "/" + key
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	s.creds.sign(req, payloadHash, time.Now())
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// CompleteMultipartUpload can fail after a 200 status
+	if resp.StatusCode/100 != 2 || bytes.Contains(data, []byte("<Error>")) {
+		e := &Error{StatusCode: resp.StatusCode}
+		xml.Unmarshal(data, e)
+		return nil, e
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("s3: invalid response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// upload is a multipart upload in progress.
+type upload struct {
+	s   *Sink
+	key string
+	id  string
+
+	buf   []byte
+	parts []completedPart
+	err   error
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (u *upload) Write(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		room := int(u.s.cfg.PartSize) - len(u.buf)
+		chunk := min(room, len(p))
+		u.buf = append(u.buf, p[:chunk]...)
+		p = p[chunk:]
+
+		if len(u.buf) == int(u.s.cfg.PartSize) {
+			if err := u.flush(); err != nil {
+				u.err = err
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush uploads the buffered data as the next part.
+func (u *upload) flush() error {
+	number := len(u.parts) + 1
+	if number > maxParts {
+		return fmt.Errorf("s3: archive exceeds %d parts, raise PartSize", maxParts)
+	}
+
+	query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {u.id}}
+	header, err := u.s.doHeader(http.MethodPut, u.key, query, u.buf, nil)
+	if err != nil {
+		return err
+	}
+
+	u.parts = append(u.parts, completedPart{PartNumber: number, ETag: header.Get("ETag")})
+	u.buf = u.buf[:0]
+	return nil
+}
+
+// Commit uploads the last part and completes the upload.
+func (u *upload) Commit() error {
+	if u.err != nil {
+		return u.err
+	}
+
+	// an upload needs at least one part, even if empty
+	if len(u.buf) > 0 || len(u.parts) == 0 {
+		if err := u.flush(); err != nil {
+			return err
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: u.parts})
+	if err != nil {
+		return err
+	}
+
+	return u.s.do(http.MethodPost, u.key, url.Values{"uploadId": {u.id}}, body, nil)
+}
+
+// Abort abandons the upload, discarding the parts already uploaded.
+func (u *upload) Abort() {
+	u.s.do(http.MethodDelete, u.key, url.Values{"uploadId": {u.id}}, nil, nil)
+}
@@ -0,0 +1,188 @@
+package s3
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	zipper "github.com/irrisdev/go-zip"
+)
+
+// fakeS3 implements enough of the multipart upload API for the tests.
+type fakeS3 struct {
+	mu      sync.Mutex
+	parts   map[string]map[int][]byte // by upload ID
+	objects map[string][]byte
+	aborted int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{parts: make(map[string]map[int][]byte), objects: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signingAlgorithm+" ") {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>unsigned</Message></Error>")
+		return
+	}
+
+	q := r.URL.Query()
+	id := q.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		id = fmt.Sprint(len(f.parts) + 1)
+		f.parts[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == http.MethodPut:
+		var n int
+		fmt.Sscan(q.Get("partNumber"), &n)
+		data, _ := io.ReadAll(r.Body)
+		f.parts[id][n] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+
+	case r.Method == http.MethodPost:
+		var req struct {
+			Parts []completedPart `xml:"Part"`
+		}
+		xml.NewDecoder(r.Body).Decode(&req)
+
+		var object []byte
+		for _, p := range req.Parts {
+			object = append(object, f.parts[id][p.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = object
+		fmt.Fprint(w, "<CompleteMultipartUploadResult/>")
+
+	case r.Method == http.MethodDelete:
+		delete(f.parts, id)
+		f.aborted++
+	}
+}
+
+func newTestSink(t *testing.T, srv *httptest.Server) *Sink {
+	t.Helper()
+
+	s, err := New(Config{
+		Bucket:          "bucket",
+		Region:          "us-east-1",
+		Prefix:          "backups/",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Endpoint:        srv.URL,
+		PartSize:        MinPartSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestZipToS3(t *testing.T) {
+	fake, srv := newFakeS3(t)
+
+	src := filepath.Join(t.TempDir(), "site")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	big := make([]byte, 2*MinPartSize+1000)
+	rand.New(rand.NewSource(1)).Read(big)
+	if err := os.WriteFile(filepath.Join(src, "big.bin"), big, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := zipper.Zip(src, zipper.WithSink(newTestSink(t, srv)), zipper.WithMethod(zipper.MethodStore))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "site.zip" {
+		t.Errorf("expected site.zip, got %s", name)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("expected no local archive")
+	}
+
+	object := fake.objects["/bucket/backups/site.zip"]
+	if len(fake.parts["1"]) != 3 {
+		t.Errorf("expected 3 parts, got %d", len(fake.parts["1"]))
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(object), int64(len(object)))
+	if err != nil {
+		t.Fatalf("expected a zip object: %v", err)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil || !bytes.Equal(data, big) {
+		t.Errorf("object contents differ (%v)", err)
+	}
+}
+
+func TestUploadAbort(t *testing.T) {
+	fake, srv := newFakeS3(t)
+
+	u, err := newTestSink(t, srv).Create("x.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Write([]byte("partial"))
+	u.Abort()
+
+	if fake.aborted != 1 || len(fake.objects) != 0 {
+		t.Errorf("expected an aborted upload and no object, got %d aborted and %v", fake.aborted, fake.objects)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+	}))
+	defer srv.Close()
+
+	_, err := newTestSink(t, srv).Create("x.zip")
+
+	var s3Err *Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden || s3Err.Code != "AccessDenied" {
+		t.Errorf("expected an AccessDenied error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Region: "us-east-1", AccessKeyID: "a", SecretAccessKey: "b"}); err == nil {
+		t.Error("expected an error without a bucket")
+	}
+
+	s, err := New(Config{Bucket: "b", Region: "eu-west-1", AccessKeyID: "a", SecretAccessKey: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.base.String(); got != "https://b.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("unexpected endpoint %s", got)
+	}
+	if s.cfg.PartSize != DefaultPartSize {
+		t.Errorf("expected the default part size, got %d", s.cfg.PartSize)
+	}
+}
@@ -0,0 +1,121 @@
+package zipper
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sanitizeName validates an entry name from an untrusted archive and
+// returns it cleaned, in slash-separated form. Rejected names give an
+// error wrapping ErrInvalidPath, which callers name the entry in.
+//
+// Names must be relative and stay below the extraction root: absolute
+// paths, drive letters, UNC paths, NUL bytes and ".." segments are all
+// rejected. Backslashes are treated as separators, since some Windows
+// tools write them despite the format requiring forward slashes.
+func sanitizeName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+
+	switch {
+	case slashed == "":
+		return "", invalidPath("empty name")
+	case strings.ContainsRune(slashed, 0):
+		return "", invalidPath("name contains a NUL byte")
+	case strings.HasPrefix(slashed, "/"):
+		return "", invalidPath("name is absolute")
+	case len(slashed) >= 2 && slashed[1] == ':':
+		return "", invalidPath("name has a drive letter")
+	}
+
+	for _, seg := range strings.Split(slashed, "/") {
+		if seg == ".." {
+			return "", invalidPath("name escapes destination")
+		}
+	}
+
+	return path.Clean(slashed), nil
+}
+
+// within reports whether path is root or lies below it. Both must be
+// clean and of the same kind (absolute or relative), though either may be
+// in extended-length form.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(shortPath(root), shortPath(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkParents guards against symlinks, whether created by earlier
+// entries or already present in the destination, redirecting path
+// outside realDest, the symlink-free destination root.
+func checkParents(realDest, path string) error {
+	// find the closest ancestor that already exists
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+
+	if !within(realDest, resolved) {
+		return invalidPath("%s resolves outside destination", path)
+	}
+
+	return nil
+}
+
+// checkSymlink validates that a link at path pointing to target stays
+// below realDest. The target is walked segment by segment, following any
+// links it passes through if resolve is set, so "link/.." cannot be used
+// to climb out via an earlier link.
+func checkSymlink(realDest, path, target string, resolve bool) error {
+	slashed := strings.ReplaceAll(target, `\`, "/")
+	if slashed == "" || strings.HasPrefix(slashed, "/") || (len(slashed) >= 2 && slashed[1] == ':') {
+		return invalidPath("link target %q is not relative", target)
+	}
+
+	cur := filepath.Dir(path)
+	if resolve {
+		var err error
+		if cur, err = filepath.EvalSymlinks(cur); err != nil {
+			return err
+		}
+	}
+
+	for _, seg := range strings.Split(slashed, "/") {
+		switch seg {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+		default:
+			cur = filepath.Join(cur, seg)
+			if !resolve {
+				break
+			}
+			if resolved, err := filepath.EvalSymlinks(cur); err == nil {
+				cur = resolved
+			}
+		}
+
+		if !within(realDest, cur) {
+			return invalidPath("link target %s escapes destination", target)
+		}
+	}
+
+	return nil
+}
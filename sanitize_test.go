@@ -0,0 +1,186 @@
+package zipper
+
+import (
+	"archive/tar"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name      string
+		want      string
+		wantError bool
+	}{
+		{name: "a/b.txt", want: "a/b.txt"},
+		{name: "./a//b.txt", want: "a/b.txt"},
+		{name: "./", want: "."},
+		{name: "./a/b", want: "a/b"},
+		{name: `dir\file.txt`, want: "dir/file.txt"},
+		{name: "dir/", want: "dir"},
+		{name: "", wantError: true},
+		{name: "../evil.txt", wantError: true},
+		{name: "a/../../evil.txt", wantError: true},
+		{name: "a/../b.txt", wantError: true},
+		{name: `..\evil.txt`, wantError: true},
+		{name: "/etc/passwd", wantError: true},
+		{name: `\\server\share\evil.txt`, wantError: true},
+		{name: "C:/Windows/evil.dll", wantError: true},
+		{name: `C:evil.txt`, wantError: true},
+		{name: "evil\x00.txt", wantError: true},
+	}
+
+	for _, tt := range tests {
+		got, err := sanitizeName(tt.name)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("sanitizeName(%q): expected error, got %q", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("sanitizeName(%q): unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sanitizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnzipMaliciousArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+	}{
+		{
+			name:    "parent traversal",
+			entries: []testEntry{{name: "../../evil.txt", body: "pwned"}},
+		},
+		{
+			name:    "absolute path",
+			entries: []testEntry{{name: "/tmp/evil.txt", body: "pwned"}},
+		},
+		{
+			name:    "backslash traversal",
+			entries: []testEntry{{name: `..\evil.txt`, body: "pwned"}},
+		},
+		{
+			name: "symlink pointing outside",
+			entries: []testEntry{
+				{name: "link", body: "../..", mode: fs.ModeSymlink | 0777},
+			},
+		},
+		{
+			name: "absolute symlink",
+			entries: []testEntry{
+				{name: "link", body: "/etc", mode: fs.ModeSymlink | 0777},
+			},
+		},
+		{
+			name: "symlink chain escaping through parent of link",
+			entries: []testEntry{
+				{name: "self", body: ".", mode: fs.ModeSymlink | 0777},
+				{name: "up", body: "self/../outside", mode: fs.ModeSymlink | 0777},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zipPath := createZip(t, tt.entries...)
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+
+			if err := Unzip(zipPath, dest); err == nil {
+				t.Error("expected error for malicious archive")
+			}
+
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected nothing written outside dest, found %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestUnzipRefusesExistingSymlinkOutsideDest(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside")
+	dest := filepath.Join(root, "dest")
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "escape")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	zipPath := createZip(t, testEntry{name: "escape/evil.txt", body: "pwned"})
+	if err := Unzip(zipPath, dest); err == nil {
+		t.Error("expected error writing through symlink outside dest")
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+		t.Error("file was written outside dest")
+	}
+}
+
+func TestUnzipSymlinkInsideDest(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "data/file.txt", body: "content"},
+		testEntry{name: "current", body: "data", mode: fs.ModeSymlink | 0777},
+		testEntry{name: "sibling/link.txt", body: "../data/file.txt", mode: fs.ModeSymlink | 0777},
+	)
+	dest := t.TempDir()
+
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, link := range []string{"current/file.txt", "sibling/link.txt"} {
+		data, err := os.ReadFile(filepath.Join(dest, link))
+		if err != nil {
+			t.Errorf("reading through %s: %v", link, err)
+			continue
+		}
+		if string(data) != "content" {
+			t.Errorf("%s: expected %q, got %q", link, "content", data)
+		}
+	}
+}
+
+func TestUnzipDotEntries(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "./"},
+		testEntry{name: "./a/b", body: "beta"},
+	)
+	tarPath := createTar(t, gzipCompress,
+		tarTestEntry{tar.TypeDir, "./", ""},
+		tarTestEntry{tar.TypeReg, "./a/b", "beta"},
+	)
+
+	for name, extract := range map[string]func(dest string) error{
+		"zip":    func(dest string) error { return Unzip(zipPath, dest) },
+		"stream": func(dest string) error { return UnzipReader(openSequential(t, zipPath), dest) },
+		"tar.gz": func(dest string) error { return Extract(tarPath, dest) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := extract(dest); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(dest, "a", "b"))
+			if err != nil || string(data) != "beta" {
+				t.Errorf("expected beta at a/b, got %q (%v)", data, err)
+			}
+		})
+	}
+}
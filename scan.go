@@ -0,0 +1,311 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Finding is something a Scanner detected in an archive entry.
+type Finding struct {
+	// Entry is the name of the entry the finding is in.
+	Entry string
+
+	// Scanner is the name of the scanner that reported it.
+	Scanner string
+
+	// Kind classifies the finding, e.g. "license" or "secret".
+	Kind string
+
+	// Line is the 1-based line of the finding, or 0 for whole entries.
+	Line int
+
+	// Detail describes what was found. Secrets are redacted.
+	Detail string
+}
+
+// Scanner inspects the contents of archive entries.
+type Scanner interface {
+	// Name identifies the scanner in findings.
+	Name() string
+
+	// Scan reads the contents of the entry called name from r and
+	// returns anything it detects.
+	Scan(name string, r io.Reader) ([]Finding, error)
+}
+
+// ScanReport collects the findings of every scanner over an archive.
+type ScanReport struct {
+	Archive  string
+	Findings []Finding
+}
+
+// HasKind reports whether any finding is of the given kind.
+func (r *ScanReport) HasKind(kind string) bool {
+	for _, f := range r.Findings {
+		if f.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan runs every scanner over each file in the archive at zipPath. With
+// no scanners, a LicenseScanner and a SecretScanner are used.
+func Scan(zipPath string, scanners ...Scanner) (*ScanReport, error) {
+	if len(scanners) == 0 {
+		scanners = []Scanner{LicenseScanner{}, SecretScanner{}}
+	}
+
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	report := &ScanReport{Archive: zipPath}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		for _, s := range scanners {
+			findings, err := scanEntry(s, f)
+			if err != nil {
+				return nil, err
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+	}
+
+	return report, nil
+}
+
+func scanEntry(s Scanner, f *zip.File) ([]Finding, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return s.Scan(f.Name, rc)
+}
+
+// LicenseScanner reports license files such as LICENSE, COPYING and
+// NOTICE, identifying common licenses by their text.
+type LicenseScanner struct{}
+
+// licenseSniffSize is how much of a license file is read to identify it.
+const licenseSniffSize = 8 << 10
+
+// licenseNames are base name prefixes of license files, upper cased.
+var licenseNames = []string{"LICENSE", "LICENCE", "COPYING", "NOTICE", "UNLICENSE"}
+
+// licenseTexts maps distinctive phrases to SPDX identifiers, most
+// specific first.
+var licenseTexts = []struct {
+	id      string
+	phrases []string
+}{
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "2.0"}},
+	{"Unlicense", []string{"This is free and unencumbered software"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"ISC", []string{"Permission to use, copy, modify, and/or distribute this software"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+}
+
+func (LicenseScanner) Name() string {
+	return "license"
+}
+
+func (s LicenseScanner) Scan(name string, r io.Reader) ([]Finding, error) {
+	base := strings.ToUpper(path.Base(name))
+	isLicense := false
+	for _, prefix := range licenseNames {
+		if strings.HasPrefix(base, prefix) {
+			isLicense = true
+			break
+		}
+	}
+	if !isLicense {
+		return nil, nil
+	}
+
+	head, err := io.ReadAll(io.LimitReader(r, licenseSniffSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return []Finding{{
+		Entry:   name,
+		Scanner: s.Name(),
+		Kind:    "license",
+		Detail:  identifyLicense(string(head)),
+	}}, nil
+}
+
+// identifyLicense returns the SPDX identifier of the license text, or
+// "unknown".
+func identifyLicense(text string) string {
+	for _, l := range licenseTexts {
+		matched := true
+		for _, phrase := range l.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return l.id
+		}
+	}
+	return "unknown"
+}
+
+// SecretScanner reports likely credentials in text entries, using
+// patterns for well known token formats and the Shannon entropy of long
+// unbroken strings. Binary entries are skipped.
+type SecretScanner struct {
+	// MinEntropy is the entropy in bits per character above which a long
+	// token is reported. Zero means the default of 4.5.
+	MinEntropy float64
+}
+
+// maxScanLine bounds the length of a line SecretScanner inspects at once;
+// longer lines, such as minified data, are inspected in windows of that
+// size overlapping by scanOverlap, so secrets spanning two are found.
+const (
+	maxScanLine = 1 << 20
+	scanOverlap = 4 << 10
+)
+
+// secretPatterns match well known credential formats.
+var secretPatterns = []struct {
+	what string
+	re   *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}`)},
+	{"credential assignment", regexp.MustCompile(`(?i)\b(password|passwd|secret|api[_-]?key|access[_-]?token)\b\s*[:=]\s*["']?[^\s"']{8,}`)},
+}
+
+// tokenPattern finds candidate strings for the entropy check.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{24,}`)
+
+func (SecretScanner) Name() string {
+	return "secret"
+}
+
+func (s SecretScanner) Scan(name string, r io.Reader) ([]Finding, error) {
+	minEntropy := s.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = 4.5
+	}
+
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(8000); bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var findings []Finding
+	reported := make(map[Finding]bool)
+	report := func(line int, detail string) {
+		f := Finding{
+			Entry:   name,
+			Scanner: s.Name(),
+			Kind:    "secret",
+			Line:    line,
+			Detail:  detail,
+		}
+		// overlapping windows of a long line may find a secret twice
+		if !reported[f] {
+			reported[f] = true
+			findings = append(findings, f)
+		}
+	}
+
+	scan := func(line int, text string) {
+		matched := false
+		for _, p := range secretPatterns {
+			if m := p.re.FindString(text); m != "" {
+				report(line, p.what+": "+redact(m))
+				matched = true
+			}
+		}
+		if matched {
+			return
+		}
+
+		for _, tok := range tokenPattern.FindAllString(text, -1) {
+			if entropy(tok) >= minEntropy {
+				report(line, "high entropy string: "+redact(tok))
+			}
+		}
+	}
+
+	var buf []byte
+	for line := 1; ; {
+		frag, isPrefix, err := br.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, frag...)
+		if isPrefix {
+			if len(buf) >= maxScanLine {
+				scan(line, string(buf))
+				buf = append(buf[:0], buf[len(buf)-scanOverlap:]...)
+			}
+			continue
+		}
+		scan(line, string(buf))
+		buf = buf[:0]
+		line++
+	}
+
+	return findings, nil
+}
+
+// redact keeps enough of a secret to locate it without disclosing it.
+func redact(s string) string {
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:4] + "****"
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
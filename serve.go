@@ -0,0 +1,153 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+)
+
+// ArchiveFS is a read-only fs.FS over the contents of an archive. It
+// builds on the fs.FS of archive/zip, but its files also implement
+// io.Seeker, which http.FileServer needs to answer range requests.
+// Seeking within a stored member is free; a compressed member is
+// decompressed up to the new offset, from the start when seeking back.
+type ArchiveFS struct {
+	a     *archive
+	files map[string]*zip.File
+}
+
+// OpenFS opens the archive at zipPath, which may be split into volumes,
+// as a file system. It must be closed when no longer needed.
+func OpenFS(zipPath string) (*ArchiveFS, error) {
+	a, err := openArchive(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(a.File))
+	for _, f := range a.File {
+		files[f.Name] = f
+	}
+	return &ArchiveFS{a: a, files: files}, nil
+}
+
+// Open opens the named file or directory.
+func (a *ArchiveFS) Open(name string) (fs.File, error) {
+	file, err := a.a.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := a.files[name]
+	if !ok || f.Mode().IsDir() {
+		return file, nil
+	}
+
+	sf := &seekableFile{File: file, f: f, size: int64(f.UncompressedSize64)}
+	if f.Method == zip.Store {
+		offset, err := f.DataOffset()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		sf.stored = io.NewSectionReader(a.a.ra, offset, sf.size)
+	}
+	return sf, nil
+}
+
+// Close closes the archive.
+func (a *ArchiveFS) Close() error {
+	return a.a.Close()
+}
+
+// FileServer returns a handler serving the contents of fsys with
+// http.FileServer, including range requests within members.
+func FileServer(fsys *ArchiveFS) http.Handler {
+	return http.FileServer(http.FS(fsys))
+}
+
+// seekableFile is a member of an ArchiveFS.
+type seekableFile struct {
+	fs.File // for Stat and Close
+	f       *zip.File
+	size    int64
+
+	// stored reads a stored member directly from the archive
+	stored *io.SectionReader
+
+	// rc decompresses a compressed member, from the start, and has
+	// produced pos bytes; off is where the next read starts
+	rc  io.ReadCloser
+	pos int64
+	off int64
+}
+
+func (s *seekableFile) Read(p []byte) (int, error) {
+	if s.stored != nil {
+		return s.stored.Read(p)
+	}
+
+	if s.rc == nil || s.off < s.pos {
+		if err := s.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	if s.off > s.pos {
+		n, err := io.CopyN(io.Discard, s.rc, s.off-s.pos)
+		s.pos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.rc.Read(p)
+	s.pos += int64(n)
+	s.off = s.pos
+	return n, err
+}
+
+// reopen starts decompressing from the beginning again.
+func (s *seekableFile) reopen() error {
+	if s.rc != nil {
+		s.rc.Close()
+	}
+
+	rc, err := s.f.Open()
+	if err != nil {
+		return err
+	}
+	s.rc, s.pos = rc, 0
+	return nil
+}
+
+func (s *seekableFile) Seek(offset int64, whence int) (int64, error) {
+	if s.stored != nil {
+		return s.stored.Seek(offset, whence)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, errors.New("seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+
+	// the work is done by the next read
+	s.off = offset
+	return offset, nil
+}
+
+func (s *seekableFile) Close() error {
+	if s.rc != nil {
+		s.rc.Close()
+	}
+	return s.File.Close()
+}
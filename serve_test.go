@@ -0,0 +1,121 @@
+package zipper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFileServer(t *testing.T) {
+	body := randomString(t, 100000)
+	for _, tc := range []struct {
+		name string
+		path string
+	}{
+		{"stored", createStoredZip(t, testEntry{name: "docs/big.txt", body: body}, testEntry{name: "docs/a.txt", body: "alpha"})},
+		{"deflated", createZip(t, testEntry{name: "docs/big.txt", body: body}, testEntry{name: "docs/a.txt", body: "alpha"})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys, err := OpenFS(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fsys.Close()
+
+			srv := httptest.NewServer(FileServer(fsys))
+			defer srv.Close()
+
+			status, got := get(t, srv.URL+"/docs/a.txt", "")
+			if status != http.StatusOK || got != "alpha" {
+				t.Errorf("expected 200 alpha, got %d %q", status, got)
+			}
+
+			status, got = get(t, srv.URL+"/docs/big.txt", "bytes=70000-70099")
+			if status != http.StatusPartialContent {
+				t.Fatalf("expected 206, got %d", status)
+			}
+			if got != body[70000:70100] {
+				t.Errorf("range returned the wrong bytes")
+			}
+
+			// seeking back within the same member
+			status, got = get(t, srv.URL+"/docs/big.txt", "bytes=-10")
+			if status != http.StatusPartialContent || got != body[len(body)-10:] {
+				t.Errorf("expected the last 10 bytes, got %d %q", status, got)
+			}
+
+			status, got = get(t, srv.URL+"/docs/", "")
+			if status != http.StatusOK || !strings.Contains(got, "big.txt") {
+				t.Errorf("expected a listing, got %d %q", status, got)
+			}
+
+			if status, _ := get(t, srv.URL+"/missing.txt", ""); status != http.StatusNotFound {
+				t.Errorf("expected 404, got %d", status)
+			}
+		})
+	}
+}
+
+func TestSeekableFile(t *testing.T) {
+	body := randomString(t, 10000)
+	fsys, err := OpenFS(createZip(t, testEntry{name: "a.txt", body: body}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	f, err := fsys.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := f.(io.ReadSeeker)
+	for _, off := range []int64{5000, 100, 9990, 0} {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 10)
+		if _, err := io.ReadFull(s, buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != body[off:off+10] {
+			t.Errorf("at %d expected %q, got %q", off, body[off:off+10], buf)
+		}
+	}
+
+	if n, err := s.Seek(0, io.SeekEnd); err != nil || n != int64(len(body)) {
+		t.Errorf("expected size %d, got %d %v", len(body), n, err)
+	}
+	if _, err := s.Seek(-1, io.SeekStart); err == nil {
+		t.Error("expected an error seeking before the start")
+	}
+}
+
+// get fetches url, with a Range header if rng is set, returning the
+// status and body.
+func get(t *testing.T, url, rng string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b)
+}
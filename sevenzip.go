@@ -0,0 +1,84 @@
+package zipper
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+
+	"github.com/bodgit/sevenzip"
+)
+
+var sevenZipMagic = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}
+
+// is7z reports whether head starts with the 7z signature.
+func is7z(head []byte) bool {
+	return bytes.HasPrefix(head, sevenZipMagic)
+}
+
+// extract7z writes the entries of the 7z archive at src below dest.
+// 7z records no compressed sizes per entry, so MaxRatio applies to the
+// archive as a whole.
+func extract7z(src, dest string, o *options) error {
+	r, err := sevenzip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	e, err := newExtractor(dest, o)
+	if err != nil {
+		return err
+	}
+	defer e.abort()
+	e.input = &size
+	for i, f := range r.File {
+		if err := o.limits.checkEntry(f.Name, i+1); err != nil {
+			return err
+		}
+
+		if !o.selectedEntry(f.Name) {
+			continue
+		}
+
+		ent := entry{
+			name:       f.Name,
+			mode:       f.Mode(),
+			modified:   f.Modified,
+			size:       int64(f.UncompressedSize),
+			compressed: -1,
+		}
+		if err := e.extract(ent, f.Open); err != nil {
+			return err
+		}
+	}
+
+	return e.finish()
+}
+
+// specialModes names the file types that cannot be extracted.
+var specialModes = []struct {
+	mode   fs.FileMode
+	detail string
+}{
+	{fs.ModeCharDevice, "character device"},
+	{fs.ModeDevice, "block device"},
+	{fs.ModeNamedPipe, "named pipe"},
+	{fs.ModeSocket, "socket"},
+}
+
+// unsupportedMode reports the entry as unsupported if its mode is a
+// special file type.
+func unsupportedMode(name string, mode fs.FileMode) []Unsupported {
+	for _, m := range specialModes {
+		if mode&m.mode != 0 {
+			return []Unsupported{{Entry: name, Feature: FeatureEntryType, Detail: m.detail, Skipped: true}}
+		}
+	}
+	return nil
+}
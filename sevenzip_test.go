@@ -0,0 +1,57 @@
+package zipper
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bodgit/sevenzip"
+)
+
+func TestExtract7z(t *testing.T) {
+	const src = "testdata/lzma2.7z"
+
+	dest := t.TempDir()
+	if err := Extract(src, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := sevenzip.OpenReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if len(r.File) == 0 {
+		t.Fatal("expected entries in the test archive")
+	}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(dest, f.Name))
+		if err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+		} else if string(got) != string(want) {
+			t.Errorf("%s: contents differ", f.Name)
+		}
+	}
+}
+
+func TestExtract7zLimits(t *testing.T) {
+	err := Extract("testdata/lzma2.7z", t.TempDir(), WithLimits(Limits{MaxEntries: 3}))
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxEntries" {
+		t.Errorf("expected a MaxEntries limit error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package zipper
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithSelfExtractor makes Zip prepend the executable at stubPath to the
+// archive, producing a self-extracting file that recipients can run
+// without any zip tooling. The stub is normally cmd/zipper-sfx built for
+// the recipient's platform, e.g.
+//
+//	GOOS=windows GOARCH=amd64 go build -ldflags="-s -w" ./cmd/zipper-sfx
+//
+// The result is still a valid zip file that ordinary readers open. Zip
+// names it "<name>.exe" if the stub is a Windows executable and
+// "<name>.sfx" otherwise, and marks it executable. It cannot be combined
+// with WithSplitSize.
+func WithSelfExtractor(stubPath string) Option {
+	return func(o *options) {
+		o.sfxStub = stubPath
+	}
+}
+
+// sfxExt returns the file extension of a self-extractor built from stub.
+func sfxExt(stub string) string {
+	if strings.EqualFold(filepath.Ext(stub), ".exe") {
+		return ".exe"
+	}
+	return ".sfx"
+}
+
+// writeStub copies the extractor stub to the start of w and returns its
+// size, the offset at which the archive begins.
+func writeStub(w io.Writer, stub string) (int64, error) {
+	f, err := os.Open(stub)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, f)
+}
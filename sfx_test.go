@@ -0,0 +1,86 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipSelfExtractor(t *testing.T) {
+	stub := []byte("#!/bin/sh\necho stub\nexit 0\n")
+	stubPath := filepath.Join(t.TempDir(), "stub")
+	if err := os.WriteFile(stubPath, stub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(t.TempDir(), "sfx-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	sfxPath, err := Zip(src, WithSelfExtractor(stubPath))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(sfxPath)
+
+	if sfxPath != "sfx-src.sfx" {
+		t.Errorf("expected sfx-src.sfx, got %s", sfxPath)
+	}
+
+	data, err := os.ReadFile(sfxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, stub) {
+		t.Error("expected the archive to start with the stub")
+	}
+
+	// the central directory offsets account for the stub
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected a readable archive: %v", err)
+	}
+	if off, err := r.File[0].DataOffset(); err != nil || off <= int64(len(stub)) {
+		t.Errorf("expected data after the stub, got offset %d (%v)", off, err)
+	}
+
+	if err := Verify(sfxPath); err != nil {
+		t.Errorf("unexpected verify error: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(sfxPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	info, err := os.Stat(sfxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected an executable file, got %v", info.Mode())
+	}
+}
+
+func TestSelfExtractorSplit(t *testing.T) {
+	_, err := Zip(t.TempDir(), WithSelfExtractor("stub"), WithSplitSize(minSplitSize))
+	if err == nil {
+		t.Error("expected an error combining a self-extractor with split volumes")
+	}
+}
+
+func TestSfxExt(t *testing.T) {
+	for stub, want := range map[string]string{
+		"zipper-sfx":     ".sfx",
+		"zipper-sfx.exe": ".exe",
+		"STUB.EXE":       ".exe",
+	} {
+		if got := sfxExt(stub); got != want {
+			t.Errorf("sfxExt(%q) = %q, want %q", stub, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package zipper
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+	zipPath := zipAside(t, src)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := Sign(zipPath, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(zipPath + SignatureSuffix)
+	if err != nil || !bytes.Equal(written, sig) {
+		t.Errorf("expected the signature written beside the archive, got %x (%v)", written, err)
+	}
+	if err := VerifySignature(zipPath, sig, pub); err != nil {
+		t.Errorf("expected the signature to verify, got %v", err)
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignature(zipPath, sig, other); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected another key to be refused, got %v", err)
+	}
+
+	f, err := os.OpenFile(zipPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("tampered")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := VerifySignature(zipPath, sig, pub); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected a changed archive to be refused, got %v", err)
+	}
+
+	if _, err := Sign(zipPath, priv[:10]); err == nil {
+		t.Error("expected a malformed key to be refused")
+	}
+}
@@ -0,0 +1,34 @@
+package zipper
+
+import "io"
+
+// Sink is a destination other than the local disk that Zip streams
+// archives to, such as object storage. The s3 subpackage provides one for
+// Amazon S3 and compatible services.
+type Sink interface {
+	// Create starts writing the archive with the given file name.
+	Create(name string) (Upload, error)
+}
+
+// Upload is an archive being written to a Sink.
+type Upload interface {
+	io.Writer
+
+	// Commit makes the archive available once everything has been
+	// written.
+	Commit() error
+
+	// Abort discards everything written so far.
+	Abort()
+}
+
+// WithSink makes Zip and Convert stream the archive to s rather than
+// create a file; the path they return is the name given to s. The
+// archive itself never touches the local disk, although entries too
+// large to compress in memory still spill to temporary files while they
+// are compressed. It cannot be combined with WithSplitSize.
+func WithSink(s Sink) Option {
+	return func(o *options) {
+		o.sink = s
+	}
+}
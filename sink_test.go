@@ -0,0 +1,79 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memorySink keeps committed archives in memory.
+type memorySink struct {
+	archives map[string][]byte
+	aborted  int
+}
+
+func (s *memorySink) Create(name string) (Upload, error) {
+	return &memoryUpload{s: s, name: name}, nil
+}
+
+type memoryUpload struct {
+	bytes.Buffer
+	s    *memorySink
+	name string
+}
+
+func (u *memoryUpload) Commit() error {
+	if u.s.archives == nil {
+		u.s.archives = make(map[string][]byte)
+	}
+	u.s.archives[u.name] = u.Bytes()
+	return nil
+}
+
+func (u *memoryUpload) Abort() {
+	u.s.aborted++
+}
+
+func TestZipSink(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "sink-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	sink := &memorySink{}
+	name, err := Zip(src, WithSink(sink))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("expected no local archive")
+	}
+
+	data := sink.archives["sink-src.zip"]
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(zr.File))
+	}
+}
+
+func TestZipSinkAbort(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "sink-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	// a missing stub fails the archive after the upload has started
+	sink := &memorySink{}
+	if _, err := Zip(src, WithSink(sink), WithSelfExtractor(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Fatal("expected an error")
+	}
+	if sink.aborted != 1 || len(sink.archives) != 0 {
+		t.Errorf("expected an aborted upload, got %d aborted and %d archives", sink.aborted, len(sink.archives))
+	}
+
+	if _, err := Zip(src, WithSink(sink), WithSplitSize(minSplitSize)); err == nil {
+		t.Error("expected an error splitting an archive written to a sink")
+	}
+}
@@ -0,0 +1,223 @@
+package zipper
+
+import (
+	"io"
+	"math"
+	"os"
+)
+
+// WithSparse makes Zip detect sparse files, such as virtual machine
+// images, and read only their data regions, never the holes between
+// them, which are compressed as the zeros they read as. Each entry still
+// holds the whole file. The layout is recorded in the embedded manifest,
+// which WithSparse therefore enables, as a hint for Unzip to leave the
+// holes unallocated where the file system supports it.
+//
+// Holes are detected on Linux and FreeBSD, on file systems that report
+// them.
+func WithSparse() Option {
+	return func(o *options) {
+		o.sparse = true
+		o.manifest = true
+	}
+}
+
+// SparseRegion is a run of data in a sparse file. The rest of the file
+// reads as zeros.
+type SparseRegion struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// sparseRegions returns the data regions of f, which is size bytes long,
+// or nil if it has no holes or they cannot be detected.
+func sparseRegions(f *os.File, size int64) []SparseRegion {
+	regions, err := dataRegions(f, size)
+	if err != nil {
+		return nil
+	}
+
+	var data int64
+	for _, r := range regions {
+		data += r.Length
+	}
+	if data == size {
+		return nil
+	}
+
+	// a file that is one hole still needs its layout recorded
+	if len(regions) == 0 {
+		regions = []SparseRegion{{}}
+	}
+	return regions
+}
+
+// validSparse reports whether regions are in order, do not overlap and
+// lie within a file of size bytes.
+func validSparse(regions []SparseRegion, size int64) bool {
+	var end int64
+	for _, r := range regions {
+		if r.Offset < end || r.Length < 0 || r.Offset > size-r.Length {
+			return false
+		}
+		end = r.Offset + r.Length
+	}
+	return true
+}
+
+// sparseReader reads a sparse file in full, reading only its data
+// regions from the file and zeros for the holes between them.
+type sparseReader struct {
+	f       io.ReaderAt
+	regions []SparseRegion
+	size    int64
+
+	pos int64
+	cur *io.SectionReader
+}
+
+func newSparseReader(f io.ReaderAt, regions []SparseRegion, size int64) *sparseReader {
+	return &sparseReader{f: f, regions: regions, size: size}
+}
+
+func (s *sparseReader) Read(p []byte) (int, error) {
+	for {
+		if s.cur != nil {
+			n, err := s.cur.Read(p)
+			s.pos += int64(n)
+			if err == io.EOF {
+				s.cur = nil
+				if n == 0 {
+					continue
+				}
+				err = nil
+			}
+			return n, err
+		}
+
+		// the hole up to the next region, or the end of the file
+		next := s.size
+		if len(s.regions) > 0 {
+			next = s.regions[0].Offset
+		}
+		if s.pos < next {
+			n := int(min(int64(len(p)), next-s.pos))
+			clear(p[:n])
+			s.pos += int64(n)
+			return n, nil
+		}
+		if len(s.regions) == 0 {
+			return 0, io.EOF
+		}
+
+		r := s.regions[0]
+		s.regions = s.regions[1:]
+		s.cur = io.NewSectionReader(s.f, r.Offset, r.Length)
+	}
+}
+
+// sparseLayout is the layout of a sparse file entry.
+type sparseLayout struct {
+	regions []SparseRegion
+	size    int64
+}
+
+// fits reports whether the layout is a well formed one for an entry of
+// size bytes, or of unknown size if negative.
+func (l *sparseLayout) fits(size int64) bool {
+	return l != nil && (size < 0 || size == l.size) && validSparse(l.regions, l.size)
+}
+
+// sparseLayouts returns the layouts of the sparse files in m by name.
+func sparseLayouts(m *Manifest) map[string]*sparseLayout {
+	layouts := make(map[string]*sparseLayout)
+	for _, e := range m.Entries {
+		if e.Sparse != nil && e.Duplicate == "" {
+			layouts[e.Name] = &sparseLayout{regions: e.Sparse, size: int64(e.Size)}
+		}
+	}
+	return layouts
+}
+
+// sparseFile is a file holes can be left in by seeking past them.
+type sparseFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+}
+
+// holeChunk is how much of a hole writeSparse checks for zeros at a time.
+const holeChunk = 64 << 10
+
+// writeSparse copies the whole file read from r to f, seeking past the
+// zeros in the holes between the data regions rather than writing them,
+// so they stay unallocated. The regions are only a hint: whatever a hole
+// holds other than zeros is written like data.
+func writeSparse(f sparseFile, r io.Reader, regions []SparseRegion) error {
+	var pos int64
+	buf := make([]byte, holeChunk)
+	for i := 0; ; i++ {
+		// past the last region, the rest of the file is a hole
+		region := SparseRegion{Offset: math.MaxInt64}
+		if i < len(regions) {
+			region = regions[i]
+		}
+
+		n, err := skipHole(f, r, region.Offset-pos, buf)
+		pos += n
+		if err == io.EOF {
+			return f.Truncate(pos)
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err = io.CopyN(f, r, region.Length)
+		pos += n
+		if err == io.EOF {
+			return f.Truncate(pos)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipHole copies up to n bytes of a hole from r to f, seeking past the
+// chunks that are all zeros. It returns the number of bytes copied, and
+// io.EOF if r ended first.
+func skipHole(f sparseFile, r io.Reader, n int64, buf []byte) (int64, error) {
+	var done int64
+	for done < n {
+		chunk := buf[:min(int64(len(buf)), n-done)]
+		k, err := io.ReadFull(r, chunk)
+		if k > 0 {
+			var werr error
+			if allZeros(chunk[:k]) {
+				_, werr = f.Seek(int64(k), io.SeekCurrent)
+			} else {
+				_, werr = f.Write(chunk[:k])
+			}
+			if werr != nil {
+				return done, werr
+			}
+			done += int64(k)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return done, io.EOF
+		}
+		if err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// allZeros reports whether p holds only zeros.
+func allZeros(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
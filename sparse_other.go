@@ -0,0 +1,13 @@
+//go:build !linux && !freebsd
+
+package zipper
+
+import (
+	"errors"
+	"os"
+)
+
+// dataRegions cannot find holes on this platform.
+func dataRegions(f *os.File, size int64) ([]SparseRegion, error) {
+	return nil, errors.ErrUnsupported
+}
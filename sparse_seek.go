@@ -0,0 +1,49 @@
+//go:build linux || freebsd
+
+package zipper
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// whence values of lseek finding data and holes
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// dataRegions returns the data regions of f, which is size bytes long,
+// leaving f at its start.
+func dataRegions(f *os.File, size int64) ([]SparseRegion, error) {
+	var regions []SparseRegion
+	for off := int64(0); off < size; {
+		data, err := f.Seek(off, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			// only a hole remains
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if data >= size {
+			break
+		}
+
+		hole, err := f.Seek(data, seekHole)
+		if err != nil {
+			return nil, err
+		}
+		hole = min(hole, size)
+
+		regions = append(regions, SparseRegion{Offset: data, Length: hole - data})
+		off = hole
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
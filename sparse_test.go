@@ -0,0 +1,152 @@
+package zipper
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// createSparse writes a file of size bytes holding data at the given
+// offsets and holes elsewhere.
+func createSparse(t *testing.T, path string, size int64, data map[int64]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for off, s := range data {
+		if _, err := f.WriteAt([]byte(s), off); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// holes returns the data regions of the file at path, or nil if it has
+// no holes.
+func holes(t *testing.T, path string) []SparseRegion {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sparseRegions(f, info.Size())
+}
+
+func TestSparse(t *testing.T) {
+	const size = 16 << 20
+
+	src := filepath.Join(t.TempDir(), "images")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(src, "disk.img")
+	createSparse(t, image, size, map[int64]string{0: "boot", 8 << 20: "data"})
+	if holes(t, image) == nil {
+		t.Skip("file system does not report holes")
+	}
+
+	zipPath, err := Zip(src, WithSparse())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	// the entry holds the whole file, holes included, for any reader
+	want, err := os.ReadFile(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := zipptest.ReadArchive(t, zipPath)["disk.img"]; !bytes.Equal(got, want) {
+		t.Errorf("expected the whole file stored, got %d bytes", len(got))
+	}
+
+	if report, err := CompareDir(zipPath, src); err != nil || !report.Equal() {
+		t.Errorf("expected the archive to match the source, got %+v (%v)", report, err)
+	}
+
+	_, m := readManifest(t, zipPath)
+	if e := m.Entries[0]; e.Size != size || len(e.Sparse) == 0 {
+		t.Errorf("expected the layout of a %d byte file, got %+v", size, e)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+	if err := VerifyManifest(zipPath, dest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if holes(t, filepath.Join(dest, "disk.img")) == nil {
+		t.Error("expected the extracted file to be sparse")
+	}
+}
+
+func TestSparseReader(t *testing.T) {
+	file := []byte("ab\x00\x00\x00cde\x00\x00")
+	regions := []SparseRegion{{Offset: 0, Length: 2}, {Offset: 5, Length: 3}}
+
+	// the holes are read as zeros, whatever the file holds there
+	onDisk := []byte("abXXXcdeXX")
+	got, err := io.ReadAll(newSparseReader(bytes.NewReader(onDisk), regions, int64(len(file))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, file) {
+		t.Errorf("expected %q, got %q", file, got)
+	}
+}
+
+func TestWriteSparse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		regions []SparseRegion
+	}{
+		{"holes", "ab\x00\x00\x00cde\x00\x00", []SparseRegion{{0, 2}, {5, 3}}},
+		{"all hole", "\x00\x00\x00\x00", []SparseRegion{{}}},
+		{"data in a hole", "ab\x00x\x00cde\x00y", []SparseRegion{{0, 2}, {5, 3}}},
+		{"shorter than the layout", "ab\x00", []SparseRegion{{0, 2}, {5, 3}}},
+		{"longer than the layout", "ab\x00\x00\x00cde\x00\x00fg", []SparseRegion{{0, 2}, {5, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if err := writeSparse(f, strings.NewReader(tt.data), tt.regions); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.data {
+				t.Errorf("expected %q, got %q", tt.data, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// SpecialFilePolicy selects what is done with named pipes, sockets and
+// device nodes, which have no contents to archive or extract.
+type SpecialFilePolicy int
+
+const (
+	// SpecialSkip leaves special files out. Zip records those it finds
+	// in the Report's Skipped, and extraction those in the archive in its
+	// Unsupported, failing without a report as for other entries it
+	// cannot extract. It is the default.
+	SpecialSkip SpecialFilePolicy = iota
+
+	// SpecialError fails on the first special file, report or not.
+	SpecialError
+
+	// SpecialRecreate makes extraction create named pipes, and device
+	// nodes when run as root from tar entries recording their numbers,
+	// instead of skipping them. Sockets, and device nodes it cannot
+	// create, are skipped as by SpecialSkip, as are the special files Zip
+	// finds, which it cannot archive.
+	SpecialRecreate
+
+	// SpecialRead makes Zip archive named pipes as regular files holding
+	// what is read from them until EOF, as zip's --fifo does, which
+	// WithFileTimeout can bound. Other special files, and the special
+	// entries of archives, are skipped as by SpecialSkip.
+	SpecialRead
+)
+
+func (p SpecialFilePolicy) String() string {
+	switch p {
+	case SpecialSkip:
+		return "skip"
+	case SpecialError:
+		return "error"
+	case SpecialRecreate:
+		return "recreate"
+	case SpecialRead:
+		return "read"
+	default:
+		return fmt.Sprintf("SpecialFilePolicy(%d)", int(p))
+	}
+}
+
+// WithSpecialFiles sets what Zip does with the special files it finds,
+// and extraction with the special file entries of an archive.
+func WithSpecialFiles(policy SpecialFilePolicy) Option {
+	return func(o *options) {
+		o.specialFiles = policy
+	}
+}
+
+// specialTypes are the mode bits of special files.
+const specialTypes = fs.ModeNamedPipe | fs.ModeSocket | fs.ModeDevice | fs.ModeCharDevice
+
+// specialType names the type of special file mode is, or "" if it is not
+// one.
+func specialType(mode fs.FileMode) string {
+	for _, m := range specialModes {
+		if mode&m.mode != 0 {
+			return m.detail
+		}
+	}
+	return ""
+}
+
+// device is the major and minor numbers of a device node.
+type device struct {
+	major, minor uint32
+}
+
+// specialError returns the error for a special file, named name, that
+// cannot be handled.
+func specialError(op, name, kind string) error {
+	return &PathError{Op: op, Entry: name, Err: fmt.Errorf("%s: %w", kind, errors.ErrUnsupported)}
+}
+
+// skipSpecial reports whether the entry f, a special file, is to be left
+// out rather than recreated, recording it as unsupported if so.
+func (e *extractor) skipSpecial(f entry) (bool, error) {
+	kind := specialType(f.mode)
+	switch {
+	case e.o.specialFiles == SpecialError:
+		return false, specialError("extract", f.name, kind)
+	case e.o.specialFiles == SpecialRecreate && e.o.onDisk() && canRecreate(f):
+		return false, nil
+	}
+
+	_, err := e.o.recordUnsupported([]Unsupported{{Entry: f.name, Feature: FeatureEntryType, Detail: kind, Skipped: true}})
+	return true, err
+}
+
+// canRecreate reports whether the special file entry f can be created.
+func canRecreate(f entry) bool {
+	switch {
+	case !mknodSupported:
+		return false
+	case f.mode&fs.ModeNamedPipe != 0:
+		return true
+	case f.mode&fs.ModeDevice != 0:
+		return f.device != nil && os.Geteuid() == 0
+	default:
+		return false
+	}
+}
+
+// extractSpecial creates the special file for the entry f at path.
+func (e *extractor) extractSpecial(f entry, path string) error {
+	perm, _ := e.o.filePerm(f)
+	if err := mknod(path, f.mode.Type()|perm, f.device); err != nil {
+		return err
+	}
+	e.record(path)
+	if err := e.chown(f, path); err != nil {
+		return err
+	}
+	e.extracted(f, path)
+	return nil
+}
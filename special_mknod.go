@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package zipper
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// mknodSupported is set where special files can be created.
+const mknodSupported = true
+
+// mknod creates the named pipe or device node at path with mode.
+func mknod(path string, mode fs.FileMode, dev *device) error {
+	perm := uint32(mode.Perm())
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return unix.Mkfifo(path, perm)
+	case mode&fs.ModeCharDevice != 0:
+		return unix.Mknod(path, unix.S_IFCHR|perm, int(unix.Mkdev(dev.major, dev.minor)))
+	default:
+		return unix.Mknod(path, unix.S_IFBLK|perm, int(unix.Mkdev(dev.major, dev.minor)))
+	}
+}
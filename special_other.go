@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package zipper
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// mknodSupported is set where special files can be created.
+const mknodSupported = false
+
+// mknod cannot create special files on this platform.
+func mknod(path string, mode fs.FileMode, dev *device) error {
+	return errors.ErrUnsupported
+}
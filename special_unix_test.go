@@ -0,0 +1,87 @@
+//go:build unix
+
+package zipper
+
+import (
+	"archive/tar"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipSpecialFiles(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "special")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	fifo := filepath.Join(src, "pipe")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("cannot make a named pipe: %v", err)
+	}
+
+	var report Report
+	files := zipptest.ReadArchive(t, zipAside(t, src, WithReport(&report)))
+	if len(files) != 1 || files["pipe"] != nil {
+		t.Errorf("expected the pipe left out, got %q", files)
+	}
+	want := Skipped{Entry: "pipe", Reason: "named pipe"}
+	if len(report.Skipped) != 1 || report.Skipped[0] != want {
+		t.Errorf("expected %v reported, got %v", want, report.Skipped)
+	}
+
+	if _, err := Zip(src, WithSpecialFiles(SpecialError), WithOutput(t.TempDir()+"/")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected the pipe to fail the archive, got %v", err)
+	}
+
+	for _, format := range []Format{FormatZip, FormatTarGz} {
+		go func() {
+			if f, err := os.OpenFile(fifo, os.O_WRONLY, 0); err == nil {
+				f.WriteString("piped")
+				f.Close()
+			}
+		}()
+
+		out := filepath.Join(t.TempDir(), "out"+format.ext())
+		if _, err := Zip(src, WithFormat(format), WithSpecialFiles(SpecialRead), WithFileTimeout(5*time.Second), WithOutput(out)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		dest := t.TempDir()
+		if err := Extract(out, dest); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dest, "pipe"))
+		if err != nil || string(data) != "piped" {
+			t.Errorf("%s: expected the pipe's contents archived, got %q (%v)", format, data, err)
+		}
+	}
+}
+
+func TestExtractSpecialFiles(t *testing.T) {
+	tarPath := createTar(t, gzipCompress,
+		tarTestEntry{tar.TypeFifo, "pipe", ""},
+		tarTestEntry{tar.TypeReg, "a.txt", "alpha"},
+	)
+
+	var report Report
+	err := Extract(tarPath, t.TempDir(), WithSpecialFiles(SpecialError), WithReport(&report))
+	var pe *PathError
+	if !errors.As(err, &pe) || pe.Entry != "pipe" || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected the pipe to fail extraction, got %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Extract(tarPath, dest, WithSpecialFiles(SpecialRecreate)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(dest, "pipe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Type() != fs.ModeNamedPipe {
+		t.Errorf("expected a named pipe, got %v", info.Mode())
+	}
+}
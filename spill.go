@@ -0,0 +1,79 @@
+package zipper
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// spillThreshold is how much compressed data a spillBuffer holds in
+// memory before moving it to a temporary file.
+const spillThreshold = 1 << 20
+
+// spillPrefix starts the names of the temporary files spillBuffers spill
+// to, in os.TempDir.
+const spillPrefix = "zipper-spill-"
+
+// spillBuffer collects compressed entry data ahead of it being written,
+// keeping small entries in memory and spilling large ones to disk so
+// concurrent compression does not hold whole files in memory.
+type spillBuffer struct {
+	limit int
+	mem   bytes.Buffer
+	file  *os.File
+	size  int64
+}
+
+func newSpillBuffer(limit int) *spillBuffer {
+	return &spillBuffer{limit: limit}
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.mem.Len()+len(p) > b.limit {
+		f, err := os.CreateTemp("", spillPrefix+"*")
+		if err != nil {
+			return 0, err
+		}
+		b.file = f
+
+		if _, err := b.mem.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// Len returns the number of bytes written.
+func (b *spillBuffer) Len() int64 {
+	return b.size
+}
+
+// Reader returns a reader over everything written so far.
+func (b *spillBuffer) Reader() (io.Reader, error) {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes()), nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return b.file, nil
+}
+
+// Release frees the buffer, removing any temporary file.
+func (b *spillBuffer) Release() {
+	b.mem = bytes.Buffer{}
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+}
@@ -0,0 +1,213 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// minSplitSize is the smallest volume size accepted by WithSplitSize.
+const minSplitSize = 64 << 10
+
+// WithSplitSize makes Zip split the archive into volumes of at most size
+// bytes, named like "archive.z01", "archive.z02" and so on, with the last
+// volume named "archive.zip". Unzip reads such split archives when given
+// the path of the last volume.
+//
+// The volumes are a plain byte-level split of one archive, so
+// concatenating them in order ("cat archive.z?? archive.zip") gives a
+// standard zip file. Sizes below 64 KiB are raised to 64 KiB.
+func WithSplitSize(size int64) Option {
+	return func(o *options) {
+		o.splitSize = max(size, minSplitSize)
+	}
+}
+
+// volumePath returns the path of the nth volume (from 1) of the split
+// archive whose last volume is zipPath.
+func volumePath(zipPath string, n int) string {
+	return fmt.Sprintf("%s.z%02d", strings.TrimSuffix(zipPath, ".zip"), n)
+}
+
+// splitOutput writes an archive across fixed-size volumes.
+type splitOutput struct {
+	dstPath string
+	size    int64
+
+	cur     *os.File
+	written int64 // bytes in the current volume
+	volumes []string
+	sync    bool // flush to stable storage, for WithSync
+}
+
+func newSplitOutput(dstPath string, size int64) *splitOutput {
+	return &splitOutput{dstPath: dstPath, size: size}
+}
+
+func (s *splitOutput) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		if s.cur == nil || s.written == s.size {
+			if err := s.next(); err != nil {
+				return total, err
+			}
+		}
+
+		chunk := p
+		if room := s.size - s.written; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+
+		n, err := s.cur.Write(chunk)
+		total += n
+		s.written += int64(n)
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+// next closes the current volume and starts the following one.
+func (s *splitOutput) next() error {
+	if s.cur != nil {
+		if err := syncClose(s.cur, s.sync); err != nil {
+			return err
+		}
+	}
+
+	path := volumePath(s.dstPath, len(s.volumes)+1)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	s.cur = f
+	s.written = 0
+	s.volumes = append(s.volumes, path)
+	return nil
+}
+
+// Commit closes the final volume and gives it the archive's name.
+func (s *splitOutput) Commit() error {
+	if s.cur == nil {
+		return errors.New("split archive is empty")
+	}
+	if err := syncClose(s.cur, s.sync); err != nil {
+		return err
+	}
+	s.cur = nil
+
+	last := s.volumes[len(s.volumes)-1]
+	if err := renameSynced(last, s.dstPath, s.sync); err != nil {
+		return err
+	}
+	s.volumes[len(s.volumes)-1] = s.dstPath
+	return nil
+}
+
+func (s *splitOutput) Abort() {
+	if s.cur != nil {
+		s.cur.Close()
+	}
+	for _, path := range s.volumes {
+		os.Remove(path)
+	}
+}
+
+// openVolumes opens the volumes of a split archive whose last volume is
+// zipPath, returning nil if it is not split.
+func openVolumes(zipPath string) (*multiReaderAt, error) {
+	if _, err := os.Stat(volumePath(zipPath, 1)); err != nil {
+		return nil, nil
+	}
+
+	m := &multiReaderAt{}
+	for n := 1; ; n++ {
+		path := volumePath(zipPath, n)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			path = zipPath
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			m.Close()
+			return nil, err
+		}
+
+		m.add(f, info.Size())
+		if path == zipPath {
+			return m, nil
+		}
+	}
+}
+
+// multiReaderAt presents several files as one contiguous io.ReaderAt.
+type multiReaderAt struct {
+	files   []*os.File
+	offsets []int64 // start of each file
+	size    int64
+}
+
+func (m *multiReaderAt) add(f *os.File, size int64) {
+	m.files = append(m.files, f)
+	m.offsets = append(m.offsets, m.size)
+	m.size += size
+}
+
+func (m *multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		if off >= m.size {
+			return total, io.EOF
+		}
+
+		// find the file holding off
+		i := len(m.offsets) - 1
+		for m.offsets[i] > off {
+			i--
+		}
+
+		end := m.size
+		if i+1 < len(m.offsets) {
+			end = m.offsets[i+1]
+		}
+
+		chunk := p
+		if room := end - off; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+
+		n, err := m.files[i].ReadAt(chunk, off-m.offsets[i])
+		total += n
+		off += int64(n)
+		p = p[n:]
+		if err != nil && err != io.EOF {
+			return total, err
+		}
+		if n < len(chunk) {
+			return total, io.ErrUnexpectedEOF
+		}
+	}
+	return total, nil
+}
+
+func (m *multiReaderAt) Close() error {
+	var err error
+	for _, f := range m.files {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipSplitRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "split-src")
+	writeTree(t, src, map[string]string{
+		"a.bin":     randomString(t, 100<<10),
+		"dir/b.bin": randomString(t, 80<<10),
+		"c.txt":     "small",
+	})
+
+	zipPath, err := Zip(src, WithSplitSize(minSplitSize))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Remove(zipPath)
+		for n := 1; n < 10; n++ {
+			os.Remove(volumePath(zipPath, n))
+		}
+	})
+
+	// collect the volumes in order, ending with the .zip
+	var volumes []string
+	for n := 1; ; n++ {
+		if _, err := os.Stat(volumePath(zipPath, n)); err != nil {
+			break
+		}
+		volumes = append(volumes, volumePath(zipPath, n))
+	}
+	volumes = append(volumes, zipPath)
+
+	if len(volumes) < 3 {
+		t.Fatalf("expected at least 3 volumes, got %v", volumes)
+	}
+
+	var joined bytes.Buffer
+	for i, v := range volumes {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > minSplitSize {
+			t.Errorf("%s: %d bytes exceeds the volume size", v, len(data))
+		}
+		if i < len(volumes)-1 && len(data) != minSplitSize {
+			t.Errorf("%s: expected a full volume, got %d bytes", v, len(data))
+		}
+		joined.Write(data)
+	}
+
+	// the volumes concatenate into a standard archive
+	if _, err := zip.NewReader(bytes.NewReader(joined.Bytes()), int64(joined.Len())); err != nil {
+		t.Errorf("concatenated volumes are not a valid archive: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	if err := Verify(zipPath); err != nil {
+		t.Errorf("unexpected verify error: %v", err)
+	}
+}
+
+func TestSplitOutputAbort(t *testing.T) {
+	dstPath := filepath.Join(t.TempDir(), "out.zip")
+	s := newSplitOutput(dstPath, 4)
+
+	if _, err := s.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.volumes) != 3 {
+		t.Fatalf("expected 3 volumes, got %v", s.volumes)
+	}
+
+	s.Abort()
+	for _, v := range s.volumes {
+		if _, err := os.Stat(v); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", v)
+		}
+	}
+}
+
+func TestMultiReaderAt(t *testing.T) {
+	dir := t.TempDir()
+	m := &multiReaderAt{}
+	for i, part := range []string{"abc", "defg", "hi"} {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(path, []byte(part), 0644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.add(f, int64(len(part)))
+	}
+	defer m.Close()
+
+	buf := make([]byte, 6)
+	n, err := m.ReadAt(buf, 2)
+	if err != nil || string(buf[:n]) != "cdefgh" {
+		t.Errorf("expected cdefgh, got %q (%v)", buf[:n], err)
+	}
+
+	n, err = m.ReadAt(buf, 7)
+	if err != io.EOF || string(buf[:n]) != "hi" {
+		t.Errorf("expected hi and EOF, got %q (%v)", buf[:n], err)
+	}
+}
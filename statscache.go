@@ -0,0 +1,188 @@
+package zipper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	// minSamples is how many observations of an extension are needed
+	// before the cache influences MethodAuto.
+	minSamples = 3
+
+	// storeRatio is the compressed to uncompressed ratio above which an
+	// extension is considered not worth compressing.
+	storeRatio = 0.95
+
+	// resampleEvery makes every nth entry of an incompressible extension
+	// be compressed anyway, so the cache keeps learning.
+	resampleEvery = 10
+)
+
+// ExtensionStats is the compression observed for one file extension.
+type ExtensionStats struct {
+	Extension    string `json:"extension"`
+	Samples      int    `json:"samples"`
+	Uncompressed uint64 `json:"uncompressed"`
+	Compressed   uint64 `json:"compressed"`
+}
+
+// Ratio returns compressed size over uncompressed size, so lower is
+// better and 1 means no saving.
+func (s ExtensionStats) Ratio() float64 {
+	if s.Uncompressed == 0 {
+		return 1
+	}
+	return float64(s.Compressed) / float64(s.Uncompressed)
+}
+
+// StatsCache records compression ratios observed per file extension, so
+// MethodAuto learns which kinds of files on a machine are not worth
+// compressing. It is safe for concurrent use.
+type StatsCache struct {
+	path string
+
+	mu      sync.Mutex
+	exts    map[string]*ExtensionStats
+	skipped map[string]int
+}
+
+// DefaultStatsCachePath returns the per-user location of the cache.
+func DefaultStatsCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "zipper", "ratios.json"), nil
+}
+
+// LoadStatsCache reads the cache stored at path. A missing file yields an
+// empty cache which Save will create.
+func LoadStatsCache(path string) (*StatsCache, error) {
+	c := &StatsCache{
+		path:    path,
+		exts:    make(map[string]*ExtensionStats),
+		skipped: make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []ExtensionStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		c.exts[stats[i].Extension] = &stats[i]
+	}
+
+	return c, nil
+}
+
+// WithStatsCache makes MethodAuto consult and update c. Call c.Save to
+// persist what was learned.
+func WithStatsCache(c *StatsCache) Option {
+	return func(o *options) {
+		o.stats = c
+	}
+}
+
+// Stats returns the observations for every extension, sorted by
+// extension.
+func (c *StatsCache) Stats() []ExtensionStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]ExtensionStats, 0, len(c.exts))
+	for _, s := range c.exts {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Extension < stats[j].Extension })
+	return stats
+}
+
+// Reset forgets every observation. Call Save to persist the reset.
+func (c *StatsCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.exts = make(map[string]*ExtensionStats)
+	c.skipped = make(map[string]int)
+}
+
+// Save writes the cache back to the path it was loaded from.
+func (c *StatsCache) Save() error {
+	data, err := json.MarshalIndent(c.Stats(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// replace the file atomically so concurrent runs never read a torn
+	// cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".ratios-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// observe records the compression achieved for an entry with extension
+// ext. It is a no-op on a nil cache.
+func (c *StatsCache) observe(ext string, uncompressed, compressed uint64) {
+	if c == nil || uncompressed == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.exts[ext]
+	if !ok {
+		s = &ExtensionStats{Extension: ext}
+		c.exts[ext] = s
+	}
+	s.Samples++
+	s.Uncompressed += uncompressed
+	s.Compressed += compressed
+}
+
+// incompressible reports whether entries with extension ext should be
+// stored. It is false on a nil cache.
+func (c *StatsCache) incompressible(ext string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.exts[ext]
+	if !ok || s.Samples < minSamples || s.Ratio() < storeRatio {
+		return false
+	}
+
+	c.skipped[ext]++
+	return c.skipped[ext]%resampleEvery != 0
+}
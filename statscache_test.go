@@ -0,0 +1,82 @@
+package zipper
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStatsCacheIncompressible(t *testing.T) {
+	c, err := LoadStatsCache(filepath.Join(t.TempDir(), "ratios.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < minSamples; i++ {
+		c.observe(".txt", 1000, 200)
+		if i < minSamples-1 {
+			c.observe(".enc", 1000, 1000)
+		}
+	}
+
+	if c.incompressible(".txt") {
+		t.Error(".txt compresses well and should not be stored")
+	}
+	if c.incompressible(".enc") {
+		t.Error("too few samples to decide for .enc")
+	}
+
+	c.observe(".enc", 1000, 1000)
+
+	// incompressible extensions are still sampled every so often
+	stored := 0
+	for i := 0; i < resampleEvery; i++ {
+		if c.incompressible(".enc") {
+			stored++
+		}
+	}
+	if stored != resampleEvery-1 {
+		t.Errorf("expected %d of %d entries stored, got %d", resampleEvery-1, resampleEvery, stored)
+	}
+
+	var nilCache *StatsCache
+	nilCache.observe(".txt", 1, 1)
+	if nilCache.incompressible(".txt") {
+		t.Error("a nil cache should never decide")
+	}
+}
+
+func TestStatsCacheSaveLoadReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "ratios.json")
+
+	c, err := LoadStatsCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.observe(".log", 4000, 400)
+	c.observe(".log", 6000, 600)
+	if err := c.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadStatsCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := loaded.Stats()
+	if len(stats) != 1 || stats[0].Samples != 2 || stats[0].Ratio() != 0.1 {
+		t.Fatalf("unexpected stats after reload: %+v", stats)
+	}
+
+	loaded.Reset()
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reset, err := LoadStatsCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reset.Stats()) != 0 {
+		t.Errorf("expected empty cache after reset, got %+v", reset.Stats())
+	}
+}
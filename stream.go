@@ -0,0 +1,339 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+const (
+	localHeaderSig    = 0x04034b50
+	centralDirSig     = 0x02014b50
+	endDirSig         = 0x06054b50
+	dataDescriptorSig = 0x08074b50
+
+	localHeaderLen = 26 // after the signature
+
+	zip64ExtraID   = 0x0001
+	extTimeExtraID = 0x5455
+)
+
+// UnzipReader extracts a zip archive read sequentially from r, such as
+// stdin or a network stream, into the directory dest without buffering
+// the archive. Entries are read from their local headers as they arrive
+// and the central directory is never consulted, so it trades some
+// fidelity for streaming:
+//
+//   - permissions and symlinks live in the central directory, so files
+//     are created with mode 0644 and symlink entries as regular files
+//     holding their target;
+//   - stored entries written with a trailing data descriptor have no
+//     recorded size and cannot be read;
+//   - the embedded manifest is not consulted, so files stored once by
+//     WithDedup or WithHardLinks are extracted only under the name of the
+//     entry holding their contents.
+//
+// Otherwise the options and safety checks are those of Unzip, except
+// that MaxRatio applies to the stream as a whole and WithComponents is
+// unavailable. CRC-32 checksums are verified as each entry completes.
+func UnzipReader(r io.Reader, dest string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return err
+	}
+	if o.components != nil {
+		return errors.New("selecting components requires the archive's central directory")
+	}
+
+	input := &countingReader{r: r}
+	br := bufio.NewReader(input)
+
+	e, err := newExtractor(dest, o)
+	if err != nil {
+		return err
+	}
+	defer e.abort()
+	e.input = &input.n
+	for count := 1; ; count++ {
+		var sig uint32
+		if err := binary.Read(br, binary.LittleEndian, &sig); err != nil {
+			if err == io.EOF && count > 1 {
+				return e.finish()
+			}
+			return err
+		}
+
+		switch sig {
+		case localHeaderSig:
+		case centralDirSig, endDirSig:
+			return e.finish()
+		default:
+			return zip.ErrFormat
+		}
+
+		h, err := readLocalHeader(br)
+		if err != nil {
+			return err
+		}
+		if err := o.limits.checkEntry(h.name, count); err != nil {
+			return err
+		}
+
+		if err := e.extractStreamed(br, h); err != nil {
+			return err
+		}
+	}
+}
+
+// localHeader is the part of a local file header UnzipReader needs.
+type localHeader struct {
+	flags      uint16
+	method     uint16
+	modified   time.Time
+	ntfs       *fileTimes // the NTFS times, if recorded
+	owner      *owner     // the owner, if recorded
+	crc32      uint32
+	compressed uint64
+	size       uint64
+	name       string
+	zip64      bool
+}
+
+// hasDescriptor reports whether the checksum and sizes follow the data.
+func (h *localHeader) hasDescriptor() bool {
+	return h.flags&0x8 != 0
+}
+
+// readLocalHeader reads a local file header following its signature.
+func readLocalHeader(r io.Reader) (*localHeader, error) {
+	var buf [localHeaderLen]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+
+	le := binary.LittleEndian
+	h := &localHeader{
+		flags:      le.Uint16(buf[2:]),
+		method:     le.Uint16(buf[4:]),
+		modified:   msDosTimeToTime(le.Uint16(buf[8:]), le.Uint16(buf[6:])),
+		crc32:      le.Uint32(buf[10:]),
+		compressed: uint64(le.Uint32(buf[14:])),
+		size:       uint64(le.Uint32(buf[18:])),
+	}
+
+	rest := make([]byte, int(le.Uint16(buf[22:]))+int(le.Uint16(buf[24:])))
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+	h.name = string(rest[:le.Uint16(buf[22:])])
+	extra := rest[le.Uint16(buf[22:]):]
+
+	for len(extra) >= 4 {
+		id, n := le.Uint16(extra), int(le.Uint16(extra[2:]))
+		if len(extra) < 4+n {
+			break
+		}
+		field := extra[4 : 4+n]
+		extra = extra[4+n:]
+
+		switch id {
+		case zip64ExtraID:
+			h.zip64 = true
+			if h.size == 0xffffffff && len(field) >= 8 {
+				h.size, field = le.Uint64(field), field[8:]
+			}
+			if h.compressed == 0xffffffff && len(field) >= 8 {
+				h.compressed = le.Uint64(field)
+			}
+		case extTimeExtraID:
+			if len(field) >= 5 && field[0]&1 != 0 {
+				h.modified = time.Unix(int64(le.Uint32(field[1:])), 0)
+			}
+		case ntfsExtraID:
+			if t, ok := parseNTFS(field); ok {
+				h.ntfs = &t
+			}
+		case unixExtraID:
+			if u, ok := parseUnixExtra(field); ok {
+				h.owner = &u
+			}
+		}
+	}
+
+	// the NTFS times are the more precise
+	if h.ntfs != nil {
+		h.modified = h.ntfs.modified
+	}
+
+	return h, nil
+}
+
+// msDosTimeToTime converts MS-DOS date and time fields to a time, in UTC
+// as archive/zip does since the fields carry no zone.
+func msDosTimeToTime(date, tm uint16) time.Time {
+	return time.Date(
+		int(date>>9)+1980, time.Month(date>>5&0xf), int(date&0x1f),
+		int(tm>>11), int(tm>>5&0x3f), int(tm&0x1f)*2, 0, time.UTC,
+	)
+}
+
+// extractStreamed extracts the entry whose local header h has just been
+// read from br, leaving br at the next header.
+func (e *extractor) extractStreamed(br *bufio.Reader, h *localHeader) error {
+	var unsupported []Unsupported
+	switch {
+	case h.flags&0x1 != 0:
+		unsupported = append(unsupported, Unsupported{Entry: h.name, Feature: FeatureEncryption, Detail: "traditional", Skipped: true})
+	case h.method != zip.Store && h.method != zip.Deflate && h.method != zipZstd:
+		unsupported = append(unsupported, Unsupported{Entry: h.name, Feature: FeatureMethod, Detail: fmt.Sprintf("method %d", h.method), Skipped: true})
+	}
+
+	if h.hasDescriptor() && (h.method != zip.Deflate || len(unsupported) > 0) {
+		return &EntryError{Name: h.name, Err: fmt.Errorf("entry of unknown size cannot be streamed: %w", errors.ErrUnsupported)}
+	}
+
+	ok, err := e.o.recordUnsupported(unsupported)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, err := io.CopyN(io.Discard, br, int64(h.compressed))
+		return err
+	}
+
+	// with a descriptor flate finds the end of the data itself, reading
+	// br byte by byte so nothing past it is consumed
+	var raw io.Reader = br
+	if !h.hasDescriptor() {
+		raw = io.LimitReader(br, int64(h.compressed))
+	}
+
+	body := raw
+	switch h.method {
+	case zip.Deflate:
+		fr := flate.NewReader(raw)
+		defer fr.Close()
+		body = fr
+	case zipZstd:
+		zr := decompressZstd(raw)
+		defer zr.Close()
+		body = zr
+	}
+
+	sr := &streamReader{r: body, br: br, h: h, crc: crc32.NewIEEE()}
+
+	if h.name != ManifestName && e.o.selectedEntry(h.name) {
+		f := entry{name: h.name, mode: 0644, modified: h.modified, size: int64(h.size), compressed: int64(h.compressed), noMode: true, owner: h.owner}
+		if h.ntfs != nil {
+			f.accessed, f.created = h.ntfs.accessed, h.ntfs.created
+		}
+		if strings.HasSuffix(h.name, "/") {
+			f.mode = fs.ModeDir | 0755
+		}
+		if h.hasDescriptor() {
+			f.size, f.compressed = -1, -1
+		}
+
+		err := e.extract(f, func() (io.ReadCloser, error) {
+			return io.NopCloser(sr), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// consume whatever extraction did not read, checking it all the same
+	_, err = io.Copy(io.Discard, sr)
+	return err
+}
+
+// streamReader reads the data of a streamed entry, checking its size and
+// CRC-32 once the data ends, after reading the data descriptor if there
+// is one.
+type streamReader struct {
+	r   io.Reader
+	br  *bufio.Reader
+	h   *localHeader
+	crc hash.Hash32
+	n   uint64
+	err error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n, err := s.r.Read(p)
+	s.crc.Write(p[:n])
+	s.n += uint64(n)
+
+	if err == io.EOF {
+		if err = s.finish(); err == nil {
+			err = io.EOF
+		}
+	}
+	if err != nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// finish checks the entry once its data has been read.
+func (s *streamReader) finish() error {
+	crc, size := s.h.crc32, s.h.size
+	if s.h.hasDescriptor() {
+		var err error
+		if crc, size, err = readDataDescriptor(s.br, s.h.zip64); err != nil {
+			return err
+		}
+	}
+
+	if s.n != size {
+		return &EntryError{Name: s.h.name, Err: io.ErrUnexpectedEOF}
+	}
+	if s.crc.Sum32() != crc {
+		return &EntryError{Name: s.h.name, Err: zip.ErrChecksum}
+	}
+	return nil
+}
+
+// readDataDescriptor reads the checksum and uncompressed size from a data
+// descriptor, whose signature is optional.
+func readDataDescriptor(br *bufio.Reader, zip64 bool) (crc uint32, size uint64, err error) {
+	sizeLen := 4
+	if zip64 {
+		sizeLen = 8
+	}
+
+	buf := make([]byte, 4+2*sizeLen)
+	if _, err := io.ReadFull(br, buf[:4]); err != nil {
+		return 0, 0, err
+	}
+	le := binary.LittleEndian
+	if le.Uint32(buf) == dataDescriptorSig {
+		if _, err := io.ReadFull(br, buf[:4]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if _, err := io.ReadFull(br, buf[4:]); err != nil {
+		return 0, 0, err
+	}
+
+	crc = le.Uint32(buf)
+	if zip64 {
+		size = le.Uint64(buf[4+sizeLen:])
+	} else {
+		size = uint64(le.Uint32(buf[4+sizeLen:]))
+	}
+	return crc, size, nil
+}
@@ -0,0 +1,130 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// sequential hides every interface but io.Reader, as a pipe would.
+type sequential struct {
+	r io.Reader
+}
+
+func (s sequential) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+// openSequential returns the archive at path as a sequential reader.
+func openSequential(t *testing.T, path string) io.Reader {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sequential{bytes.NewReader(data)}
+}
+
+func TestUnzipReader(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "stream-src")
+	writeTree(t, src, map[string]string{
+		"a.txt":     "alpha",
+		"dir/b.txt": "beta",
+		"big.bin":   randomString(t, 200<<10),
+	})
+
+	for _, method := range []Method{MethodDeflate, MethodStore} {
+		zipPath, err := Zip(src, WithMethod(method), WithManifest())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer os.Remove(zipPath)
+
+		dest := t.TempDir()
+		if err := UnzipReader(openSequential(t, zipPath), dest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		zipptest.AssertTreesEqual(t, src, dest)
+	}
+}
+
+func TestUnzipReaderDataDescriptor(t *testing.T) {
+	// zip.Writer.CreateHeader writes sizes in a trailing data descriptor
+	zipPath := createZip(t,
+		testEntry{name: "dir/", mode: os.ModeDir | 0755},
+		testEntry{name: "dir/a.txt", body: "alpha"},
+		testEntry{name: "b.txt", body: "beta"},
+	)
+
+	dest := t.TempDir()
+	if err := UnzipReader(openSequential(t, zipPath), dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := zipptest.ReadTree(t, dest)
+	if len(got) != 2 || string(got["dir/a.txt"]) != "alpha" || string(got["b.txt"]) != "beta" {
+		t.Errorf("unexpected tree: %v", got)
+	}
+}
+
+func TestUnzipReaderStoredDescriptor(t *testing.T) {
+	zipPath := createStoredZip(t, testEntry{name: "a.txt", body: "alpha"})
+
+	err := UnzipReader(openSequential(t, zipPath), t.TempDir())
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
+
+func TestUnzipReaderChecksum(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "crc-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	zipPath, err := Zip(src, WithMethod(MethodStore))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(data, []byte("alpha"))
+	data[i] = 'A'
+
+	dest := t.TempDir()
+	err = UnzipReader(sequential{bytes.NewReader(data)}, dest)
+	if !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("expected zip.ErrChecksum, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); !os.IsNotExist(err) {
+		t.Error("expected the corrupt file to be removed")
+	}
+}
+
+func TestUnzipReaderTraversal(t *testing.T) {
+	zipPath := createZip(t, testEntry{name: "../evil.txt", body: "evil"})
+
+	root := t.TempDir()
+	if err := UnzipReader(openSequential(t, zipPath), filepath.Join(root, "dest")); err == nil {
+		t.Error("expected an error for an entry outside dest")
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Error("expected no file outside dest")
+	}
+}
+
+func TestUnzipReaderNotZip(t *testing.T) {
+	err := UnzipReader(bytes.NewReader([]byte("not a zip file at all")), t.TempDir())
+	if !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("expected zip.ErrFormat, got %v", err)
+	}
+}
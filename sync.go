@@ -0,0 +1,99 @@
+package zipper
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// WithSync makes Zip, Convert, Repack and Append flush the archive to
+// stable storage before returning, and extraction each file it writes,
+// along with the directories naming them, so that a crash after a call
+// succeeds loses none of its output. It suits crash-safe pipelines, at
+// the cost of a disk flush per file. Archives written to a Sink are left
+// to it.
+func WithSync() Option {
+	return func(o *options) {
+		o.sync = true
+	}
+}
+
+// syncClose flushes f to stable storage if asked to and it can be, then
+// closes it.
+func syncClose(f io.Closer, sync bool) error {
+	if s, ok := f.(interface{ Sync() error }); ok && sync {
+		if err := s.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// renameSynced renames the file at oldPath to newPath, flushing the
+// directory holding newPath if asked to, so the new name survives a
+// crash.
+func renameSynced(oldPath, newPath string, sync bool) error {
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	if sync {
+		return syncDir(filepath.Dir(newPath))
+	}
+	return nil
+}
+
+// touched records, with WithSync, that path was created, so the
+// directories from its own up to the destination's parent are flushed
+// once extraction ends.
+func (e *extractor) touched(path string) {
+	if !e.o.sync || !e.o.onDisk() {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dirs == nil {
+		e.dirs = make(map[string]bool)
+	}
+
+	top := filepath.Dir(e.dest)
+	for dir := filepath.Dir(path); !e.dirs[dir]; dir = filepath.Dir(dir) {
+		e.dirs[dir] = true
+		if dir == top || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+}
+
+// finish ends a successful extraction once the files in flight are
+// written, removing any PartialMarker left by an earlier one and flushing
+// the directories touched with WithSync, deepest first.
+func (e *extractor) finish() error {
+	if err := e.pool.wait(); err != nil {
+		return err
+	}
+	if e.o.checkInput != nil {
+		if err := e.o.checkInput(); err != nil {
+			return err
+		}
+	}
+	if err := e.unmark(); err != nil {
+		return err
+	}
+
+	dirs := make([]string, 0, len(e.dirs))
+	for dir := range e.dirs {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	slices.Reverse(dirs)
+
+	for _, dir := range dirs {
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+	e.done = true
+	return nil
+}
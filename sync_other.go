@@ -0,0 +1,9 @@
+//go:build !unix
+
+package zipper
+
+// syncDir does nothing where directories cannot be flushed, as on
+// Windows, whose file systems journal their entries.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,43 @@
+package zipper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestSync(t *testing.T) {
+	files := map[string]string{"a.txt": "alpha", "sub/deep/b.txt": "beta"}
+	src := filepath.Join(t.TempDir(), "durable")
+	writeTree(t, src, files)
+
+	zipPath := zipAside(t, src, WithSync())
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := Unzip(zipPath, dest, WithSync()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
+
+func TestExtractorTouched(t *testing.T) {
+	dest := filepath.FromSlash("/srv/dest")
+	e := &extractor{o: &options{sync: true}, dest: dest}
+	e.touched(filepath.Join(dest, "a", "b", "c.txt"))
+	e.touched(filepath.Join(dest, "a", "d.txt"))
+
+	want := []string{"/srv", "/srv/dest", "/srv/dest/a", "/srv/dest/a/b"}
+	if len(e.dirs) != len(want) {
+		t.Errorf("expected %d directories, got %v", len(want), e.dirs)
+	}
+	for _, dir := range want {
+		if !e.dirs[filepath.FromSlash(dir)] {
+			t.Errorf("expected %s to be flushed, got %v", dir, e.dirs)
+		}
+	}
+
+	e = &extractor{o: &options{}, dest: dest}
+	if e.touched(filepath.Join(dest, "a.txt")); e.dirs != nil {
+		t.Errorf("expected nothing recorded without WithSync, got %v", e.dirs)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package zipper
+
+import "os"
+
+// syncDir flushes the entries of the directory dir to stable storage.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
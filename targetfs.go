@@ -0,0 +1,163 @@
+package zipper
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WritableFS is a file system extraction writes to other than the local
+// disk, such as memory, a test double or a remote store. Names are dest
+// joined with the entry's path, in the form of the local platform.
+//
+// A WritableFS may also implement the methods of OSFS it lacks: Lstat,
+// so existing files are detected and WithOverwrite applies; Remove, so
+// files being replaced are removed rather than truncated; Link, so hard
+// links are recreated rather than written again; and Lchown, for
+// WithPreserveOwnership.
+type WritableFS interface {
+	// Create creates or truncates the named file.
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+
+	// MkdirAll creates the named directory and any parents missing.
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Symlink creates name as a symbolic link to target.
+	Symlink(target, name string) error
+
+	// Chmod sets the permissions of the named file.
+	Chmod(name string, mode fs.FileMode) error
+
+	// Chtimes sets the access and modification times of the named file.
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// WithTargetFS makes Unzip and the other extraction functions write
+// through fsys instead of to the local disk. Features only the disk
+// offers, such as WithSync, preallocation, creation times and special
+// files, are left out. Symlinks are checked against dest by their
+// targets alone, as links already on fsys cannot be followed.
+func WithTargetFS(fsys WritableFS) Option {
+	return func(o *options) {
+		o.targetFS = fsys
+	}
+}
+
+// OSFS is the WritableFS of the local disk that extraction uses by
+// default.
+type OSFS struct{}
+
+func (OSFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (OSFS) Symlink(target, name string) error {
+	return os.Symlink(target, name)
+}
+
+func (OSFS) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (OSFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (OSFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFS) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+func (OSFS) Lchown(name string, uid, gid int) error {
+	return os.Lchown(name, uid, gid)
+}
+
+// The optional methods of a WritableFS.
+type (
+	lstatFS interface {
+		Lstat(name string) (fs.FileInfo, error)
+	}
+	removeFS interface {
+		Remove(name string) error
+	}
+	linkFS interface {
+		Link(oldname, newname string) error
+	}
+	chownFS interface {
+		Lchown(name string, uid, gid int) error
+	}
+)
+
+// extractDest returns dest in the form extraction joins entry paths to,
+// extended-length on Windows when it is on the local disk.
+func (o *options) extractDest(dest string) string {
+	if o.onDisk() {
+		return longPath(filepath.Clean(dest))
+	}
+	return filepath.Clean(dest)
+}
+
+// onDisk reports whether extraction writes to the local disk.
+func (o *options) onDisk() bool {
+	return o.targetFS == nil
+}
+
+// target returns the file system extraction writes to.
+func (e *extractor) target() WritableFS {
+	if e.o.targetFS != nil {
+		return e.o.targetFS
+	}
+	return OSFS{}
+}
+
+// lstat describes the file at path, once any write to it in flight is
+// done, or fails with fs.ErrNotExist if the file system cannot tell.
+func (e *extractor) lstat(path string) (fs.FileInfo, error) {
+	if err := e.pool.settle(path); err != nil {
+		return nil, err
+	}
+	if fsys, ok := e.target().(lstatFS); ok {
+		return fsys.Lstat(path)
+	}
+	return nil, &fs.PathError{Op: "lstat", Path: path, Err: fs.ErrNotExist}
+}
+
+// remove removes the file at path, if the file system can. Those that
+// cannot truncate files as they are created again.
+func (e *extractor) remove(path string) error {
+	if fsys, ok := e.target().(removeFS); ok {
+		return fsys.Remove(path)
+	}
+	return nil
+}
+
+// link makes newname a hard link to oldname, failing with
+// errors.ErrUnsupported if the file system cannot.
+func (e *extractor) link(oldname, newname string) error {
+	if fsys, ok := e.target().(linkFS); ok {
+		return fsys.Link(oldname, newname)
+	}
+	return errors.ErrUnsupported
+}
+
+// lchown sets the owner of the file at path, if the file system can.
+func (e *extractor) lchown(path string, uid, gid int) error {
+	if fsys, ok := e.target().(chownFS); ok {
+		return fsys.Lchown(path, uid, gid)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package zipper
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memFS is a WritableFS keeping everything in memory, with none of the
+// optional methods.
+type memFS struct {
+	files map[string]*memFile
+	dirs  map[string]bool
+	links map[string]string
+}
+
+type memFile struct {
+	bytes.Buffer
+	mode     fs.FileMode
+	modified time.Time
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]*memFile), dirs: make(map[string]bool), links: make(map[string]string)}
+}
+
+func (m *memFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	if !m.dirs[filepath.Dir(name)] {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrNotExist}
+	}
+	f := &memFile{mode: perm}
+	m.files[name] = f
+	return nopWriteCloser{f}, nil
+}
+
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	for ; !m.dirs[name] && name != filepath.Dir(name); name = filepath.Dir(name) {
+		m.dirs[name] = true
+	}
+	return nil
+}
+
+func (m *memFS) Symlink(target, name string) error {
+	m.links[name] = target
+	return nil
+}
+
+func (m *memFS) Chmod(name string, mode fs.FileMode) error {
+	m.files[name].mode = mode
+	return nil
+}
+
+func (m *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	if f, ok := m.files[name]; ok {
+		f.modified = mtime
+	}
+	return nil
+}
+
+func TestUnzipTargetFS(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"})
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := zipAside(t, src)
+
+	dest := filepath.Join(t.TempDir(), "out")
+	mem := newMemFS()
+	if err := Unzip(zipPath, dest, WithTargetFS(mem)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected nothing written to disk, got %v", err)
+	}
+
+	for name, body := range map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"} {
+		f, ok := mem.files[filepath.Join(dest, filepath.FromSlash(name))]
+		if !ok || f.String() != body {
+			t.Errorf("expected %s to hold %q, got %v", name, body, f)
+		}
+	}
+	if f := mem.files[filepath.Join(dest, "a.txt")]; f != nil && !f.modified.Equal(modified) {
+		t.Errorf("expected modification time %v, got %v", modified, f.modified)
+	}
+}
+
+func TestUnzipTargetFSSymlinks(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "dir/file.txt", body: "data"},
+		testEntry{name: "dir/link", body: "file.txt", mode: fs.ModeSymlink | 0777},
+	)
+	dest := filepath.Join(t.TempDir(), "out")
+	mem := newMemFS()
+	if err := Unzip(zipPath, dest, WithTargetFS(mem)); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.links[filepath.Join(dest, "dir", "link")]; got != "file.txt" {
+		t.Errorf("expected the link to point at file.txt, got %q", got)
+	}
+
+	zipPath = createZip(t, testEntry{name: "dir/link", body: "../..", mode: fs.ModeSymlink | 0777})
+	if err := Unzip(zipPath, dest, WithTargetFS(newMemFS())); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("expected the escaping link to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package zipper
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// WithBandwidthLimit caps the rate at which Zip reads the files being
+// archived, and the rate at which it writes the archive, at bytesPerSecond
+// each, so background backups do not saturate disks or network file
+// systems. Each cap is shared by all the workers of a call. Unzip and
+// Extract cap the files they write, which also bounds how fast they read
+// the archive. Zero means no limit.
+func WithBandwidthLimit(bytesPerSecond int64) Option {
+	return func(o *options) {
+		o.bandwidth = bytesPerSecond
+	}
+}
+
+// limiter paces transfers to a rate, shared between goroutines.
+type limiter struct {
+	rate  float64 // bytes per second
+	chunk int     // largest transfer paced at once
+
+	mu   sync.Mutex
+	next time.Time // when the transfers so far are paid for
+}
+
+func newLimiter(bytesPerSecond int64) *limiter {
+	return &limiter{
+		rate:  float64(bytesPerSecond),
+		chunk: int(max(bytesPerSecond/10, 1)),
+	}
+}
+
+// wait blocks until the transfer of n bytes fits the rate.
+func (l *limiter) wait(n int) {
+	if n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// throttleReader returns r paced to the read limit, if any.
+func (o *options) throttleReader(r io.Reader) io.Reader {
+	if o.readLimit == nil {
+		return r
+	}
+	return &throttledReader{r: r, l: o.readLimit}
+}
+
+// throttleWriter returns w paced to the write limit, if any.
+func (o *options) throttleWriter(w io.Writer) io.Writer {
+	if o.writeLimit == nil {
+		return w
+	}
+	return &throttledWriter{w: w, l: o.writeLimit}
+}
+
+type throttledReader struct {
+	r io.Reader
+	l *limiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > t.l.chunk {
+		p = p[:t.l.chunk]
+	}
+	n, err := t.r.Read(p)
+	t.l.wait(n)
+	return n, err
+}
+
+type throttledWriter struct {
+	w io.Writer
+	l *limiter
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), t.l.chunk)]
+		n, err := t.w.Write(chunk)
+		written += n
+		t.l.wait(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// throttledUpload paces the writes of an upload.
+type throttledUpload struct {
+	Upload
+	w io.Writer
+}
+
+func (t throttledUpload) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
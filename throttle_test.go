@@ -0,0 +1,68 @@
+package zipper
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(100 << 10)
+
+	// the rate is shared between goroutines
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.wait(10 << 10)
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected 50 KiB at 100 KiB/s to take 500ms, took %v", elapsed)
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	data := strings.Repeat("throttled ", 1000)
+	o := newOptions([]Option{WithBandwidthLimit(1 << 20)})
+
+	var out bytes.Buffer
+	w := o.throttleWriter(&out)
+	if _, err := io.Copy(w, o.throttleReader(strings.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != data {
+		t.Error("expected the data to pass through unchanged")
+	}
+
+	if r := newOptions(nil).throttleReader(&out); r != io.Reader(&out) {
+		t.Error("expected no throttle without a limit")
+	}
+}
+
+func TestZipBandwidthLimit(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "throttled")
+	writeTree(t, src, map[string]string{"a.bin": randomString(t, 200<<10)})
+
+	start := time.Now()
+	zipPath := zipAside(t, src, WithBandwidthLimit(1<<20), WithMethod(MethodStore))
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected 200 KiB at 1 MiB/s to take about 200ms, took %v", elapsed)
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithBandwidthLimit(1<<20)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zipptest.AssertTreesEqual(t, src, dest)
+}
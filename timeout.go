@@ -0,0 +1,159 @@
+package zipper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// WithFileTimeout bounds the time Zip spends opening and reading any one
+// file, such as one on a hung network mount or a named pipe nobody
+// writes to, read with SpecialRead. A file taking longer fails the archive with an error
+// matching *TimeoutError, or is left out with WithContinueOnError if
+// nothing of it has been written yet. The stuck open or read cannot be
+// interrupted and is abandoned, holding the file open until it returns.
+func WithFileTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.fileTimeout = d
+	}
+}
+
+// TimeoutError is the failure of a file that took longer to read than
+// WithFileTimeout allows. It is wrapped in an *fs.PathError naming the
+// file.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v", e.Timeout)
+}
+
+// Is matches os.ErrDeadlineExceeded, as other I/O timeouts do.
+func (e *TimeoutError) Is(target error) bool {
+	return target == os.ErrDeadlineExceeded
+}
+
+// timedOut returns the error of file timing out during op.
+func (o *options) timedOut(op, file string) error {
+	return &fs.PathError{Op: op, Path: file, Err: &TimeoutError{Timeout: o.fileTimeout}}
+}
+
+// compressFileTimed compresses file as compressFile does, giving up once
+// the file timeout passes. The compressed data of an abandoned file is
+// released when it eventually completes.
+func compressFileTimed(root, file string, o *options) compressed {
+	if o.fileTimeout == 0 {
+		return compressFile(root, file, o)
+	}
+
+	done := make(chan compressed, 1)
+	go func() {
+		done <- compressFile(root, file, o)
+	}()
+
+	timer := time.NewTimer(o.fileTimeout)
+	defer timer.Stop()
+	select {
+	case c := <-done:
+		return c
+	case <-timer.C:
+		go func() {
+			if c := <-done; c.data != nil {
+				c.data.Release()
+			}
+		}()
+		return compressed{err: o.timedOut("read", file)}
+	}
+}
+
+// openTimed opens file for reading, giving up once the file timeout
+// passes. A file opened too late is closed.
+func (o *options) openTimed(file string) (*os.File, error) {
+	if o.fileTimeout == 0 {
+		return os.Open(file)
+	}
+
+	type opened struct {
+		f   *os.File
+		err error
+	}
+	done := make(chan opened, 1)
+	go func() {
+		f, err := os.Open(file)
+		done <- opened{f, err}
+	}()
+
+	timer := time.NewTimer(o.fileTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.f, r.err
+	case <-timer.C:
+		go func() {
+			if r := <-done; r.f != nil {
+				r.f.Close()
+			}
+		}()
+		return nil, o.timedOut("open", file)
+	}
+}
+
+// timedReader returns r, the contents of file, failing a read once the
+// file timeout has passed since start.
+func (o *options) timedReader(file string, r io.Reader, start time.Time) io.Reader {
+	if o.fileTimeout == 0 {
+		return r
+	}
+	return &timeoutReader{r: r, file: file, deadline: start.Add(o.fileTimeout), o: o}
+}
+
+// timeoutReader reads in the background so a read outlasting the
+// deadline can be abandoned. Its buffer belongs to the abandoned read,
+// never the caller's.
+type timeoutReader struct {
+	r        io.Reader
+	file     string
+	deadline time.Time
+	o        *options
+	buf      []byte
+	err      error // the timeout, once reached
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (t *timeoutReader) Read(p []byte) (int, error) {
+	if t.err != nil {
+		return 0, t.err
+	}
+	remaining := time.Until(t.deadline)
+	if remaining <= 0 {
+		t.err = t.o.timedOut("read", t.file)
+		return 0, t.err
+	}
+
+	if cap(t.buf) < len(p) {
+		t.buf = make([]byte, len(p))
+	}
+	buf := t.buf[:len(p)]
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := t.r.Read(buf)
+		done <- readResult{n, err}
+	}()
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return copy(p, buf[:r.n]), r.err
+	case <-timer.C:
+		t.err = t.o.timedOut("read", t.file)
+		return 0, t.err
+	}
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package zipper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestZipFileTimeout(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "stuck")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+
+	// opening a pipe nobody writes to blocks
+	fifo := filepath.Join(src, "pipe")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("cannot make a named pipe: %v", err)
+	}
+	// release the abandoned opens once done
+	defer func() {
+		if f, err := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			f.Close()
+		}
+	}()
+
+	for _, format := range []Format{FormatZip, FormatTarGz} {
+		out := filepath.Join(t.TempDir(), "out"+format.ext())
+		_, err := Zip(src, WithFormat(format), WithSpecialFiles(SpecialRead), WithFileTimeout(50*time.Millisecond), WithOutput(out))
+		var timeout *TimeoutError
+		if !errors.As(err, &timeout) || !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("%s: expected a TimeoutError, got %v", format, err)
+		}
+
+		_, err = Zip(src, WithFormat(format), WithSpecialFiles(SpecialRead), WithFileTimeout(50*time.Millisecond), WithContinueOnError(), WithOutput(out))
+		var partial *PartialError
+		if !errors.As(err, &partial) || len(partial.Failed) != 1 || partial.Failed[0].Entry != fifo {
+			t.Errorf("%s: expected the pipe to be left out, got %v", format, err)
+		}
+	}
+}
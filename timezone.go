@@ -0,0 +1,34 @@
+package zipper
+
+import "time"
+
+// TimeZonePolicy controls the time zone used for the MS-DOS date and time
+// fields of each entry.
+//
+// MS-DOS times carry no zone information, so archives created in one zone
+// and read in another disagree on when a file was modified. Every entry
+// also carries an extended timestamp field holding the absolute Unix
+// time, which readers that understand it (including archive/zip) prefer,
+// so modification times compare equal after a cross-timezone round trip
+// regardless of the policy chosen. An NTFS field records the same time to
+// 100 nanoseconds, along with the access and creation times, and is
+// preferred by Unzip and List.
+type TimeZonePolicy int
+
+const (
+	// TimeZoneLocal records times in the local time zone, as most zip
+	// tools do.
+	TimeZoneLocal TimeZonePolicy = iota
+
+	// TimeZoneUTC records times in UTC, giving the same MS-DOS fields for
+	// the same input on every machine.
+	TimeZoneUTC
+)
+
+// apply converts t according to the policy.
+func (p TimeZonePolicy) apply(t time.Time) time.Time {
+	if p == TimeZoneUTC {
+		return t.UTC()
+	}
+	return t.Local()
+}
@@ -0,0 +1,548 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxLinkTarget bounds the size of a symlink entry's target.
+const maxLinkTarget = 4096
+
+// Unzip extracts the archive at src into the directory dest, creating
+// dest if it does not exist.
+//
+// Entry names are sanitized, and entries that would land outside dest,
+// whether through "..", absolute paths or symlinks, fail the extraction.
+// Symlink entries are recreated as links only when they point inside
+// dest. Existing files are never replaced unless WithOverwrite allows it.
+// Entries using features zipper cannot read fail the extraction, or are
+// skipped and recorded when WithReport is given.
+func Unzip(src, dest string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	r, err := openArchive(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return unzipArchive(r.Reader, dest, o)
+}
+
+// unzipArchive extracts the open archive r into dest, as Unzip does.
+func unzipArchive(r *zip.Reader, dest string, o *options) error {
+	if err := o.limits.check(r.File); err != nil {
+		return err
+	}
+
+	// the manifest selects components and describes files stored other
+	// than as plain entries
+	m, err := readEmbeddedManifest(r)
+	if err == ErrNoManifest {
+		m, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		if err := o.limits.checkDuplicates(m, len(r.File)); err != nil {
+			return err
+		}
+	}
+
+	inComponents, err := o.componentFilter(m)
+	if err != nil {
+		return err
+	}
+
+	e, err := newExtractor(dest, o)
+	if err != nil {
+		return err
+	}
+	defer e.abort()
+	e.paths = make(map[string]string)
+	if m != nil {
+		e.sparse = sparseLayouts(m)
+	}
+
+	// duplicates recorded in the manifest are not counted
+	selected := make([]*zip.File, 0, len(r.File))
+	var total int64
+	for _, f := range r.File {
+		if f.Name == ManifestName || !o.selectedEntry(f.Name) {
+			continue
+		}
+		if inComponents != nil && !inComponents(f.Name) {
+			continue
+		}
+		selected = append(selected, f)
+		total += int64(f.UncompressedSize64)
+	}
+	o.progress.setTotal(total)
+
+	if n := o.extractWorkers(); n > 1 && o.dryRun == nil {
+		e.pool = newWritePool(n)
+	}
+	for _, f := range selected {
+		ok, err := o.checkSupported(f)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := e.extractFile(f); err != nil {
+			return err
+		}
+	}
+
+	// duplicates link to files that must be written first
+	if err := e.pool.wait(); err != nil {
+		return err
+	}
+
+	if err := e.extractDuplicates(r, m, inComponents); err != nil {
+		return err
+	}
+	return e.finish()
+}
+
+// extractor holds the state of a single extraction.
+type extractor struct {
+	o        *options
+	dest     string
+	realDest string // dest with symlinks resolved, "" if it does not exist
+	total    int64  // bytes extracted so far, updated atomically
+
+	// input counts the compressed bytes consumed, for formats that do not
+	// record compressed sizes per entry; nil otherwise
+	input *int64
+
+	// paths maps the names of files extracted to where they were written,
+	// for recreating hard links; nil if not needed
+	paths map[string]string
+
+	// sparse maps the names of sparse file entries to their layout
+	sparse map[string]*sparseLayout
+
+	// names holds the sanitized names of the files written so far, for
+	// WithDuplicates
+	names map[string]bool
+
+	// dirs holds the directories to flush once done, for WithSync
+	dirs map[string]bool
+
+	// created holds the paths created so far, in order, for
+	// CleanupRollback
+	created []string
+
+	done bool // finished successfully
+
+	// pool writes files concurrently, with WithMaxWorkers; nil if they
+	// are written one at a time
+	pool *writePool
+
+	// mu guards the state above that writing files updates, and calls to
+	// WithOnEntry
+	mu sync.Mutex
+}
+
+// newExtractor prepares the extraction of an archive into dest.
+func newExtractor(dest string, o *options) (*extractor, error) {
+	e := &extractor{o: o, dest: o.extractDest(dest)}
+	if o.cleanup == CleanupRollback && o.dryRun == nil {
+		if _, err := e.lstat(e.dest); errors.Is(err, fs.ErrNotExist) {
+			e.created = append(e.created, e.dest)
+		}
+	}
+
+	realDest, err := prepareDest(dest, o)
+	if err != nil {
+		return nil, err
+	}
+	e.realDest = realDest
+	return e, nil
+}
+
+// entry describes an archive entry independently of the archive format.
+type entry struct {
+	name     string
+	mode     fs.FileMode
+	modified time.Time
+
+	// accessed and created are the access and creation times, if the
+	// archive records them
+	accessed time.Time
+	created  time.Time
+
+	// size is the uncompressed size, or -1 if it is not known up front
+	size int64
+
+	// compressed is the compressed size, or -1 if the format does not
+	// record it
+	compressed int64
+
+	// link is the path of an extracted file to hard link to instead of
+	// writing the contents, if any
+	link string
+
+	// sparse is the layout of a sparse file, a hint for leaving its holes
+	// unallocated; nil for other files
+	sparse *sparseLayout
+
+	// noMode is set if the archive records no permissions for the entry,
+	// leaving mode a guess
+	noMode bool
+
+	// owner is the owner the archive records for the entry, if any
+	owner *owner
+
+	// device is the device numbers of a device node entry, if recorded
+	device *device
+}
+
+// zipEntry returns the description of a zip entry.
+func zipEntry(f *zip.File) entry {
+	t := zipTimes(f)
+	return entry{
+		name:       f.Name,
+		mode:       f.Mode(),
+		modified:   t.modified,
+		accessed:   t.accessed,
+		created:    t.created,
+		size:       int64(f.UncompressedSize64),
+		compressed: int64(f.CompressedSize64),
+		noMode:     !recordsMode(f),
+		owner:      zipOwner(f),
+	}
+}
+
+// extractFile writes a single zip entry below dest.
+func (e *extractor) extractFile(f *zip.File) error {
+	ze := zipEntry(f)
+	ze.sparse = e.sparse[f.Name]
+	return e.extract(ze, e.o.opener(f))
+}
+
+// extract writes a single archive entry below dest, reading its contents
+// from open.
+func (e *extractor) extract(f entry, open func() (io.ReadCloser, error)) error {
+	if err := e.o.ctxErr(); err != nil {
+		return err
+	}
+
+	name, err := sanitizeName(f.name)
+	if err != nil {
+		return &PathError{Op: "extract", Entry: f.name, Err: err}
+	}
+	name = e.o.normalize(name)
+	if name, err = e.o.remapName(name); err != nil {
+		return &PathError{Op: "extract", Entry: f.name, Err: err}
+	}
+	if name == "" {
+		e.skipped(f, "remapped away")
+		return nil
+	}
+
+	path := filepath.Join(e.dest, filepath.FromSlash(name))
+	// "." names dest itself, whose parents lie outside it by design
+	if e.realDest != "" && name != "." {
+		if err := checkParents(e.realDest, path); err != nil {
+			return &PathError{Op: "extract", Entry: f.name, Err: err}
+		}
+	}
+
+	if f.mode.IsDir() {
+		if e.o.dryRun != nil {
+			e.o.dryRun(PlannedEntry{Name: f.name, Path: path})
+			return nil
+		}
+		if err := e.mkdirAll(path); err != nil {
+			return err
+		}
+		if err := e.chown(f, path); err != nil {
+			return err
+		}
+		e.touched(path)
+		e.entryDone(f)
+		return nil
+	}
+
+	if name == "." {
+		return &PathError{Op: "extract", Entry: f.name, Err: invalidPath("file names the destination")}
+	}
+
+	if f.mode&specialTypes != 0 {
+		if skip, err := e.skipSpecial(f); skip || err != nil {
+			return err
+		}
+	}
+
+	if e.o.dryRun == nil {
+		if err := e.mkdirAll(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+
+	if e.names[name] {
+		switch e.o.duplicates {
+		case CollisionSuffix:
+			name = suffixedName(name, e.names)
+			path = filepath.Join(e.dest, filepath.FromSlash(name))
+		case CollisionKeepFirst:
+			e.skipped(f, "duplicate of an earlier entry")
+			return nil
+		case CollisionKeepLast:
+			// the earlier entry was written by this extraction
+			if e.o.dryRun == nil {
+				if err := e.pool.settle(path); err != nil {
+					return err
+				}
+				if err := e.remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		default:
+			return &PathError{Op: "extract", Entry: f.name, Err: fmt.Errorf("duplicate of an earlier entry: %w", fs.ErrExist)}
+		}
+	}
+
+	path, err = e.resolveConflict(f, path)
+	if err != nil || path == "" {
+		return err
+	}
+	if e.names == nil {
+		e.names = make(map[string]bool)
+	}
+	e.names[name] = true
+
+	if e.o.dryRun != nil {
+		e.o.dryRun(PlannedEntry{Name: f.name, Path: path, Size: max(f.size, 0)})
+		return nil
+	}
+
+	if f.mode&specialTypes != 0 {
+		return e.extractSpecial(f, path)
+	}
+
+	// fall back to writing the contents where links are not possible
+	if f.link != "" && e.link(f.link, path) == nil {
+		e.record(path)
+		e.extracted(f, path)
+		return nil
+	}
+
+	if f.mode&fs.ModeSymlink != 0 {
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if err := e.extractSymlink(e.limitReader(rc, f), f.name, path); err != nil {
+			return err
+		}
+		if err := e.chown(f, path); err != nil {
+			return err
+		}
+		e.touched(path)
+		e.entryDone(f)
+		return nil
+	}
+
+	if err := e.o.stripSpecialBits(f); err != nil {
+		return err
+	}
+
+	// the file is placed, so its contents can be written alongside others
+	if e.pool != nil {
+		return e.pool.run(path, func() error {
+			return e.writeFile(f, path, open)
+		})
+	}
+	return e.writeFile(f, path, open)
+}
+
+// writeFile writes the regular file entry f to path, reading its
+// contents from open.
+func (e *extractor) writeFile(f entry, path string, open func() (io.ReadCloser, error)) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	perm, exact := e.o.filePerm(f)
+	out, err := e.target().Create(path, perm.Perm())
+	if err != nil {
+		return err
+	}
+	e.record(path)
+
+	src := e.o.trackReader(e.limitReader(rc, f))
+	if sf, ok := out.(sparseFile); ok && f.sparse.fits(f.size) {
+		err = writeSparse(sf, src, f.sparse.regions)
+	} else if err = e.preallocate(out, f.size); err == nil {
+		err = e.writeContents(out, src)
+	}
+	if err != nil {
+		out.Close()
+		e.remove(path)
+		return err
+	}
+
+	if err := syncClose(out, e.o.sync); err != nil {
+		return err
+	}
+	if err := e.chown(f, path); err != nil {
+		return err
+	}
+	if exact {
+		if err := e.target().Chmod(path, perm); err != nil {
+			return err
+		}
+	}
+
+	// restore the modification time, which archive/zip resolves from the
+	// extended timestamp when present, and the NTFS times if recorded
+	if !f.modified.IsZero() {
+		accessed := f.accessed
+		if accessed.IsZero() {
+			accessed = f.modified
+		}
+		if err := e.target().Chtimes(path, accessed, f.modified); err != nil {
+			return err
+		}
+	}
+	if !f.created.IsZero() && e.o.onDisk() {
+		// not every file system keeps creation times
+		_ = setCreated(path, f.created)
+	}
+
+	e.extracted(f, path)
+	return nil
+}
+
+// limitReader returns r, the contents of the entry f, checked against
+// the limits.
+func (e *extractor) limitReader(r io.Reader, f entry) *limitReader {
+	return &limitReader{
+		r:          r,
+		limits:     e.o.limits,
+		entry:      f.name,
+		compressed: f.compressed,
+		total:      &e.total,
+		input:      e.input,
+	}
+}
+
+// preallocate reserves the declared size of an entry for out, where it is
+// a file on a file system that can, unless it would exceed MaxTotalSize,
+// which declared sizes may lie about.
+func (e *extractor) preallocate(out io.Writer, size int64) error {
+	f, ok := out.(*os.File)
+	if !ok || size <= 0 || e.o.limits.MaxTotalSize > 0 && size > e.o.limits.MaxTotalSize-atomic.LoadInt64(&e.total) {
+		return nil
+	}
+	if err := preallocate(f, size); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
+
+// writeContents copies the contents of an entry from r to out.
+func (e *extractor) writeContents(out io.Writer, r io.Reader) error {
+	bw := e.o.bufferedWriter(e.o.throttleWriter(out))
+	defer releaseWriter(bw)
+
+	if _, err := e.o.copy(bw, r); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// extracted records that the file f was written to path.
+func (e *extractor) extracted(f entry, path string) {
+	if e.paths != nil {
+		e.mu.Lock()
+		e.paths[f.name] = path
+		e.mu.Unlock()
+	}
+	e.touched(path)
+	e.entryDone(f)
+}
+
+// entryDone reports the entry f extracted, one entry at a time.
+func (e *extractor) entryDone(f entry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.o.entryDone(f.info())
+}
+
+// skipped records that the entry f was left out for reason, counting its
+// contents as handled for WithProgress.
+func (e *extractor) skipped(f entry, reason string) {
+	e.o.logSkip(f.name, reason)
+	e.o.progress.add(max(f.size, 0))
+}
+
+// extractSymlink creates a link at path, for the entry name, to the target
+// stored in r.
+func (e *extractor) extractSymlink(r io.Reader, name, path string) error {
+	target, err := io.ReadAll(io.LimitReader(r, maxLinkTarget+1))
+	if err != nil {
+		return err
+	}
+	if len(target) > maxLinkTarget {
+		return &PathError{Op: "extract", Entry: name, Err: invalidPath("link target too long")}
+	}
+
+	// links already on another file system cannot be followed
+	root := e.realDest
+	if !e.o.onDisk() {
+		root = e.dest
+	}
+	if err := checkSymlink(root, path, string(target), e.o.onDisk()); err != nil {
+		return &PathError{Op: "extract", Entry: name, Err: err}
+	}
+
+	if err := e.target().Symlink(filepath.FromSlash(string(target)), path); err != nil {
+		return err
+	}
+	e.record(path)
+	return nil
+}
+
+// prepareDest creates dest and returns it with symlinks resolved. In a dry
+// run nothing is created, and "" is returned if dest does not exist yet,
+// as it is for dest on a WithTargetFS file system.
+func prepareDest(dest string, o *options) (string, error) {
+	if !o.onDisk() {
+		if o.dryRun != nil {
+			return "", nil
+		}
+		return "", o.targetFS.MkdirAll(dest, 0755)
+	}
+
+	if o.dryRun != nil {
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			return "", nil
+		}
+	} else if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(dest)
+}
@@ -0,0 +1,220 @@
+package zipper
+
+import (
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+// writeTree creates the given slash-separated files below root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testEntry is a raw entry written by createZip. A symlink entry stores
+// its target as the body.
+type testEntry struct {
+	name string
+	body string
+	mode fs.FileMode
+}
+
+// createZip writes the entries verbatim into a new archive, allowing
+// tests to craft archives Zip would never produce.
+func createZip(t *testing.T, entries ...testEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "crafted.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			hdr.SetMode(e.mode)
+		}
+
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// listTree returns the slash-separated paths of all files below root.
+func listTree(t *testing.T, root string) []string {
+	t.Helper()
+
+	var names []string
+	for name := range zipptest.ReadTree(t, root) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// zipTree zips a fresh tree of files and returns the archive path, which
+// is removed when the test ends.
+func zipTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, files)
+
+	zipPath, err := Zip(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(zipPath) })
+
+	return zipPath
+}
+
+func TestUnzipRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"root.txt":          "root",
+		"subdir/nested.txt": "nested",
+		"a/b/c/deep.txt":    "deep",
+	})
+
+	mtime := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "root.txt"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath, err := Zip(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(zipPath)
+
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := Unzip(zipPath, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zipptest.AssertTreesEqual(t, src, dest)
+
+	info, err := os.Stat(filepath.Join(dest, "root.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("expected mtime %v, got %v", mtime, info.ModTime())
+	}
+}
+
+func TestUnzipPatterns(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{
+		"docs/index.md":     "index",
+		"docs/api/v1.md":    "v1",
+		"db/schema.sql":     "schema",
+		"db/seed/users.sql": "users",
+		"src/main.go":       "main",
+	})
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "include directory tree",
+			opts:     []Option{WithInclude("docs/**")},
+			expected: []string{"docs/api/v1.md", "docs/index.md"},
+		},
+		{
+			name:     "include extension at any depth",
+			opts:     []Option{WithInclude("*.sql")},
+			expected: []string{"db/schema.sql", "db/seed/users.sql"},
+		},
+		{
+			name:     "multiple includes",
+			opts:     []Option{WithInclude("*.sql", "src/*.go")},
+			expected: []string{"db/schema.sql", "db/seed/users.sql", "src/main.go"},
+		},
+		{
+			name:     "exclude",
+			opts:     []Option{WithExclude("docs/**", "db/seed/**")},
+			expected: []string{"db/schema.sql", "src/main.go"},
+		},
+		{
+			name:     "exclude wins over include",
+			opts:     []Option{WithInclude("*.sql"), WithExclude("db/seed/**")},
+			expected: []string{"db/schema.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := Unzip(zipPath, dest, tt.opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := listTree(t, dest)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestUnzipPatternsSanitizedNames(t *testing.T) {
+	zipPath := createZip(t,
+		testEntry{name: "./docs/a.md", body: "a"},
+		testEntry{name: `docs\b.md`, body: "b"},
+		testEntry{name: "src/main.go", body: "main"},
+	)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, dest, WithInclude("docs/**")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listTree(t, dest); !slices.Equal(got, []string{"docs/a.md", "docs/b.md"}) {
+		t.Errorf("expected the docs entries, got %v", got)
+	}
+}
+
+func TestUnzipInvalidPattern(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "a"})
+
+	if err := Unzip(zipPath, t.TempDir(), WithInclude("[")); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
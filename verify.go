@@ -0,0 +1,137 @@
+package zipper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EntryError is a failure concerning a single archive entry.
+type EntryError struct {
+	Name string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyError is returned by Verify when one or more entries are corrupt.
+type VerifyError struct {
+	Entries []*EntryError
+}
+
+func (e *VerifyError) Error() string {
+	msgs := make([]string, len(e.Entries))
+	for i, entry := range e.Entries {
+		msgs[i] = entry.Error()
+	}
+	return fmt.Sprintf("%d entries failed verification: %s", len(e.Entries), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual entry errors, so errors.Is can match
+// failures such as zip.ErrChecksum.
+func (e *VerifyError) Unwrap() []error {
+	errs := make([]error, len(e.Entries))
+	for i, entry := range e.Entries {
+		errs[i] = entry
+	}
+	return errs
+}
+
+// Verify checks the integrity of the archive at zipPath. Every entry is
+// read end to end so its CRC-32 is checked, and the central directory is
+// checked for consistency: each entry must have a valid local header,
+// lie within the file, and not overlap another entry or repeat its name.
+//
+// If the archive cannot be opened at all that error is returned as is,
+// otherwise all failures are collected into a *VerifyError. Encrypted
+// entries are checked given WithPassword, and fail with ErrPassword
+// otherwise; other options are ignored.
+func Verify(zipPath string, opts ...Option) error {
+	o := newOptions(opts)
+
+	r, err := openArchive(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return verifyArchive(r, o)
+}
+
+// verifyArchive checks the integrity of the open archive r, as Verify
+// does.
+func verifyArchive(r *archive, o *options) error {
+	report := &VerifyError{}
+	fail := func(name string, err error) {
+		report.Entries = append(report.Entries, &EntryError{Name: name, Err: err})
+	}
+
+	type span struct {
+		name       string
+		start, end int64
+	}
+	spans := make([]span, 0, len(r.File))
+	seen := make(map[string]bool, len(r.File))
+
+	for _, f := range r.File {
+		if seen[f.Name] {
+			fail(f.Name, errors.New("duplicate entry name"))
+		}
+		seen[f.Name] = true
+
+		offset, err := f.DataOffset()
+		if err != nil {
+			fail(f.Name, fmt.Errorf("local header: %w", err))
+			continue
+		}
+
+		end := offset + int64(f.CompressedSize64)
+		if end > r.size {
+			fail(f.Name, errors.New("data extends past end of archive"))
+			continue
+		}
+		spans = append(spans, span{name: f.Name, start: offset, end: end})
+
+		if err := readEntry(o.opener(f)); err != nil {
+			// decryption failures already name the entry
+			var entryErr *EntryError
+			if errors.As(err, &entryErr) && entryErr.Name == f.Name {
+				err = entryErr.Err
+			}
+			fail(f.Name, err)
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			fail(spans[i].name, fmt.Errorf("data overlaps %s", spans[i-1].name))
+		}
+	}
+
+	if len(report.Entries) > 0 {
+		return report
+	}
+	return nil
+}
+
+// readEntry decompresses an entry opened by open in full, which checks its
+// size and CRC-32.
+func readEntry(open func() (io.ReadCloser, error)) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
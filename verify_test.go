@@ -0,0 +1,109 @@
+package zipper
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createStoredZip writes uncompressed entries so tests can corrupt their
+// data at known offsets.
+func createStoredZip(t *testing.T, entries ...testEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "stored.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestVerify(t *testing.T) {
+	zipPath := zipTree(t, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	if err := Verify(zipPath); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyCorruptEntry(t *testing.T) {
+	zipPath := createStoredZip(t,
+		testEntry{name: "good.txt", body: "intact content"},
+		testEntry{name: "bad.txt", body: "corrupted content"},
+	)
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := r.File[1].DataOffset()
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[offset] ^= 0xff
+	if err := os.WriteFile(zipPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Verify(zipPath)
+
+	var verr *VerifyError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *VerifyError, got %v", err)
+	}
+	if len(verr.Entries) != 1 || verr.Entries[0].Name != "bad.txt" {
+		t.Errorf("expected only bad.txt to fail, got %v", verr.Entries)
+	}
+	if !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+}
+
+func TestVerifyDuplicateNames(t *testing.T) {
+	zipPath := createStoredZip(t,
+		testEntry{name: "same.txt", body: "one"},
+		testEntry{name: "same.txt", body: "two"},
+	)
+
+	var verr *VerifyError
+	if err := Verify(zipPath); !errors.As(err, &verr) || len(verr.Entries) != 1 {
+		t.Errorf("expected duplicate name to be reported, got %v", err)
+	}
+}
+
+func TestVerifyNotAnArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junk.zip")
+	if err := os.WriteFile(path, []byte("not a zip file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Verify(path)
+	if !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("expected zip.ErrFormat, got %v", err)
+	}
+}
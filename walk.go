@@ -0,0 +1,267 @@
+package zipper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithOneFileSystem keeps Zip on the file system inPath is on, like tar's
+// --one-file-system: directories that are mount points of another file
+// system, such as network mounts or /proc, are left out along with
+// everything below them. Mount points are only detected on Unix.
+func WithOneFileSystem() Option {
+	return func(o *options) {
+		o.oneFileSystem = true
+	}
+}
+
+// WithMaxDepth limits how deep below inPath Zip archives files: depth 1
+// archives only the files directly in inPath, depth 2 those in its
+// subdirectories too, and so on. Zero, the default, means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
+// WithMinSize makes Zip leave out files smaller than size bytes. Files
+// left out are recorded in the report given to WithReport.
+func WithMinSize(size int64) Option {
+	return func(o *options) {
+		o.minSize = size
+	}
+}
+
+// WithMaxSize makes Zip leave out files larger than size bytes, such as
+// huge blobs. Files left out are recorded in the report given to
+// WithReport. Zero, the default, means no limit.
+func WithMaxSize(size int64) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// WithFilter makes Zip call keep for every file and directory below inPath,
+// with its path as walked and its directory entry, and leave out those for
+// which it returns false, along with everything below a directory left
+// out. It gives control beyond what patterns can express, such as leaving
+// out files owned by another user.
+func WithFilter(keep func(path string, d fs.DirEntry) bool) Option {
+	return func(o *options) {
+		o.filter = keep
+	}
+}
+
+// walker decides which paths below root Zip archives, naming them
+// relative to base.
+type walker struct {
+	root string
+	base string
+	o    *options
+
+	dev    uint64 // the device root is on, with WithOneFileSystem
+	hasDev bool
+}
+
+func newWalker(root, base string, o *options) (*walker, error) {
+	w := &walker{root: root, base: base, o: o}
+	if o.oneFileSystem {
+		info, err := os.Lstat(root)
+		if err != nil {
+			return nil, err
+		}
+		w.dev, w.hasDev = deviceID(info)
+	}
+	return w, nil
+}
+
+// skip reports whether the walk leaves out path, and everything below it
+// if it is a directory.
+func (w *walker) skip(path string, d fs.DirEntry) (bool, error) {
+	if path != w.root {
+		if skip, err := w.skipBranch(path, d); skip || err != nil {
+			return skip, err
+		}
+		if w.o.skipMacMetadata && isMacMetadata(d.Name()) {
+			w.o.logSkip(path, "macOS metadata")
+			return true, nil
+		}
+		if w.o.skipHidden && isHidden(d) {
+			w.o.logSkip(path, "hidden")
+			return true, nil
+		}
+	}
+
+	if skip, err := w.skipPattern(path, d); skip || err != nil {
+		return skip, err
+	}
+
+	if w.o.filter != nil && !(path == w.root && d.IsDir()) && !w.o.filter(path, d) {
+		w.o.logSkip(path, "left out by filter")
+		return true, nil
+	}
+
+	if d.IsDir() {
+		return false, nil
+	}
+	if skip, err := w.skipSpecial(path, d); skip || err != nil {
+		return skip, err
+	}
+	if w.o.minSize > 0 || w.o.maxSize > 0 {
+		return w.skipSize(path)
+	}
+	return false, nil
+}
+
+// skipPattern reports whether path is left out by WithInclude or
+// WithExclude. Directories are only pruned by excludes.
+func (w *walker) skipPattern(path string, d fs.DirEntry) (bool, error) {
+	if len(w.o.includes) == 0 && len(w.o.excludes) == 0 {
+		return false, nil
+	}
+
+	name, err := entryName(w.base, path)
+	if err != nil {
+		return false, err
+	}
+	var skip bool
+	if d.IsDir() {
+		skip = path != w.root && w.o.excluded(name)
+	} else {
+		skip = !w.o.selected(name)
+	}
+	if skip {
+		w.o.logSkip(path, "does not match patterns")
+	}
+	return skip, nil
+}
+
+// skipBranch reports whether path below root is too deep or on another
+// file system.
+func (w *walker) skipBranch(path string, d fs.DirEntry) (bool, error) {
+	// the directories at the limit hold nothing shallow enough
+	if w.o.maxDepth > 0 {
+		depth := w.depth(path)
+		if depth > w.o.maxDepth || d.IsDir() && depth == w.o.maxDepth {
+			w.o.logSkip(path, "deeper than the maximum depth")
+			return true, nil
+		}
+	}
+
+	if w.hasDev {
+		info, err := d.Info()
+		if err != nil {
+			return false, err
+		}
+		if dev, ok := deviceID(info); ok && dev != w.dev {
+			w.o.logSkip(path, "on another file system")
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// skipSize reports whether the file at path is outside the size limits,
+// recording it in the report if so.
+func (w *walker) skipSize(path string) (bool, error) {
+	// symlinks are archived as the file they point to
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	var reason string
+	switch size := info.Size(); {
+	case size < w.o.minSize:
+		reason = fmt.Sprintf("smaller than %d bytes", w.o.minSize)
+	case w.o.maxSize > 0 && size > w.o.maxSize:
+		reason = fmt.Sprintf("larger than %d bytes", w.o.maxSize)
+	default:
+		return false, nil
+	}
+
+	return true, w.recordSkip(path, info.Size(), reason)
+}
+
+// skipSpecial reports whether path is a special file, which Zip cannot
+// archive, recording it in the report if so, or failing with
+// SpecialError.
+func (w *walker) skipSpecial(path string, d fs.DirEntry) (bool, error) {
+	mode := d.Type()
+	if mode&fs.ModeSymlink != 0 {
+		// symlinks are archived as the file they point to, and dangling
+		// ones fail as they are
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, nil
+		}
+		mode = info.Mode()
+	}
+
+	kind := specialType(mode)
+	if kind == "" || w.o.specialFiles == SpecialRead && mode&fs.ModeNamedPipe != 0 {
+		return false, nil
+	}
+	if w.o.specialFiles == SpecialError {
+		return false, specialError("zip", path, kind)
+	}
+	return true, w.recordSkip(path, 0, kind)
+}
+
+// recordSkip logs that the file at path, of size bytes, was left out for
+// reason, recording it in the report.
+func (w *walker) recordSkip(path string, size int64, reason string) error {
+	w.o.logSkip(path, reason)
+	if w.o.report != nil {
+		name, err := w.o.entryName(w.base, path)
+		if err != nil {
+			return err
+		}
+		w.o.report.Skipped = append(w.o.report.Skipped, Skipped{Entry: name, Size: size, Reason: reason})
+	}
+	return nil
+}
+
+// depth returns how many levels below root path is, 1 for its entries.
+func (w *walker) depth(path string) int {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// walk calls fn for each file below root that is not skipped, in
+// lexical order.
+func (w *walker) walk(fn func(path string) error) error {
+	return filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err := w.o.ctxErr(); err != nil {
+			return err
+		}
+		if err != nil {
+			return w.o.unreadable(path, err)
+		}
+
+		skip, err := w.skip(path, d)
+		if err != nil {
+			if err := w.o.unreadable(path, err); err != nil {
+				return err
+			}
+			skip = true
+		}
+		if skip {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+		return fn(path)
+	})
+}
@@ -0,0 +1,181 @@
+package zipper
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-zip/zipptest"
+)
+
+func TestZipOneFileSystem(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "onefs")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+	zipPath := zipAside(t, src, WithOneFileSystem())
+
+	// a tree without mount points is archived whole
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 2 || string(files["dir/b.txt"]) != "beta" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
+
+func TestZipMaxDepth(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "deep")
+	writeTree(t, src, map[string]string{
+		"a.txt":       "alpha",
+		"dir/b.txt":   "beta",
+		"dir/sub/c":   "gamma",
+		"dir/sub/d/e": "delta",
+	})
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{1, []string{"a.txt"}},
+		{2, []string{"a.txt", "dir/b.txt"}},
+		{0, []string{"a.txt", "dir/b.txt", "dir/sub/c", "dir/sub/d/e"}},
+	}
+	for _, tt := range tests {
+		zipPath := zipAside(t, src, WithMaxDepth(tt.depth))
+		files := zipptest.ReadArchive(t, zipPath)
+		if len(files) != len(tt.want) {
+			t.Errorf("depth %d: expected %v, got %q", tt.depth, tt.want, files)
+			continue
+		}
+		for _, name := range tt.want {
+			if _, ok := files[name]; !ok {
+				t.Errorf("depth %d: missing %s", tt.depth, name)
+			}
+		}
+	}
+}
+
+func TestZipSizeLimits(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "sized")
+	writeTree(t, src, map[string]string{
+		"small.txt":   "a",
+		"medium.txt":  "abcdefghij",
+		"dir/big.bin": strings.Repeat("x", 100),
+	})
+
+	report := &Report{}
+	zipPath := zipAside(t, src, WithMinSize(2), WithMaxSize(50), WithReport(report))
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 1 || string(files["medium.txt"]) != "abcdefghij" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+
+	want := []Skipped{
+		{Entry: "dir/big.bin", Size: 100, Reason: "larger than 50 bytes"},
+		{Entry: "small.txt", Size: 1, Reason: "smaller than 2 bytes"},
+	}
+	if len(report.Skipped) != len(want) {
+		t.Fatalf("expected %v skipped, got %v", want, report.Skipped)
+	}
+	for i, s := range report.Skipped {
+		if s != want[i] {
+			t.Errorf("expected %v, got %v", want[i], s)
+		}
+	}
+}
+
+func TestZipFilter(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "filtered")
+	writeTree(t, src, map[string]string{
+		"a.txt":         "alpha",
+		"b.log":         "beta",
+		"vendor/c.txt":  "gamma",
+		"nested/d.txt":  "delta",
+		"nested/e.tmpl": "epsilon",
+	})
+
+	var visited []string
+	zipPath := zipAside(t, src, WithFilter(func(path string, d fs.DirEntry) bool {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		visited = append(visited, filepath.ToSlash(rel))
+		if d.IsDir() {
+			return d.Name() != "vendor"
+		}
+		return filepath.Ext(path) == ".txt"
+	}))
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 2 || string(files["a.txt"]) != "alpha" || string(files["nested/d.txt"]) != "delta" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+
+	// nothing below a directory left out is visited, nor the root itself
+	for _, rel := range visited {
+		if rel == "." || strings.HasPrefix(rel, "vendor/") {
+			t.Errorf("unexpected visit of %s", rel)
+		}
+	}
+}
+
+func TestZipPatterns(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "source")
+	writeTree(t, src, map[string]string{
+		"main.go":                   "package main",
+		"main_test.go":              "package main",
+		"README.md":                 "readme",
+		"node_modules/dep/index.js": "js",
+		".git/HEAD":                 "ref",
+		"web/app.js":                "js",
+	})
+
+	var visited []string
+	zipPath := zipAside(t, src,
+		WithInclude("*.go", "*.js"),
+		WithExclude("*_test.go", "node_modules", ".git"),
+		WithFilter(func(path string, d fs.DirEntry) bool {
+			visited = append(visited, path)
+			return true
+		}))
+
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 2 || string(files["main.go"]) != "package main" || string(files["web/app.js"]) != "js" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+
+	// excluded directories are not descended into
+	for _, path := range visited {
+		if strings.Contains(path, "node_modules") || strings.Contains(path, ".git") {
+			t.Errorf("unexpected visit of %s", path)
+		}
+	}
+}
+
+func TestWalkerOtherDevice(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, ok := deviceID(info)
+	if !ok {
+		t.Skip("devices are not available")
+	}
+
+	// pretend the root is on another device than what is below it
+	w := &walker{root: src, o: &options{oneFileSystem: true}, dev: dev + 1, hasDev: true}
+	var files []string
+	if err := w.walk(func(path string) error {
+		files = append(files, path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected everything to be left out, got %v", files)
+	}
+}
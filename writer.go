@@ -0,0 +1,311 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Writer builds a zip archive piece by piece, from files, directories,
+// file systems and generated content, for callers composing an archive
+// that Zip's single walk cannot describe. Its entries are named,
+// compressed, encrypted and recorded in the manifest as Zip does its
+// files, as the options given to NewWriter say. It is not safe for
+// concurrent use.
+type Writer struct {
+	o       *options
+	bw      *bufio.Writer
+	zipw    *zip.Writer
+	seen    dedup
+	names   map[string]bool // entry names written, for WithDuplicates
+	written []ManifestEntry
+	start   time.Time
+	closed  bool
+}
+
+// NewWriter returns a Writer writing a zip archive to w. It takes the
+// options of Zip, which apply to each call adding entries, except that
+// splitting, resuming, self-extractors and formats other than zip are
+// refused. The archive is complete once Close is called.
+func NewWriter(w io.Writer, opts ...Option) (*Writer, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.splitSize > 0 || o.resume || o.sfxStub != "" || o.format != FormatZip {
+		return nil, errors.New("a Writer writes plain zip archives only")
+	}
+	if o.duplicates == CollisionKeepLast {
+		return nil, errors.New("a Writer cannot replace entries already written")
+	}
+
+	bw := o.bufferedWriter(o.meterWriter(o.throttleWriter(w)))
+	zw := &Writer{o: o, bw: bw, zipw: zip.NewWriter(bw), names: make(map[string]bool), start: time.Now()}
+	if o.dedup {
+		zw.seen = make(dedup)
+	}
+	return zw, nil
+}
+
+var errWriterClosed = errors.New("zipper: write to closed Writer")
+
+// AddFile adds file as an entry called name, or by its base
+// name, as rewritten by WithRename, if name is empty. Either is placed
+// under WithRootDir.
+func (w *Writer) AddFile(file, name string) error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return &PathError{Op: "add", Entry: file, Err: errors.New("not a regular file")}
+	}
+
+	file = longPath(file)
+	w.o.flattened = nil
+	if name != "" {
+		clean, err := w.entryName(name)
+		if err != nil {
+			return err
+		}
+		w.o.flattened = map[string]string{file: clean}
+	}
+	defer func() { w.o.flattened = nil }()
+
+	return w.addFiles(filepath.Dir(file), []string{file})
+}
+
+// AddDir adds the files below dir, walked, filtered and named as Zip does
+// those of a directory, under the directory name in the archive, or at
+// the top if name is empty.
+func (w *Writer) AddDir(dir, name string) error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &PathError{Op: "add", Entry: dir, Err: errors.New("not a directory")}
+	}
+
+	rootDir := w.o.rootDir
+	if name != "" {
+		clean, err := w.entryName(name)
+		if err != nil {
+			return err
+		}
+		w.o.rootDir = path.Join(rootDir, clean)
+	}
+	defer func() {
+		w.o.rootDir = rootDir
+		w.o.flattened = nil
+	}()
+
+	dir = longPath(dir)
+	files, err := collectFiles(dir, []string{dir}, "", w.o)
+	if err != nil {
+		return err
+	}
+	return w.addFiles(dir, files)
+}
+
+// addFiles writes files below root, as Zip does, resolving names taken
+// by earlier calls by WithDuplicates.
+func (w *Writer) addFiles(root string, files []string) error {
+	named := make(map[string]string, len(files))
+	kept := files[:0:0]
+	for _, file := range files {
+		name, err := w.o.entryName(root, file)
+		if err != nil {
+			return err
+		}
+		if name, err = w.claim(name, file); err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		if w.o.rootDir != "" {
+			name = strings.TrimPrefix(name, w.o.rootDir+"/")
+		}
+		named[file] = name
+		kept = append(kept, file)
+	}
+	w.o.flattened = named
+
+	written, err := writeEntries(w.zipw, root, kept, w.seen, nil, w.o)
+	w.written = append(w.written, written...)
+	return err
+}
+
+// AddFS adds the regular files of fsys passing WithInclude and
+// WithExclude, named by their paths in fsys and placed under WithRootDir.
+// Other kinds of file are left out.
+func (w *Writer) AddFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return w.o.unreadable(name, err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			w.o.logSkip(name, "not a regular file")
+			return nil
+		}
+		if !w.o.selected(name) {
+			w.o.logSkip(name, "left out by pattern")
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return w.o.unreadable(name, err)
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return w.o.unreadable(name, err)
+		}
+		defer f.Close()
+		return w.AddReader(name, info.Mode(), info.ModTime(), f)
+	})
+}
+
+// AddReader adds an entry called name, with the mode and modification
+// time given, holding what is read from r until EOF. The name must be
+// relative and stay below the archive's root, as Unzip requires of the
+// names it extracts, and is placed under WithRootDir.
+func (w *Writer) AddReader(name string, mode fs.FileMode, mtime time.Time, r io.Reader) error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	clean, err := w.entryName(name)
+	if err != nil {
+		return err
+	}
+	if w.o.rootDir != "" {
+		clean = path.Join(w.o.rootDir, clean)
+	}
+	if clean, err = w.claim(clean, name); err != nil || clean == "" {
+		return err
+	}
+
+	hdr := &zip.FileHeader{Name: clean, Modified: w.o.modTime(mtime)}
+	if w.o.reproducible {
+		mode = normalizeMode(mode)
+	}
+	hdr.SetMode(mode)
+	hdr.Extra = ntfsExtra(fileTimes{modified: hdr.Modified, accessed: hdr.Modified, created: hdr.Modified})
+
+	c, err := encodeEntry(w.o.source(r), hdr, w.o.methodFor(clean), w.o.manifest, w.o)
+	if err != nil {
+		return err
+	}
+	defer c.data.Release()
+
+	original := w.seen.original(c)
+	if original == "" {
+		if err := writeCompressed(w.zipw, c, w.o); err != nil {
+			return err
+		}
+	}
+
+	entry := c.manifestEntry()
+	entry.Groups = w.o.groupsFor(entry.Name)
+	info := headerInfo(c.hdr)
+	if original != "" {
+		entry.CompressedSize = 0
+		entry.Duplicate = original
+		info.CompressedSize = 0
+	}
+	w.written = append(w.written, entry)
+	w.o.fileAdded(info, true)
+	w.o.entryDone(info)
+	return nil
+}
+
+// AddBytes adds an entry called name holding data, as AddReader does.
+func (w *Writer) AddBytes(name string, mode fs.FileMode, mtime time.Time, data []byte) error {
+	return w.AddReader(name, mode, mtime, bytes.NewReader(data))
+}
+
+// entryName checks that name given for an entry is relative and stays
+// below the archive's root, returning it cleaned and normalized.
+func (w *Writer) entryName(name string) (string, error) {
+	clean, err := sanitizeName(name)
+	if err == nil && clean == "." {
+		err = invalidPath("name is empty")
+	}
+	if err != nil {
+		return "", &PathError{Op: "add", Entry: name, Err: err}
+	}
+	return w.o.normalize(clean), nil
+}
+
+// claim records that an entry called name is to be written for source,
+// returning the name to write it under, by WithDuplicates if an earlier
+// entry took name, or "" if it is to be left out.
+func (w *Writer) claim(name, source string) (string, error) {
+	if w.names[name] {
+		switch w.o.duplicates {
+		case CollisionSuffix:
+			name = suffixedName(name, w.names)
+		case CollisionKeepFirst:
+			w.o.logSkip(source, "is named the same as an earlier entry")
+			return "", nil
+		default:
+			return "", &PathError{Op: "add", Entry: name, Err: fmt.Errorf("already added: %w", fs.ErrExist)}
+		}
+	}
+	w.names[name] = true
+	return name, nil
+}
+
+// SetComment sets the archive's comment, written by Close.
+func (w *Writer) SetComment(comment string) error {
+	return w.zipw.SetComment(comment)
+}
+
+// Close finishes the archive, writing the manifest, if asked for, and the
+// central directory. It does not close the underlying writer. With
+// WithContinueOnError, it returns a *PartialError listing the files
+// left out of the archive, which is still complete.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer releaseWriter(w.bw)
+
+	if w.o.manifest {
+		if err := writeManifestEntry(w.zipw, &Manifest{Entries: w.written}, w.o); err != nil {
+			return err
+		}
+	}
+	if err := w.zipw.Close(); err != nil {
+		return err
+	}
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+
+	w.o.finish(w.start)
+	return w.o.partialError()
+}
@@ -0,0 +1,128 @@
+package zipper
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWriterAddReader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithManifest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := w.AddReader("reports/summary.txt", 0640, mtime, strings.NewReader("all good")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.AddBytes("config.json", 0644, mtime, []byte(`{"debug":false}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.AddBytes("../escape.txt", 0644, mtime, nil); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("expected an invalid path, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"reports/summary.txt": "all good", "config.json": `{"debug":false}`}
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(data) != want[f.Name] {
+			t.Errorf("%s: expected %q, got %q", f.Name, want[f.Name], data)
+		}
+		if !f.Modified.Equal(mtime) {
+			t.Errorf("%s: expected modified %v, got %v", f.Name, mtime, f.Modified)
+		}
+		delete(want, f.Name)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing entries: %v", want)
+	}
+	if mode := r.File[0].Mode(); mode != 0640 {
+		t.Errorf("expected mode 0640, got %v", mode)
+	}
+
+	m, err := readEmbeddedManifest(r)
+	if err != nil || len(m.Entries) != 2 {
+		t.Errorf("expected a manifest of 2 entries, got %+v, %v", m, err)
+	}
+}
+
+func TestWriterCompose(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "site")
+	writeTree(t, src, map[string]string{"index.html": "<html>", "css/main.css": "body{}", "notes.tmp": "scratch"})
+	readme := filepath.Join(dir, "README")
+	if err := os.WriteFile(readme, []byte("read me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithExclude("*.tmp"), WithRootDir("release"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	steps := []error{
+		w.AddDir(src, "www"),
+		w.AddFile(readme, ""),
+		w.AddFile(readme, "docs/README.txt"),
+		w.AddFS(fstest.MapFS{"extra/version": {Data: []byte("1.0")}, "extra/junk.tmp": {Data: []byte("x")}}),
+		w.SetComment("release 1.0"),
+		w.Close(),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	want := []string{
+		"release/www/css/main.css", "release/www/index.html", "release/README",
+		"release/docs/README.txt", "release/extra/version",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected entries %v, got %v", want, names)
+	}
+	if r.Comment != "release 1.0" {
+		t.Errorf("expected the comment, got %q", r.Comment)
+	}
+
+	if err := w.AddBytes("late", 0644, time.Now(), nil); err == nil {
+		t.Error("expected an error adding to a closed Writer")
+	}
+	w, _ = NewWriter(io.Discard)
+	if err := w.AddDir(readme, ""); err == nil {
+		t.Error("expected an error adding a file as a directory")
+	}
+}
@@ -3,163 +3,412 @@ package zipper
 import (
 	"archive/zip"
 	"errors"
-	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-func Zip(inPath string) (string, error) {
+func Zip(inPath string, opts ...Option) (string, error) {
+	return ZipPaths([]string{inPath}, opts...)
+}
+
+// ZipPaths archives several files and directories into one archive, like
+// Zip does one. Entries are named by their paths relative to the closest
+// directory holding every input, so "a.txt", "dir1" and "dir2" from the
+// same directory give "a.txt", "dir1/..." and "dir2/...", and the archive
+// is named after that directory unless WithOutput says otherwise. Include
+// and exclude patterns match those names, while WithMaxDepth counts from
+// each input. A file under more than one input is archived once.
+//
+// With a single input it is the same as Zip.
+func ZipPaths(inPaths []string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return "", err
+	}
 
 	// short validation on path
-	inPath = filepath.Clean(inPath)
+	root, inputs, err := splitInputs(inPaths)
+	if err != nil {
+		return "", err
+	}
+	name, err := archiveName(root, o)
+	if err != nil {
+		return "", err
+	}
+	root, inputs = longPaths(root, inputs)
+	dstPath := o.outputPath(name)
+	if err := checkOutput(dstPath, o); err != nil {
+		return "", err
+	}
+
+	// the archive may be written below an input
+	output := dstPath
+	if o.sink != nil {
+		output = ""
+	}
+	start := time.Now()
+	o.log(slog.LevelInfo, "walking", "root", root, "inputs", len(inputs))
+	files, err := collectFiles(root, inputs, output, o)
+	if err != nil {
+		return "", err
+	}
+
+	files, diff, err := diffBase(root, files, o)
+	if err != nil {
+		return "", err
+	}
+
+	// report what would be archived without writing anything
+	if o.dryRun != nil {
+		if err := planEntries(root, files, o); err != nil {
+			return "", err
+		}
+		return dstPath, o.partialError()
+	}
+
+	// create new file
+	out, err := createOutput(dstPath, o)
+	if err != nil {
+		return "", err
+	}
+
+	// discard partial output on failure
+	completed := false
+	defer func() {
+		if !completed {
+			out.Abort()
+		}
+	}()
+
+	if err := writeArchive(out, dstPath, root, files, diff, o); err != nil {
+		return "", err
+	}
+
+	if err := out.Commit(); err != nil {
+		return "", err
+	}
+
+	if o.sfxStub != "" && o.sink == nil {
+		if err := os.Chmod(dstPath, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	completed = true
+
+	o.finish(start)
+	o.log(slog.LevelInfo, "wrote archive", "path", dstPath, "files", len(files), "failed", len(o.failed), "duration", time.Since(start))
+	return dstPath, o.partialError()
+}
+
+// ZipTo writes an archive of inPath to w instead of a file, streaming it
+// as it is produced, for example to a network connection. It takes the
+// same options as Zip, except WithSplitSize, and nothing is written to
+// disk beyond the spill files of large entries. If it fails, what was
+// written to w is incomplete.
+func ZipTo(w io.Writer, inPath string, opts ...Option) error {
+	return ZipPathsTo(w, []string{inPath}, opts...)
+}
+
+// ZipPathsTo writes an archive of several files and directories to w, as
+// ZipPaths names them and ZipTo writes them.
+func ZipPathsTo(w io.Writer, inPaths []string, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return err
+	}
+	if o.splitSize > 0 || o.resume {
+		return errors.New("split and resumable archives can only be written to files")
+	}
+
+	root, inputs, err := splitInputs(inPaths)
+	if err != nil {
+		return err
+	}
+	name, err := archiveName(root, o)
+	if err != nil {
+		return err
+	}
+	root, inputs = longPaths(root, inputs)
+
+	start := time.Now()
+	o.log(slog.LevelInfo, "walking", "root", root, "inputs", len(inputs))
+	files, err := collectFiles(root, inputs, "", o)
+	if err != nil {
+		return err
+	}
+
+	files, diff, err := diffBase(root, files, o)
+	if err != nil {
+		return err
+	}
+
+	if o.dryRun != nil {
+		if err := planEntries(root, files, o); err != nil {
+			return err
+		}
+		return o.partialError()
+	}
+
+	if err := writeArchive(o.throttleWriter(w), name, root, files, diff, o); err != nil {
+		return err
+	}
+	o.finish(start)
+	o.log(slog.LevelInfo, "wrote archive", "name", name, "files", len(files), "failed", len(o.failed), "duration", time.Since(start))
+	return o.partialError()
+}
+
+// splitInputs returns the directory entries are named relative to and the
+// cleaned inputs below it. A single input is its own root.
+func splitInputs(inPaths []string) (string, []string, error) {
+	switch len(inPaths) {
+	case 0:
+		return "", nil, errors.New("no paths to archive")
+	case 1:
+		inPath := filepath.Clean(inPaths[0])
+		return inPath, []string{inPath}, nil
+	}
+
+	// relative inputs may climb above the working directory
+	inputs := make([]string, len(inPaths))
+	for i, p := range inPaths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", nil, err
+		}
+		inputs[i] = abs
+	}
+
+	root := filepath.Dir(inputs[0])
+	for _, in := range inputs[1:] {
+		for !isBelow(in, root) {
+			root = filepath.Dir(root)
+		}
+	}
+	return root, inputs, nil
+}
+
+// longPaths returns root and inputs in the form walking them needs for
+// deep trees, extended-length on Windows.
+func longPaths(root string, inputs []string) (string, []string) {
+	long := make([]string, len(inputs))
+	for i, in := range inputs {
+		long[i] = longPath(in)
+	}
+	return longPath(root), long
+}
+
+// isBelow reports whether the absolute path is strictly below dir.
+func isBelow(path, dir string) bool {
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// archiveName returns the file name of the archive of inPath.
+func archiveName(inPath string, o *options) (string, error) {
 	if inPath == "." || inPath == ".." {
-		return "", errors.New("invalid path")
+		return "", &PathError{Op: "zip", Entry: inPath, Err: ErrInvalidPath}
 	}
 
-	dstPath := filepath.Base(inPath)
-	if dstPath == "" || dstPath == "." || dstPath == ".." {
-		return "", errors.New("invalid path")
+	name := filepath.Base(inPath)
+	if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", &PathError{Op: "zip", Entry: inPath, Err: ErrInvalidPath}
 	}
 
-	dstPath = fmt.Sprintf("%s.zip", dstPath)
+	if o.sfxStub != "" {
+		return name + sfxExt(o.sfxStub), nil
+	}
+	return name + o.format.ext(), nil
+}
+
+// collectFiles returns the files to archive below each of inputs, leaving
+// out the archive at dstPath and the files written alongside it, if
+// dstPath is not empty. Entries are named relative to root.
+func collectFiles(root string, inputs []string, dstPath string, o *options) ([]string, error) {
+	if dstPath != "" {
+		var err error
+		if dstPath, err = filepath.Abs(dstPath); err != nil {
+			return nil, err
+		}
+		dstPath = longPath(dstPath)
+	}
 
 	// collect all files in the path recursivley
 	files := make([]string, 0)
-	if err := filepath.WalkDir(inPath, func(path string, d fs.DirEntry, err error) error {
+	seen := make(map[string]bool)
+	for _, in := range inputs {
+		if _, err := os.Lstat(in); errors.Is(err, fs.ErrNotExist) {
+			return nil, &PathError{Op: "zip", Entry: in, Err: ErrNotFound}
+		}
 
+		w, err := newWalker(in, root, o)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if !d.IsDir() {
-			files = append(files, path)
+		if err := w.walk(func(path string) error {
+			// inputs may overlap
+			if !isOutputFile(path, dstPath) && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
 		}
+	}
 
-		return nil
-	}); err != nil {
-		return "", err
+	if o.format == FormatGzip && (len(files) != 1 || files[0] != root) {
+		return nil, errors.New("gzip format requires a single file")
 	}
 
-	// create new file
-	zipFile, err := os.Create(dstPath)
+	order := o.order
+	if order == OrderWalk && o.reproducible {
+		order = OrderName
+	}
+	if err := sortFiles(files, order); err != nil {
+		return nil, err
+	}
+
+	// names only clash once flattened, renamed or normalized, and clashes
+	// are resolved in the order entries are written
+	if o.flatten || o.rename != nil || o.normalization != NormalizeNone {
+		return resolveNames(root, files, o)
+	}
+
+	return files, nil
+}
+
+// isOutputFile reports whether path is the archive at the absolute path
+// dstPath, or its temporary file, resume journal or split volumes.
+func isOutputFile(path, dstPath string) bool {
+	if dstPath == "" {
+		return false
+	}
+
+	// only names sharing the archive's stem need resolving
+	stem := strings.TrimSuffix(dstPath, ".zip")
+	if !strings.HasPrefix(filepath.Base(path), filepath.Base(stem)) {
+		return false
+	}
+	abs, err := filepath.Abs(path)
 	if err != nil {
-		return "", err
+		return false
 	}
 
-	// defer outfile closing
-	completed := false
-	defer func() {
-		zipFile.Close()
-		if !completed {
-			os.Remove(dstPath)
+	switch abs {
+	case dstPath, dstPath + tempSuffix, dstPath + journalSuffix:
+		return true
+	}
+
+	// volumes are numbered "<stem>.z01" onwards
+	n, ok := strings.CutPrefix(abs, stem+".z")
+	if !ok || len(n) < 2 {
+		return false
+	}
+	for i := 0; i < len(n); i++ {
+		if n[i] < '0' || n[i] > '9' {
+			return false
 		}
-	}()
+	}
+	return true
+}
 
-	// create new zip writer
-	zipw := zip.NewWriter(zipFile)
+// writeArchive writes files below root to w in the configured format.
+// name is the archive's file name, and diff what an incremental archive
+// records about its base.
+func writeArchive(w io.Writer, name, root string, files []string, diff *baseDiff, o *options) error {
+	switch o.format {
+	case FormatZip:
+		return writeZip(w, name, root, files, diff, o)
+	case FormatGzip:
+		return writeGzip(w, root, o)
+	default:
+		return writeTarball(w, root, files, o)
+	}
+}
 
-	if err := func() error {
-		for _, file := range files {
-			f, err := os.Open(file)
-			if err != nil {
-				return err
-			}
+// writeZip writes files below root to out as a zip archive.
+func writeZip(out io.Writer, dstPath, root string, files []string, diff *baseDiff, o *options) error {
+	// create new zip writer, which adopts the buffer as its own
+	bw := o.bufferedWriter(o.meterWriter(out))
+	defer releaseWriter(bw)
+	zipw := zip.NewWriter(bw)
 
-			relPath, err := filepath.Rel(inPath, file)
-			if err != nil {
-				f.Close()
-				return err
-			}
+	// the extractor stub goes first, with the archive offsets after it
+	if o.sfxStub != "" {
+		n, err := writeStub(out, o.sfxStub)
+		if err != nil {
+			return err
+		}
+		zipw.SetOffset(n)
+	}
 
-			zw, err := zipw.Create(relPath)
-			if err != nil {
-				f.Close()
-				return err
-			}
+	// hard links share the contents of an earlier file
+	var links []hardLink
+	if o.hardLinks {
+		var err error
+		if files, links, err = splitHardLinks(root, files, o); err != nil {
+			return err
+		}
+	}
 
-			if _, err := io.Copy(zw, f); err != nil {
-				f.Close()
-				return err
-			}
+	var seen dedup
+	if o.dedup {
+		seen = make(dedup)
+	}
 
-			f.Close()
+	// continue an earlier attempt
+	var resumed []ManifestEntry
+	var done func(compressed) error
+	if r, ok := out.(*resumableOutput); ok {
+		if err := r.replay(zipw); err != nil {
+			return err
+		}
+		files = r.remaining(root, files, o)
+		resumed = r.written(o)
+		for _, e := range resumed {
+			if seen != nil && e.SHA256 != "" && e.Size > 0 {
+				seen[e.SHA256] = e.Name
+			}
+		}
+		done = func(c compressed) error {
+			return r.record(zipw, c)
 		}
-		return zipw.Close()
-	}(); err != nil {
-		return "", err
 	}
+	o.progress.expectFiles(files)
 
-	completed = true
+	written, err := writeEntries(zipw, root, files, seen, done, o)
+	if err != nil {
+		return err
+	}
+	written = append(resumed, written...)
 
-	return dstPath, nil
-}
+	linked, err := linkEntries(links, written, o)
+	if err != nil {
+		return err
+	}
+	written = append(written, linked...)
 
-// Source - https://stackoverflow.com/a
-// Posted by Astockwell, modified by community. See post 'Timeline' for change history
-// Retrieved 2026-01-06, License - CC BY-SA 4.0
-
-// func Unzip(src, dest string) error {
-// 	r, err := zip.OpenReader(src)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer func() {
-// 		if err := r.Close(); err != nil {
-// 			panic(err)
-// 		}
-// 	}()
-
-// 	os.MkdirAll(dest, 0755)
-
-// 	// Closure to address file descriptors issue with all the deferred .Close() methods
-// 	extractAndWriteFile := func(f *zip.File) error {
-// 		rc, err := f.Open()
-// 		if err != nil {
-// 			return err
-// 		}
-// 		defer func() {
-// 			if err := rc.Close(); err != nil {
-// 				panic(err)
-// 			}
-// 		}()
-
-// 		path := filepath.Join(dest, f.Name)
-
-// 		// Check for ZipSlip (Directory traversal)
-// 		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
-// 			return fmt.Errorf("illegal file path: %s", path)
-// 		}
-
-// 		if f.FileInfo().IsDir() {
-// 			os.MkdirAll(path, f.Mode())
-// 		} else {
-// 			os.MkdirAll(filepath.Dir(path), f.Mode())
-// 			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-// 			if err != nil {
-// 				return err
-// 			}
-// 			defer func() {
-// 				if err := f.Close(); err != nil {
-// 					panic(err)
-// 				}
-// 			}()
-
-// 			_, err = io.Copy(f, rc)
-// 			if err != nil {
-// 				return err
-// 			}
-// 		}
-// 		return nil
-// 	}
-
-// 	for _, f := range r.File {
-// 		err := extractAndWriteFile(f)
-// 		if err != nil {
-// 			return err
-// 		}
-// 	}
-
-// 	return nil
-// }
+	if o.manifest {
+		m := &Manifest{Archive: filepath.Base(dstPath), Entries: written}
+		if diff != nil {
+			m.Base, m.Inherited, m.Deleted = diff.base, diff.inherited, diff.deleted
+		}
+		if err := writeManifestEntry(zipw, m, o); err != nil {
+			return err
+		}
+	}
+
+	if err := zipw.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
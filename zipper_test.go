@@ -2,10 +2,14 @@ package zipper
 
 import (
 	"archive/zip"
-	"io"
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/irrisdev/go-zip/zipptest"
 )
 
 func TestZip(t *testing.T) {
@@ -14,7 +18,7 @@ func TestZip(t *testing.T) {
 		setup       func(t *testing.T) string
 		cleanup     func(t *testing.T, path string)
 		expectError bool
-		errorMsg    string
+		wantErr     error
 	}{
 		{
 			name: "zip single file",
@@ -78,7 +82,7 @@ func TestZip(t *testing.T) {
 			},
 			cleanup:     func(t *testing.T, path string) {},
 			expectError: true,
-			errorMsg:    "invalid path",
+			wantErr:     ErrInvalidPath,
 		},
 		{
 			name: "invalid path - double dot",
@@ -87,7 +91,7 @@ func TestZip(t *testing.T) {
 			},
 			cleanup:     func(t *testing.T, path string) {},
 			expectError: true,
-			errorMsg:    "invalid path",
+			wantErr:     ErrInvalidPath,
 		},
 		{
 			name: "nonexistent path",
@@ -96,6 +100,7 @@ func TestZip(t *testing.T) {
 			},
 			cleanup:     func(t *testing.T, path string) {},
 			expectError: true,
+			wantErr:     ErrNotFound,
 		},
 	}
 
@@ -110,8 +115,12 @@ func TestZip(t *testing.T) {
 				if err == nil {
 					t.Errorf("expected error but got none")
 				}
-				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
-					t.Errorf("expected error message %q, got %q", tt.errorMsg, err.Error())
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				var pathErr *PathError
+				if tt.wantErr != nil && (!errors.As(err, &pathErr) || pathErr.Entry != filepath.Clean(inPath)) {
+					t.Errorf("expected a PathError for %s, got %v", inPath, err)
 				}
 				return
 			}
@@ -129,7 +138,7 @@ func TestZip(t *testing.T) {
 			}
 
 			// Verify zip file can be opened and contains expected files
-			verifyZipContents(t, zipPath, inPath)
+			zipptest.AssertRoundTrip(t, zipPath, inPath)
 		})
 	}
 }
@@ -192,65 +201,188 @@ func TestZipCleanupOnError(t *testing.T) {
 	}
 }
 
-func verifyZipContents(t *testing.T, zipPath, originalPath string) {
-	t.Helper()
+func TestZipAtomic(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "atomic")
+	writeTree(t, src, map[string]string{"a.txt": "alpha"})
+	zipPath := "atomic.zip"
+	defer os.Remove(zipPath)
 
-	// Open the zip file
-	zr, err := zip.OpenReader(zipPath)
-	if err != nil {
-		t.Fatalf("failed to open zip file: %v", err)
+	if _, err := Zip(src); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(zipPath + tempSuffix); !os.IsNotExist(err) {
+		t.Error("expected no temporary file after success")
 	}
-	defer zr.Close()
 
-	// Collect original files
-	originalFiles := make(map[string][]byte)
-	err = filepath.WalkDir(originalPath, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
-			return err
-		}
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		originalFiles[path] = content
-		return nil
+	// a failed attempt leaves the earlier archive untouched
+	if err := os.Symlink("missing", filepath.Join(src, "broken")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Zip(src); err == nil {
+		t.Fatal("expected the broken symlink to fail the archive")
+	}
+	if _, err := os.Stat(zipPath + tempSuffix); !os.IsNotExist(err) {
+		t.Error("expected the temporary file to be removed")
+	}
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 1 || string(files["a.txt"]) != "alpha" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
+
+func TestZipExcludesOutput(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "self")
+	writeTree(t, src, map[string]string{
+		"a.txt":        "alpha",
+		"self.zip":     "stale archive",
+		"self.zip.tmp": "stale temporary file",
+		"self.z01":     "stale volume",
+		"self.zipper":  "kept",
 	})
+
+	// write the archive inside the tree being archived
+	wd, err := os.Getwd()
 	if err != nil {
-		t.Fatalf("failed to walk original directory: %v", err)
+		t.Fatal(err)
+	}
+	if err := os.Chdir(src); err != nil {
+		t.Fatal(err)
 	}
+	defer os.Chdir(wd)
 
-	// Verify each file in the zip
-	for _, f := range zr.File {
-		originalContent, exists := originalFiles[f.Name]
-		if !exists {
-			t.Errorf("unexpected file in zip: %s", f.Name)
-			continue
-		}
+	zipPath, err := Zip(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-		rc, err := f.Open()
-		if err != nil {
-			t.Errorf("failed to open file in zip %s: %v", f.Name, err)
-			continue
-		}
+	files := zipptest.ReadArchive(t, zipPath)
+	if len(files) != 2 || string(files["a.txt"]) != "alpha" || string(files["self.zipper"]) != "kept" {
+		t.Errorf("unexpected contents: %q", files)
+	}
+}
 
-		zipContent, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			t.Errorf("failed to read file in zip %s: %v", f.Name, err)
-			continue
-		}
+func TestZipTimeZonePolicy(t *testing.T) {
+	// run in a zone far from UTC so local and UTC fields differ
+	origLocal := time.Local
+	time.Local = time.FixedZone("UTC+10", 10*60*60)
+	defer func() { time.Local = origLocal }()
 
-		if string(zipContent) != string(originalContent) {
-			t.Errorf("content mismatch for %s", f.Name)
-		}
+	mtime := time.Date(2024, 3, 15, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   TimeZonePolicy
+		wantHour int
+	}{
+		{name: "local", policy: TimeZoneLocal, wantHour: mtime.In(time.Local).Hour()},
+		{name: "utc", policy: TimeZoneUTC, wantHour: mtime.Hour()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			testFile := filepath.Join(dir, "tz.txt")
+			if err := os.WriteFile(testFile, []byte("content"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(testFile, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
 
-		delete(originalFiles, f.Name)
+			zipPath, err := Zip(testFile, WithTimeZonePolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(zipPath)
+
+			zr, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+
+			f := zr.File[0]
+			if !f.Modified.Equal(mtime) {
+				t.Errorf("expected modified %v, got %v", mtime, f.Modified)
+			}
+
+			// MS-DOS time packs the hour into the top five bits
+			if hour := int(f.ModifiedTime >> 11); hour != tt.wantHour {
+				t.Errorf("expected MS-DOS hour %d, got %d", tt.wantHour, hour)
+			}
+		})
 	}
+}
 
-	// Check if any original files were not included in the zip
-	if len(originalFiles) > 0 {
-		for path := range originalFiles {
-			t.Errorf("file not found in zip: %s", path)
+func TestZipTo(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "to-src")
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	var buf bytes.Buffer
+	if err := ZipTo(&buf, src); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "to.zip")
+	if err := os.WriteFile(zipPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	zipptest.AssertRoundTrip(t, zipPath, src)
+
+	if _, err := os.Stat("to-src.zip"); !os.IsNotExist(err) {
+		t.Error("expected no archive file to be written")
+	}
+
+	if err := ZipTo(&buf, src, WithSplitSize(minSplitSize)); err == nil {
+		t.Error("expected an error splitting a streamed archive")
+	}
+}
+
+func TestZipPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+	writeTree(t, dir, map[string]string{
+		"a.txt":           "alpha",
+		"dir1/b.txt":      "beta",
+		"dir1/sub/c.txt":  "gamma",
+		"dir2/d.txt":      "delta",
+		"left-out/e.txt":  "epsilon",
+		"dir2/skip/f.tmp": "zeta",
+		"dir2/skip/g.txt": "eta",
+		"elsewhere/h.txt": "theta",
+	})
+
+	inputs := []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "dir1"),
+		filepath.Join(dir, "dir1", "sub"),
+		filepath.Join(dir, "dir2"),
+	}
+	zipPath, err := ZipPaths(inputs, WithExclude("*.tmp"), WithOutput(t.TempDir()+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Base(zipPath) != "bundle.zip" {
+		t.Errorf("expected the archive named after the common directory, got %s", zipPath)
+	}
+
+	got := zipptest.ReadArchive(t, zipPath)
+	want := map[string]string{
+		"a.txt":           "alpha",
+		"dir1/b.txt":      "beta",
+		"dir1/sub/c.txt":  "gamma",
+		"dir2/d.txt":      "delta",
+		"dir2/skip/g.txt": "eta",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected entries %v, got %v", want, got)
+	}
+	for name, body := range want {
+		if string(got[name]) != body {
+			t.Errorf("%s: expected %q, got %q", name, body, got[name])
 		}
 	}
+
+	if _, err := ZipPaths(nil); err == nil {
+		t.Error("expected an error for no paths")
+	}
 }
@@ -0,0 +1,148 @@
+// Package zipptest provides helpers for verifying zip archives in tests.
+//
+// The helpers compare archives against the files they were built from,
+// against other archives, and compare directory trees, which is useful
+// for asserting on archives created (or extracted) with go-zip.
+package zipptest
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// ReadArchive returns the contents of every file entry in the archive at
+// zipPath keyed by entry name. Directory entries are skipped.
+func ReadArchive(t testing.TB, zipPath string) map[string][]byte {
+	t.Helper()
+
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("failed to open zip file: %v", err)
+	}
+	defer zr.Close()
+
+	contents := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open file in zip %s: %v", f.Name, err)
+		}
+
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read file in zip %s: %v", f.Name, err)
+		}
+
+		contents[f.Name] = data
+	}
+
+	return contents
+}
+
+// ReadTree returns the contents of every regular file under root keyed by
+// its slash-separated path relative to root. If root is a file, the single
+// entry is keyed by its base name, mirroring how it is stored when zipped.
+func ReadTree(t testing.TB, root string) map[string][]byte {
+	t.Helper()
+
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", root, err)
+	}
+
+	contents := make(map[string][]byte)
+	if !info.IsDir() {
+		data, err := os.ReadFile(root)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", root, err)
+		}
+		contents[filepath.Base(root)] = data
+		return contents
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		contents[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk %s: %v", root, err)
+	}
+
+	return contents
+}
+
+// AssertRoundTrip checks that the archive at zipPath holds exactly the
+// files found at srcPath, with matching contents.
+func AssertRoundTrip(t testing.TB, zipPath, srcPath string) {
+	t.Helper()
+	compare(t, "zip", ReadArchive(t, zipPath), "source", ReadTree(t, srcPath))
+}
+
+// AssertArchivesEqual checks that the archives at a and b hold the same
+// set of file entries with the same contents.
+func AssertArchivesEqual(t testing.TB, a, b string) {
+	t.Helper()
+	compare(t, a, ReadArchive(t, a), b, ReadArchive(t, b))
+}
+
+// AssertTreesEqual checks that the directory trees rooted at a and b hold
+// the same set of files with the same contents.
+func AssertTreesEqual(t testing.TB, a, b string) {
+	t.Helper()
+	compare(t, a, ReadTree(t, a), b, ReadTree(t, b))
+}
+
+// compare reports every name missing from either side and every name
+// whose contents differ, in sorted order for stable output.
+func compare(t testing.TB, aName string, a map[string][]byte, bName string, b map[string][]byte) {
+	t.Helper()
+
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		aData, inA := a[name]
+		bData, inB := b[name]
+		switch {
+		case !inA:
+			t.Errorf("%s not found in %s", name, aName)
+		case !inB:
+			t.Errorf("%s not found in %s", name, bName)
+		case !bytes.Equal(aData, bData):
+			t.Errorf("content mismatch for %s", name)
+		}
+	}
+}
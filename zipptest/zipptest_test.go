@@ -0,0 +1,95 @@
+package zipptest
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recorder captures failures instead of failing the enclosing test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := map[string]string{"a.txt": "a", "sub/b.txt": "b"}
+	writeTree(t, src, files)
+
+	zipPath := filepath.Join(dir, "src.zip")
+	writeZip(t, zipPath, files)
+
+	AssertRoundTrip(t, zipPath, src)
+}
+
+func TestAssertArchivesEqualReportsDifferences(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.zip")
+	b := filepath.Join(dir, "b.zip")
+	writeZip(t, a, map[string]string{"same.txt": "x", "changed.txt": "1", "only-a.txt": "a"})
+	writeZip(t, b, map[string]string{"same.txt": "x", "changed.txt": "2", "only-b.txt": "b"})
+
+	r := &recorder{TB: t}
+	AssertArchivesEqual(r, a, b)
+
+	if len(r.errors) != 3 {
+		t.Fatalf("expected 3 reported differences, got %d: %v", len(r.errors), r.errors)
+	}
+}
+
+func TestAssertTreesEqual(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "a", "nested/deep/c.txt": "c"}
+	writeTree(t, filepath.Join(dir, "one"), files)
+	writeTree(t, filepath.Join(dir, "two"), files)
+
+	AssertTreesEqual(t, filepath.Join(dir, "one"), filepath.Join(dir, "two"))
+}